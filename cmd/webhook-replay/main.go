@@ -0,0 +1,306 @@
+// Command webhook-replay re-signs stored MartianPay webhook events and POSTs them to a local handler.
+// It is meant for reproducing production webhook bugs against a development server.
+//
+// Inputs can be single event JSON files, JSON arrays of events, JSONL captures with one event
+// per line, or inbox dead-letter files (whose lines wrap the original payload). Directories are
+// walked for .json and .jsonl files. Each payload is signed byte for byte, so the handler sees
+// exactly the body that was captured.
+//
+// Usage:
+//
+//	go run ./cmd/webhook-replay -url http://localhost:8080/v1/webhook_test \
+//	    -secret whsec_... -type 'payment_intent.*' -rate 2 captures/
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// storedEvent is a raw event payload read from disk
+type storedEvent struct {
+	// Source is the file (and line, for JSONL) the event was read from
+	Source string
+	// ID is the event ID
+	ID string
+	// Type is the event type
+	Type developer.EventType
+	// Payload is the raw event JSON exactly as stored
+	Payload []byte
+}
+
+// eventHeader holds the fields needed to route and filter an event
+type eventHeader struct {
+	ID   string              `json:"id"`
+	Type developer.EventType `json:"type"`
+	// EventID and RawPayload are set when the line is an inbox record
+	EventID    string          `json:"event_id"`
+	RawPayload json.RawMessage `json:"payload"`
+}
+
+func main() {
+	target := flag.String("url", "", "local webhook URL to POST events to (required)")
+	secret := flag.String("secret", os.Getenv("MARTIANPAY_WEBHOOK_SECRET"), "webhook signing secret (default $MARTIANPAY_WEBHOOK_SECRET)")
+	timestamp := flag.String("timestamp", "now", `signature timestamp: "now", "original" (event created time) or Unix seconds`)
+	types := flag.String("type", "", "comma-separated event types to replay, e.g. 'payment_intent.succeeded,payout.*' (default all)")
+	rate := flag.Float64("rate", 0, "maximum events per second (0 for no limit)")
+	limit := flag.Int("limit", 0, "stop after this many events (0 for no limit)")
+	timeout := flag.Duration("timeout", 30*time.Second, "HTTP request timeout")
+	dryRun := flag.Bool("dry-run", false, "print the signature header for each event without sending it")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] FILE|DIR...\n\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || (*target == "" && !*dryRun) || *secret == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	events, err := loadEvents(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	events = filterEvents(events, splitPatterns(*types))
+	if *limit > 0 && len(events) > *limit {
+		events = events[:*limit]
+	}
+	if len(events) == 0 {
+		fmt.Println("No events matched")
+		return
+	}
+
+	var interval time.Duration
+	if *rate > 0 {
+		interval = time.Duration(float64(time.Second) / *rate)
+	}
+	client := &http.Client{Timeout: *timeout}
+
+	failures := 0
+	for i, ev := range events {
+		if i > 0 && interval > 0 {
+			time.Sleep(interval)
+		}
+
+		ts, err := signingTime(*timestamp, ev)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", ev.Source, err)
+			os.Exit(2)
+		}
+		sig := developer.SignPayload(ev.Payload, ts, *secret)
+
+		fmt.Printf("→ [%d/%d] %s %s (%s)\n", i+1, len(events), ev.ID, ev.Type, ev.Source)
+		if *dryRun {
+			fmt.Printf("  %s: %s\n", developer.MartianPaySignature, sig)
+			continue
+		}
+
+		status, body, elapsed, err := post(client, *target, ev.Payload, sig)
+		if err != nil {
+			failures++
+			fmt.Printf("  ✗ %v\n", err)
+			continue
+		}
+		mark := "✓"
+		if status < 200 || status >= 300 {
+			mark = "✗"
+			failures++
+		}
+		fmt.Printf("  %s %d %s (%s)\n", mark, status, http.StatusText(status), elapsed.Round(time.Millisecond))
+		if len(body) > 0 {
+			fmt.Printf("  %s\n", strings.TrimSpace(string(body)))
+		}
+	}
+
+	fmt.Printf("\nReplayed %d event(s), %d failed\n", len(events), failures)
+	if failures > 0 {
+		os.Exit(1)
+	}
+}
+
+// splitPatterns parses the comma-separated -type flag
+func splitPatterns(s string) []string {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// filterEvents keeps the events whose type matches any of the patterns
+func filterEvents(events []*storedEvent, patterns []string) []*storedEvent {
+	if len(patterns) == 0 {
+		return events
+	}
+	var kept []*storedEvent
+	for _, ev := range events {
+		for _, p := range patterns {
+			if ev.Type.Matches(p) {
+				kept = append(kept, ev)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// signingTime resolves the -timestamp flag for an event
+func signingTime(flagValue string, ev *storedEvent) (time.Time, error) {
+	switch flagValue {
+	case "", "now":
+		return time.Now(), nil
+	case "original":
+		var e struct {
+			Created int64 `json:"created"`
+		}
+		if err := json.Unmarshal(ev.Payload, &e); err != nil || e.Created == 0 {
+			return time.Time{}, fmt.Errorf("event has no created timestamp")
+		}
+		return time.Unix(e.Created, 0), nil
+	default:
+		secs, err := strconv.ParseInt(flagValue, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid -timestamp %q", flagValue)
+		}
+		return time.Unix(secs, 0), nil
+	}
+}
+
+// post sends one signed payload and returns the handler's response
+func post(client *http.Client, target string, payload []byte, sig string) (int, []byte, time.Duration, error) {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, 0, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(developer.MartianPaySignature, sig)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, 0, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, body, time.Since(start), nil
+}
+
+// loadEvents reads every event from the given files and directories, in order
+func loadEvents(paths []string) ([]*storedEvent, error) {
+	var events []*storedEvent
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if path != root && ext != ".json" && ext != ".jsonl" {
+				return nil
+			}
+			loaded, err := loadFile(path)
+			if err != nil {
+				return err
+			}
+			events = append(events, loaded...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// loadFile reads a JSON event, a JSON array of events or a JSONL capture
+func loadFile(path string) ([]*storedEvent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	// A whole-file JSON value: either one event or an array of events
+	if json.Valid(trimmed) {
+		if trimmed[0] == '[' {
+			var raws []json.RawMessage
+			if err := json.Unmarshal(trimmed, &raws); err != nil {
+				return nil, fmt.Errorf("error parsing %s: %v", path, err)
+			}
+			var events []*storedEvent
+			for i, raw := range raws {
+				ev, err := parseEvent(fmt.Sprintf("%s[%d]", path, i), raw)
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, ev)
+			}
+			return events, nil
+		}
+		if !bytes.Contains(trimmed, []byte("\n")) || strings.ToLower(filepath.Ext(path)) != ".jsonl" {
+			ev, err := parseEvent(path, trimmed)
+			if err != nil {
+				return nil, err
+			}
+			return []*storedEvent{ev}, nil
+		}
+	}
+
+	// Otherwise treat the file as JSONL
+	var events []*storedEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		ev, err := parseEvent(fmt.Sprintf("%s:%d", path, line), append([]byte(nil), raw...))
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	return events, nil
+}
+
+// parseEvent extracts the routing fields of a raw event, unwrapping inbox records
+func parseEvent(source string, raw []byte) (*storedEvent, error) {
+	var h eventHeader
+	if err := json.Unmarshal(raw, &h); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", source, err)
+	}
+	if h.EventID != "" && len(h.RawPayload) > 0 {
+		return parseEvent(source, h.RawPayload)
+	}
+	if h.ID == "" || h.Type == "" {
+		return nil, fmt.Errorf("%s is not a webhook event (missing id or type)", source)
+	}
+	return &storedEvent{Source: source, ID: h.ID, Type: h.Type, Payload: raw}, nil
+}
@@ -0,0 +1,208 @@
+// aml.go contains typed access to Anti-Money Laundering (AML) screening results.
+// The API reports AML details in several shapes: a struct on deposit transactions, a
+// semicolon-separated list of rule names on payouts and payroll items, and sometimes a JSON
+// object in a string field. ParseAmlInfo reads all of them into an AmlInfo, and the AmlResult
+// methods combine it with the screening status of transactions, payouts, and payroll items.
+package developer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AmlStatus is the outcome of AML screening
+type AmlStatus string
+
+const (
+	// AmlStatusNone indicates the funds have not been screened (yet)
+	AmlStatusNone AmlStatus = ""
+	// AmlStatusApproved indicates the funds passed AML screening
+	AmlStatusApproved AmlStatus = "approved"
+	// AmlStatusRejected indicates the funds failed AML screening; incoming funds are frozen
+	AmlStatusRejected AmlStatus = "rejected"
+)
+
+// ParseAmlStatus normalizes an AML status string as reported by the API
+func ParseAmlStatus(s string) AmlStatus {
+	return AmlStatus(strings.ToLower(strings.TrimSpace(s)))
+}
+
+// IsApproved reports whether the funds passed AML screening
+func (s AmlStatus) IsApproved() bool {
+	return s == AmlStatusApproved
+}
+
+// IsRejected reports whether the funds failed AML screening
+func (s AmlStatus) IsRejected() bool {
+	return s == AmlStatusRejected
+}
+
+// IsScreened reports whether AML screening has completed
+func (s AmlStatus) IsScreened() bool {
+	return s != AmlStatusNone
+}
+
+// ParseAmlInfo parses AML details from a string field.
+// It accepts a JSON object with "score" and "rule_names" (a number or numeric string, and
+// a list or delimited string), or a list of rule names separated by semicolons, commas, or
+// newlines, where an entry such as "score=7.5" or "score: 7.5" sets the score.
+//
+// Parameters:
+//   - raw: The AML info as reported by the API; may be empty
+//
+// Returns:
+//   - *AmlInfo: The parsed score and rule names; empty, not nil, when raw is empty
+//   - error: Error if raw looks like JSON but cannot be parsed, or a score is not a number
+func ParseAmlInfo(raw string) (*AmlInfo, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return &AmlInfo{}, nil
+	}
+	if strings.HasPrefix(raw, "{") {
+		return parseAmlInfoJSON(raw)
+	}
+
+	info := &AmlInfo{}
+	for _, part := range splitAmlList(raw) {
+		if key, value, ok := cutAny(part, "=:"); ok && strings.EqualFold(strings.TrimSpace(key), "score") {
+			score, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing AML score %q: %v", value, err)
+			}
+			info.Score = score
+			continue
+		}
+		info.RuleNames = append(info.RuleNames, part)
+	}
+	return info, nil
+}
+
+// parseAmlInfoJSON parses AML details encoded as a JSON object
+func parseAmlInfoJSON(raw string) (*AmlInfo, error) {
+	var fields struct {
+		Score     json.RawMessage `json:"score"`
+		RiskScore json.RawMessage `json:"risk_score"`
+		RuleNames json.RawMessage `json:"rule_names"`
+		Rules     json.RawMessage `json:"rules"`
+	}
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return nil, fmt.Errorf("error parsing AML info: %v", err)
+	}
+
+	info := &AmlInfo{}
+	score := fields.Score
+	if len(score) == 0 {
+		score = fields.RiskScore
+	}
+	if len(score) > 0 && string(score) != "null" {
+		text := strings.Trim(string(score), `"`)
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing AML score %s: %v", score, err)
+		}
+		info.Score = value
+	}
+
+	rules := fields.RuleNames
+	if len(rules) == 0 {
+		rules = fields.Rules
+	}
+	if len(rules) > 0 && string(rules) != "null" {
+		var list []string
+		if err := json.Unmarshal(rules, &list); err != nil {
+			var text string
+			if err := json.Unmarshal(rules, &text); err != nil {
+				return nil, fmt.Errorf("error parsing AML rule names: %v", err)
+			}
+			list = splitAmlList(text)
+		}
+		for _, rule := range list {
+			if rule = strings.TrimSpace(rule); rule != "" {
+				info.RuleNames = append(info.RuleNames, rule)
+			}
+		}
+	}
+	return info, nil
+}
+
+// splitAmlList splits a delimited list of rule names, dropping empty entries
+func splitAmlList(s string) []string {
+	var parts []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' || r == '\n' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// cutAny slices s around the first occurrence of any of the separator characters
+func cutAny(s, separators string) (before, after string, found bool) {
+	if i := strings.IndexAny(s, separators); i >= 0 {
+		return s[:i], s[i+1:], true
+	}
+	return s, "", false
+}
+
+// HasRule reports whether the named rule was triggered, ignoring case
+func (a *AmlInfo) HasRule(name string) bool {
+	if a == nil {
+		return false
+	}
+	for _, rule := range a.RuleNames {
+		if strings.EqualFold(rule, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AmlResult is the AML screening outcome of a transaction, payout, or payroll item
+type AmlResult struct {
+	// Status is the screening status
+	Status AmlStatus `json:"status"`
+	// Info contains the risk score and triggered rules; never nil
+	Info *AmlInfo `json:"info"`
+}
+
+// newAmlResult combines a status string with raw AML details
+func newAmlResult(status string, raw *string) (*AmlResult, error) {
+	var text string
+	if raw != nil {
+		text = *raw
+	}
+	info, err := ParseAmlInfo(text)
+	if err != nil {
+		return nil, err
+	}
+	return &AmlResult{Status: ParseAmlStatus(status), Info: info}, nil
+}
+
+// AmlResult returns the AML screening outcome of a payment transaction
+func (t *TransactionDetails) AmlResult() (*AmlResult, error) {
+	return newAmlResult(t.AmlStatus, t.AmlInfo)
+}
+
+// AmlResult returns the AML screening outcome of a deposit transaction
+func (t *Transaction) AmlResult() (*AmlResult, error) {
+	info := &AmlInfo{}
+	if t.AmlInfo != nil {
+		info.Score = t.AmlInfo.Score
+		info.RuleNames = append([]string(nil), t.AmlInfo.RuleNames...)
+	}
+	return &AmlResult{Status: ParseAmlStatus(t.AmlStatus), Info: info}, nil
+}
+
+// AmlResult returns the AML screening outcome of a payout
+func (p *Payout) AmlResult() (*AmlResult, error) {
+	return newAmlResult(p.AmlStatus, p.AmlInfo)
+}
+
+// AmlResult returns the AML details of a payroll item.
+// Payroll items carry no screening status, so Status is always AmlStatusNone; the
+// triggered rules are in Info.
+func (p *PayrollItems) AmlResult() (*AmlResult, error) {
+	return newAmlResult("", &p.AmlInfo)
+}
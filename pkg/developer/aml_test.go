@@ -0,0 +1,53 @@
+// aml_test.go contains unit tests for AML info parsing.
+package developer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAmlInfo(t *testing.T) {
+	tests := []struct {
+		raw   string
+		score float64
+		rules []string
+	}{
+		{"", 0, nil},
+		{"mixer; darknet_market ;", 0, []string{"mixer", "darknet_market"}},
+		{"score=7.5;sanctions", 7.5, []string{"sanctions"}},
+		{`{"score": 6, "rule_names": ["gambling"]}`, 6, []string{"gambling"}},
+		{`{"risk_score": "8.2", "rules": "scam,sanctions"}`, 8.2, []string{"scam", "sanctions"}},
+	}
+	for _, tt := range tests {
+		info, err := ParseAmlInfo(tt.raw)
+		require.NoError(t, err, tt.raw)
+		assert.Equal(t, tt.score, info.Score, tt.raw)
+		assert.Equal(t, tt.rules, info.RuleNames, tt.raw)
+	}
+
+	_, err := ParseAmlInfo(`{"score": "high"}`)
+	assert.Error(t, err)
+	_, err = ParseAmlInfo(`{broken`)
+	assert.Error(t, err)
+}
+
+func TestAmlResult(t *testing.T) {
+	raw := "Sanctions;Mixer"
+	result, err := (&Payout{AmlStatus: " Rejected ", AmlInfo: &raw}).AmlResult()
+	require.NoError(t, err)
+	assert.True(t, result.Status.IsRejected())
+	assert.True(t, result.Info.HasRule("sanctions"))
+	assert.False(t, result.Info.HasRule("scam"))
+
+	result, err = (&TransactionDetails{}).AmlResult()
+	require.NoError(t, err)
+	assert.False(t, result.Status.IsScreened())
+	assert.NotNil(t, result.Info)
+
+	result, err = (&Transaction{AmlStatus: "approved", AmlInfo: &AmlInfo{Score: 1.5}}).AmlResult()
+	require.NoError(t, err)
+	assert.True(t, result.Status.IsApproved())
+	assert.Equal(t, 1.5, result.Info.Score)
+}
@@ -0,0 +1,221 @@
+// api_key.go contains request and response types for managing API keys, and an audit helper
+// that flags keys which are about to expire or have not been used recently.
+package developer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ================================
+// Request Types
+// ================================
+
+// ApiKeyListRequest lists API keys
+type ApiKeyListRequest struct {
+	// KeyType filters keys by type ("public" or "secret")
+	KeyType *string `json:"key_type,omitempty" form:"key_type"`
+	// Status filters keys by status (e.g., "active")
+	Status *string `json:"status,omitempty" form:"status"`
+	// Page is the page number (zero-based)
+	Page int32 `json:"page" binding:"min=0" form:"page"`
+	// PageSize is the number of items per page (max 50)
+	PageSize int32 `json:"page_size" binding:"required,min=1,max=50" form:"page_size"`
+}
+
+// ApiKeyRollRequest replaces an API key with a new one
+type ApiKeyRollRequest struct {
+	// ExpiresInSeconds is how long the old key keeps working after the roll (0 revokes it immediately).
+	// The overlap lets deployments pick up the new key before the old one stops working.
+	ExpiresInSeconds int64 `json:"expires_in_seconds"`
+}
+
+// ================================
+// Response Types
+// ================================
+
+// ApiKeyListResp represents a paginated list of API keys
+type ApiKeyListResp struct {
+	// ApiKeys is the list of API keys; secret key values are masked
+	ApiKeys []*ApiKey `json:"api_keys"`
+	// Total is the total number of keys matching the filters
+	Total int64 `json:"total"`
+	// Page is the current page number
+	Page int32 `json:"page"`
+	// PageSize is the number of items per page
+	PageSize int32 `json:"page_size"`
+}
+
+// ApiKeyRollResp contains the result of rolling an API key
+type ApiKeyRollResp struct {
+	// ApiKey is the new key, including its full key value (only returned once)
+	ApiKey *ApiKey `json:"api_key"`
+	// PreviousApiKey is the rolled key, with ExpiredAt set to the end of the overlap window
+	PreviousApiKey *ApiKey `json:"previous_api_key"`
+}
+
+// Validate checks the parameters used to create an API key
+func (p *ApiKeyParams) Validate() error {
+	if p.Name == nil || *p.Name == "" {
+		return errors.New("api key name is required")
+	}
+	if p.KeyType == nil {
+		return errors.New("api key type is required")
+	}
+	if *p.KeyType != DeveloperKeyTypePublic && *p.KeyType != DeveloperKeyTypeSecret {
+		return fmt.Errorf("invalid api key type %q (want %q or %q)", *p.KeyType, DeveloperKeyTypePublic, DeveloperKeyTypeSecret)
+	}
+	return nil
+}
+
+// ================================
+// Audit
+// ================================
+
+// ApiKeyAuditReason explains why an API key was flagged by AuditApiKeys
+type ApiKeyAuditReason string
+
+const (
+	// ApiKeyAuditExpired indicates the key has already expired but is still active
+	ApiKeyAuditExpired ApiKeyAuditReason = "expired"
+	// ApiKeyAuditExpiringSoon indicates the key expires within the audit window
+	ApiKeyAuditExpiringSoon ApiKeyAuditReason = "expiring_soon"
+	// ApiKeyAuditUnused indicates the key has not been used for the configured number of days
+	ApiKeyAuditUnused ApiKeyAuditReason = "unused"
+	// ApiKeyAuditNeverUsed indicates the key has never been used and is older than the unused threshold
+	ApiKeyAuditNeverUsed ApiKeyAuditReason = "never_used"
+)
+
+// ApiKeyAuditOptions configures AuditApiKeys
+type ApiKeyAuditOptions struct {
+	// ExpiringWithin flags keys that expire within this duration (0 disables the check)
+	ExpiringWithin time.Duration
+	// UnusedDays flags keys that have not been used for this many days (0 disables the check)
+	UnusedDays int
+	// Now is the reference time (defaults to time.Now())
+	Now time.Time
+}
+
+// ApiKeyAuditFinding is an API key flagged by AuditApiKeys
+type ApiKeyAuditFinding struct {
+	// ApiKey is the flagged key
+	ApiKey *ApiKey
+	// Reasons lists every reason the key was flagged
+	Reasons []ApiKeyAuditReason
+}
+
+// AuditApiKeys flags active keys that are expired, expiring soon, or unused.
+// Keys that are not active are skipped.
+//
+// Parameters:
+//   - keys: API keys to audit, e.g. from ListApiKeys
+//   - opts: Audit thresholds
+//
+// Returns:
+//   - []ApiKeyAuditFinding: Flagged keys, soonest expiry first, then by ID
+func AuditApiKeys(keys []*ApiKey, opts ApiKeyAuditOptions) []ApiKeyAuditFinding {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	unusedSince := now.AddDate(0, 0, -opts.UnusedDays).Unix()
+
+	var findings []ApiKeyAuditFinding
+	for _, key := range keys {
+		if key == nil || (key.Status != "" && key.Status != DeveloperStatusActive) {
+			continue
+		}
+		var reasons []ApiKeyAuditReason
+		if key.ExpiredAt > 0 {
+			if key.ExpiredAt <= now.Unix() {
+				reasons = append(reasons, ApiKeyAuditExpired)
+			} else if opts.ExpiringWithin > 0 && key.ExpiredAt <= now.Add(opts.ExpiringWithin).Unix() {
+				reasons = append(reasons, ApiKeyAuditExpiringSoon)
+			}
+		}
+		if opts.UnusedDays > 0 {
+			if key.LastAccessedAt == 0 {
+				if key.Created <= unusedSince {
+					reasons = append(reasons, ApiKeyAuditNeverUsed)
+				}
+			} else if key.LastAccessedAt <= unusedSince {
+				reasons = append(reasons, ApiKeyAuditUnused)
+			}
+		}
+		if len(reasons) > 0 {
+			findings = append(findings, ApiKeyAuditFinding{ApiKey: key, Reasons: reasons})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i].ApiKey, findings[j].ApiKey
+		if (a.ExpiredAt > 0) != (b.ExpiredAt > 0) {
+			return a.ExpiredAt > 0
+		}
+		if a.ExpiredAt != b.ExpiredAt {
+			return a.ExpiredAt < b.ExpiredAt
+		}
+		return a.ID < b.ID
+	})
+	return findings
+}
+
+// ================================
+// Key Parsing
+// ================================
+
+// ApiKeyMode is the environment an API key belongs to
+type ApiKeyMode string
+
+const (
+	// ApiKeyModeTest is the mode of test keys (sk_test_, pk_test_), which never move real funds
+	ApiKeyModeTest ApiKeyMode = "test"
+	// ApiKeyModeLive is the mode of live keys (sk_live_, pk_live_)
+	ApiKeyModeLive ApiKeyMode = "live"
+)
+
+// ErrInvalidApiKey is returned by ParseApiKey for keys without a known prefix
+var ErrInvalidApiKey = errors.New("invalid api key: expected an sk_test_, sk_live_, pk_test_ or pk_live_ prefix")
+
+// ApiKeyInfo describes an API key as encoded in its prefix
+type ApiKeyInfo struct {
+	// KeyType is DeveloperKeyTypePublic or DeveloperKeyTypeSecret
+	KeyType string
+	// Mode is ApiKeyModeTest or ApiKeyModeLive
+	Mode ApiKeyMode
+}
+
+// IsLive reports whether the key is a live mode key
+func (i *ApiKeyInfo) IsLive() bool {
+	return i.Mode == ApiKeyModeLive
+}
+
+// IsSecret reports whether the key is a secret key
+func (i *ApiKeyInfo) IsSecret() bool {
+	return i.KeyType == DeveloperKeyTypeSecret
+}
+
+// ParseApiKey reads the key type and mode from an API key's prefix
+//
+// Parameters:
+//   - key: API key such as "sk_test_..."
+//
+// Returns:
+//   - *ApiKeyInfo: The key type and mode
+//   - error: ErrInvalidApiKey if the key has no known prefix
+func ParseApiKey(key string) (*ApiKeyInfo, error) {
+	switch {
+	case strings.HasPrefix(key, ApiKeyPrefixSecretTest):
+		return &ApiKeyInfo{KeyType: DeveloperKeyTypeSecret, Mode: ApiKeyModeTest}, nil
+	case strings.HasPrefix(key, ApiKeyPrefixSecretLive):
+		return &ApiKeyInfo{KeyType: DeveloperKeyTypeSecret, Mode: ApiKeyModeLive}, nil
+	case strings.HasPrefix(key, ApiKeyPrefixPublicTest):
+		return &ApiKeyInfo{KeyType: DeveloperKeyTypePublic, Mode: ApiKeyModeTest}, nil
+	case strings.HasPrefix(key, ApiKeyPrefixPublicLive):
+		return &ApiKeyInfo{KeyType: DeveloperKeyTypePublic, Mode: ApiKeyModeLive}, nil
+	}
+	return nil, ErrInvalidApiKey
+}
@@ -0,0 +1,77 @@
+// api_key_test.go contains unit tests for API key helpers.
+package developer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseApiKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		secret bool
+		live   bool
+	}{
+		{"sk_test_abc", true, false},
+		{"sk_live_abc", true, true},
+		{"pk_test_abc", false, false},
+		{"pk_live_abc", false, true},
+	}
+	for _, tt := range tests {
+		info, err := ParseApiKey(tt.key)
+		require.NoError(t, err, tt.key)
+		assert.Equal(t, tt.secret, info.IsSecret(), tt.key)
+		assert.Equal(t, tt.live, info.IsLive(), tt.key)
+	}
+
+	for _, key := range []string{"", "abc", "sk_abc", "rk_live_abc", "SK_TEST_abc"} {
+		_, err := ParseApiKey(key)
+		assert.ErrorIs(t, err, ErrInvalidApiKey, key)
+	}
+}
+
+func TestAuditApiKeys(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	day := int64(24 * 60 * 60)
+	ts := now.Unix()
+
+	tests := []struct {
+		name    string
+		key     *ApiKey
+		reasons []ApiKeyAuditReason
+	}{
+		{"healthy", &ApiKey{ID: "k_ok", Status: DeveloperStatusActive, Created: ts - 100*day, LastAccessedAt: ts - day, ExpiredAt: ts + 90*day}, nil},
+		{"expiring", &ApiKey{ID: "k_soon", Status: DeveloperStatusActive, LastAccessedAt: ts, ExpiredAt: ts + 3*day}, []ApiKeyAuditReason{ApiKeyAuditExpiringSoon}},
+		{"expired", &ApiKey{ID: "k_old", Status: DeveloperStatusActive, LastAccessedAt: ts, ExpiredAt: ts - day}, []ApiKeyAuditReason{ApiKeyAuditExpired}},
+		{"unused", &ApiKey{ID: "k_idle", Status: DeveloperStatusActive, Created: ts - 200*day, LastAccessedAt: ts - 45*day}, []ApiKeyAuditReason{ApiKeyAuditUnused}},
+		{"never used", &ApiKey{ID: "k_new", Status: DeveloperStatusActive, Created: ts - 31*day}, []ApiKeyAuditReason{ApiKeyAuditNeverUsed}},
+		{"recently created", &ApiKey{ID: "k_fresh", Status: DeveloperStatusActive, Created: ts - day}, nil},
+		{"expired and unused", &ApiKey{ID: "k_dead", Created: ts - 200*day, LastAccessedAt: ts - 60*day, ExpiredAt: ts - day}, []ApiKeyAuditReason{ApiKeyAuditExpired, ApiKeyAuditUnused}},
+		{"inactive", &ApiKey{ID: "k_off", Status: DeveloperStatusInactive, ExpiredAt: ts - day}, nil},
+	}
+	opts := ApiKeyAuditOptions{ExpiringWithin: 7 * 24 * time.Hour, UnusedDays: 30, Now: now}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := AuditApiKeys([]*ApiKey{tt.key}, opts)
+			if tt.reasons == nil {
+				assert.Empty(t, findings)
+				return
+			}
+			require.Len(t, findings, 1)
+			assert.Equal(t, tt.reasons, findings[0].Reasons)
+		})
+	}
+
+	var keys []*ApiKey
+	for _, tt := range tests {
+		keys = append(keys, tt.key)
+	}
+	var order []string
+	for _, f := range AuditApiKeys(keys, opts) {
+		order = append(order, f.ApiKey.ID)
+	}
+	assert.Equal(t, []string{"k_dead", "k_old", "k_soon", "k_idle", "k_new"}, order)
+}
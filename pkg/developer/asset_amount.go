@@ -3,7 +3,13 @@
 // decimal precision and formatting.
 package developer
 
-import "github.com/shopspring/decimal"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
 
 // AssetAmount represents a cryptocurrency asset with its amount and decimal precision
 type AssetAmount struct {
@@ -11,8 +17,8 @@ type AssetAmount struct {
 	AssetId string `json:"asset_id"`
 	// Amount is the decimal amount of the asset
 	Amount decimal.Decimal `json:"amount"`
-	// DecimalDigits is the number of decimal places for this asset (not serialized to JSON)
-	DecimalDigits int `json:"-"`
+	// DecimalDigits is the number of decimal places for this asset (omitted from JSON when zero)
+	DecimalDigits int `json:"decimals,omitempty"`
 }
 
 // NewAssetAmount creates a new AssetAmount with the specified decimal amount
@@ -44,10 +50,9 @@ func (a *AssetAmount) Value() decimal.Decimal {
 	return a.Amount
 }
 
-// DecimalOverflow checks if the amount has decimal overflow
+// DecimalOverflow checks if the amount has more decimal places than the asset supports
 func (a *AssetAmount) DecimalOverflow() bool {
-	inter := a.BigInt().Truncate(0)
-	return a.Amount.Cmp(inter) == 0
+	return !a.BigInt().IsInteger()
 }
 
 // IsValidPrice checks if the asset amount represents a valid price (positive and no decimal overflow)
@@ -57,3 +62,346 @@ func (a *AssetAmount) IsValidPrice() bool {
 	}
 	return a.Value().Sign() > 0 && !a.DecimalOverflow()
 }
+
+// ================================
+// Arithmetic
+// ================================
+
+// ErrAssetMismatch is returned when amounts of different assets are combined
+var ErrAssetMismatch = errors.New("asset amounts have different assets")
+
+// Add returns a + b
+//
+// Parameters:
+//   - b: Amount of the same asset
+//
+// Returns:
+//   - *AssetAmount: The sum, with the larger number of decimal digits
+//   - error: ErrAssetMismatch if the assets differ
+func (a *AssetAmount) Add(b *AssetAmount) (*AssetAmount, error) {
+	if err := a.checkSameAsset(b); err != nil {
+		return nil, err
+	}
+	return NewAssetAmount(a.Amount.Add(b.Amount), a.AssetId, maxDecimalDigits(a, b)), nil
+}
+
+// Sub returns a - b
+//
+// Parameters:
+//   - b: Amount of the same asset
+//
+// Returns:
+//   - *AssetAmount: The difference, with the larger number of decimal digits
+//   - error: ErrAssetMismatch if the assets differ
+func (a *AssetAmount) Sub(b *AssetAmount) (*AssetAmount, error) {
+	if err := a.checkSameAsset(b); err != nil {
+		return nil, err
+	}
+	return NewAssetAmount(a.Amount.Sub(b.Amount), a.AssetId, maxDecimalDigits(a, b)), nil
+}
+
+// Mul returns the amount multiplied by a quantity, e.g. a unit price times the number of items
+func (a *AssetAmount) Mul(quantity int64) *AssetAmount {
+	return NewAssetAmount(a.Amount.Mul(decimal.NewFromInt(quantity)), a.AssetId, a.DecimalDigits)
+}
+
+// Cmp compares two amounts of the same asset
+//
+// Returns:
+//   - int: -1 if a < b, 0 if a == b, +1 if a > b
+//   - error: ErrAssetMismatch if the assets differ
+func (a *AssetAmount) Cmp(b *AssetAmount) (int, error) {
+	if err := a.checkSameAsset(b); err != nil {
+		return 0, err
+	}
+	return a.Amount.Cmp(b.Amount), nil
+}
+
+// IsZero reports whether the amount is zero
+func (a *AssetAmount) IsZero() bool {
+	return a.Amount.IsZero()
+}
+
+func (a *AssetAmount) checkSameAsset(b *AssetAmount) error {
+	if a == nil || b == nil {
+		return errors.New("asset amount is nil")
+	}
+	if a.AssetId != b.AssetId {
+		return fmt.Errorf("%w: %q and %q", ErrAssetMismatch, a.AssetId, b.AssetId)
+	}
+	return nil
+}
+
+func maxDecimalDigits(a, b *AssetAmount) int {
+	if a.DecimalDigits > b.DecimalDigits {
+		return a.DecimalDigits
+	}
+	return b.DecimalDigits
+}
+
+// ================================
+// Rounding
+// ================================
+
+// RoundingMode selects how amounts are rounded to an asset's decimal places
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds to the nearest value, with halves away from zero (1.005 -> 1.01)
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds to the nearest value, with halves to the even digit (banker's rounding)
+	RoundHalfEven
+	// RoundUp rounds away from zero, e.g. for amounts a customer still has to pay
+	RoundUp
+	// RoundDown rounds towards zero (truncates), e.g. for amounts paid out
+	RoundDown
+	// RoundCeiling rounds towards positive infinity
+	RoundCeiling
+	// RoundFloor rounds towards negative infinity
+	RoundFloor
+)
+
+// Round returns the amount rounded to the asset's decimal digits.
+// Amounts decoded from API responses do not carry their decimals; when DecimalDigits is
+// zero the amount is returned unrounded, so create it with AssetRegistry.NewAmount to round it.
+//
+// Parameters:
+//   - mode: How to round digits beyond DecimalDigits
+//
+// Returns:
+//   - *AssetAmount: The rounded amount
+func (a *AssetAmount) Round(mode RoundingMode) *AssetAmount {
+	if a.DecimalDigits == 0 {
+		return NewAssetAmount(a.Amount, a.AssetId, 0)
+	}
+	return NewAssetAmount(roundDecimal(a.Amount, a.DecimalDigits, mode), a.AssetId, a.DecimalDigits)
+}
+
+// roundDecimal rounds d to the given number of decimal places
+func roundDecimal(d decimal.Decimal, places int, mode RoundingMode) decimal.Decimal {
+	p := int32(places)
+	switch mode {
+	case RoundHalfEven:
+		return d.RoundBank(p)
+	case RoundUp:
+		return d.RoundUp(p)
+	case RoundDown:
+		return d.RoundDown(p)
+	case RoundCeiling:
+		return d.RoundCeil(p)
+	case RoundFloor:
+		return d.RoundFloor(p)
+	default:
+		return d.Round(p)
+	}
+}
+
+// ================================
+// Base Units
+// ================================
+
+// ToBaseUnits returns the amount as an integer number of the asset's smallest units (e.g., wei)
+//
+// Returns:
+//   - decimal.Decimal: The integer amount in base units
+//   - error: Error if the amount has more decimal places than DecimalDigits
+func (a *AssetAmount) ToBaseUnits() (decimal.Decimal, error) {
+	units := a.BigInt()
+	if !units.IsInteger() {
+		return decimal.Zero, fmt.Errorf("amount %s %s has more than %d decimal places", a.Amount, a.AssetId, a.DecimalDigits)
+	}
+	return units, nil
+}
+
+// NewAmount parses a decimal amount of an asset and sets its decimal digits from the registry
+//
+// Parameters:
+//   - assetID: ID of a registered asset
+//   - amount: Decimal amount in whole units (e.g., "12.5")
+//
+// Returns:
+//   - *AssetAmount: The amount
+//   - error: Error if the asset is unknown, the amount is invalid, or it is more precise than the asset
+func (r AssetRegistry) NewAmount(assetID, amount string) (*AssetAmount, error) {
+	asset := r.Get(assetID)
+	if asset == nil {
+		return nil, fmt.Errorf("unknown asset %q", assetID)
+	}
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing amount %q: %v", amount, err)
+	}
+	a := NewAssetAmount(d, assetID, asset.Decimals)
+	if a.DecimalOverflow() {
+		return nil, fmt.Errorf("amount %s %s has more than %d decimal places", amount, assetID, asset.Decimals)
+	}
+	return a, nil
+}
+
+// FromBaseUnits converts an integer number of base units (e.g., wei) into an amount of the asset
+//
+// Parameters:
+//   - assetID: ID of a registered asset
+//   - units: Integer amount in base units
+//
+// Returns:
+//   - *AssetAmount: The amount in whole units
+//   - error: Error if the asset is unknown or units is not an integer
+func (r AssetRegistry) FromBaseUnits(assetID, units string) (*AssetAmount, error) {
+	asset := r.Get(assetID)
+	if asset == nil {
+		return nil, fmt.Errorf("unknown asset %q", assetID)
+	}
+	d, err := decimal.NewFromString(units)
+	if err != nil || !d.IsInteger() {
+		return nil, fmt.Errorf("invalid base unit amount %q", units)
+	}
+	return NewAssetAmountFromBigInt(d, assetID, asset.Decimals), nil
+}
+
+// ToBaseUnits converts an amount into base units using the registry's decimals,
+// which is reliable even when DecimalDigits was not set on the amount
+//
+// Parameters:
+//   - a: Amount of a registered asset
+//
+// Returns:
+//   - decimal.Decimal: The integer amount in base units
+//   - error: Error if the asset is unknown or the amount is more precise than the asset
+func (r AssetRegistry) ToBaseUnits(a *AssetAmount) (decimal.Decimal, error) {
+	asset := r.Get(a.AssetId)
+	if asset == nil {
+		return decimal.Zero, fmt.Errorf("unknown asset %q", a.AssetId)
+	}
+	return NewAssetAmount(a.Amount, a.AssetId, asset.Decimals).ToBaseUnits()
+}
+
+// ================================
+// Formatting
+// ================================
+
+// NumberFormat describes how a locale writes monetary amounts
+type NumberFormat struct {
+	// DecimalSeparator separates the integer and fractional parts (e.g., "." or ",")
+	DecimalSeparator string
+	// GroupSeparator separates groups of thousands (e.g., "," or ".")
+	GroupSeparator string
+	// SymbolAfter places the currency symbol after the number ("12,50 €") instead of before ("$12.50")
+	SymbolAfter bool
+	// SymbolSpace puts a space between the number and the currency symbol
+	SymbolSpace bool
+}
+
+// numberFormats lists the number formats of common locales by language or language-region tag
+var numberFormats = map[string]NumberFormat{
+	"en":    {DecimalSeparator: ".", GroupSeparator: ","},
+	"en-IE": {DecimalSeparator: ".", GroupSeparator: ","},
+	"de":    {DecimalSeparator: ",", GroupSeparator: ".", SymbolAfter: true, SymbolSpace: true},
+	"de-CH": {DecimalSeparator: ".", GroupSeparator: "’", SymbolSpace: true},
+	"fr":    {DecimalSeparator: ",", GroupSeparator: " ", SymbolAfter: true, SymbolSpace: true},
+	"es":    {DecimalSeparator: ",", GroupSeparator: ".", SymbolAfter: true, SymbolSpace: true},
+	"it":    {DecimalSeparator: ",", GroupSeparator: ".", SymbolAfter: true, SymbolSpace: true},
+	"nl":    {DecimalSeparator: ",", GroupSeparator: ".", SymbolSpace: true},
+	"pt":    {DecimalSeparator: ",", GroupSeparator: ".", SymbolSpace: true},
+	"ja":    {DecimalSeparator: ".", GroupSeparator: ","},
+	"ko":    {DecimalSeparator: ".", GroupSeparator: ","},
+	"zh":    {DecimalSeparator: ".", GroupSeparator: ","},
+}
+
+// NumberFormatFor returns the number format of a locale such as "en-US", "de_DE" or "fr".
+// Unknown locales fall back to the language, then to English.
+func NumberFormatFor(locale string) NumberFormat {
+	locale = strings.ReplaceAll(locale, "_", "-")
+	if f, ok := numberFormats[locale]; ok {
+		return f
+	}
+	if i := strings.Index(locale, "-"); i > 0 {
+		locale = locale[:i]
+	}
+	if f, ok := numberFormats[strings.ToLower(locale)]; ok {
+		return f
+	}
+	return numberFormats["en"]
+}
+
+// Format renders the amount for display in the given locale. Fiat amounts use the asset's
+// currency symbol and always show all decimal places ("$1,234.50", "1.234,50 €"); crypto
+// amounts drop trailing zeros and end with the coin ("1,234.5 USDT").
+//
+// Parameters:
+//   - asset: The asset of the amount (supplies the symbol and decimals); nil uses DecimalDigits and
+//     AssetId, and leaves the amount unrounded when DecimalDigits is zero (unknown)
+//   - locale: Locale tag such as "en-US" or "de-DE"
+//
+// Returns:
+//   - string: The formatted amount
+func (a *AssetAmount) Format(asset *Asset, locale string) string {
+	nf := NumberFormatFor(locale)
+	places := a.DecimalDigits
+	unit := a.AssetId
+	symbol := ""
+	fiat := false
+	if asset != nil {
+		places = asset.Decimals
+		unit = asset.Coin
+		if unit == "" {
+			unit = asset.Id
+		}
+		if asset.IsFiat {
+			fiat = true
+			if asset.FiatAssetParams != nil {
+				symbol = asset.Symbol
+			}
+		}
+	}
+
+	rounded := a.Amount
+	if asset != nil || places > 0 {
+		rounded = roundDecimal(a.Amount, places, RoundHalfUp)
+	}
+	var digits string
+	if fiat {
+		digits = rounded.Abs().StringFixed(int32(places))
+	} else {
+		digits = rounded.Abs().String()
+	}
+	number := groupDigits(digits, nf)
+	if rounded.Sign() < 0 {
+		number = "-" + number
+	}
+
+	if symbol == "" {
+		return number + " " + unit
+	}
+	sep := ""
+	if nf.SymbolSpace {
+		sep = " "
+	}
+	if nf.SymbolAfter {
+		return number + sep + symbol
+	}
+	if rounded.Sign() < 0 {
+		return "-" + symbol + sep + number[1:]
+	}
+	return symbol + sep + number
+}
+
+// groupDigits applies the locale's decimal and thousands separators to a plain decimal string
+func groupDigits(digits string, nf NumberFormat) string {
+	intPart, frac := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		intPart, frac = digits[:i], digits[i+1:]
+	}
+	var sb strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(nf.GroupSeparator)
+		}
+		sb.WriteRune(c)
+	}
+	if frac != "" {
+		sb.WriteString(nf.DecimalSeparator)
+		sb.WriteString(frac)
+	}
+	return sb.String()
+}
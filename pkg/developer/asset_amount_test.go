@@ -0,0 +1,91 @@
+// asset_amount_test.go contains unit tests for asset amount arithmetic, rounding, and formatting.
+package developer
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetAmountArithmetic(t *testing.T) {
+	a := NewAssetAmount(decimal.RequireFromString("10.25"), "USDT-TRON", 6)
+	b := NewAssetAmount(decimal.RequireFromString("0.75"), "USDT-TRON", 6)
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	assert.Equal(t, "11", sum.Amount.String())
+	diff, err := a.Sub(b)
+	require.NoError(t, err)
+	assert.Equal(t, "9.5", diff.Amount.String())
+	assert.Equal(t, "30.75", a.Mul(3).Amount.String())
+	cmp, err := a.Cmp(b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cmp)
+
+	_, err = a.Add(NewAssetAmount(decimal.NewFromInt(1), "USDC-ETH", 6))
+	assert.True(t, errors.Is(err, ErrAssetMismatch))
+
+	assert.False(t, a.DecimalOverflow())
+	assert.True(t, NewAssetAmount(decimal.RequireFromString("1.005"), "USD", 2).DecimalOverflow())
+
+	// DecimalDigits survives a JSON round trip
+	data, err := json.Marshal(a)
+	require.NoError(t, err)
+	var decoded AssetAmount
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, 6, decoded.DecimalDigits)
+}
+
+func TestAssetAmountRounding(t *testing.T) {
+	amount := func(s string) *AssetAmount {
+		return NewAssetAmount(decimal.RequireFromString(s), "USD", 2)
+	}
+	assert.Equal(t, "1.01", amount("1.005").Round(RoundHalfUp).Amount.String())
+	assert.Equal(t, "1", amount("1.005").Round(RoundHalfEven).Amount.String())
+	assert.Equal(t, "1.01", amount("1.001").Round(RoundUp).Amount.String())
+	assert.Equal(t, "-1.01", amount("-1.001").Round(RoundUp).Amount.String())
+	assert.Equal(t, "1", amount("1.009").Round(RoundDown).Amount.String())
+	assert.Equal(t, "-1", amount("-1.001").Round(RoundCeiling).Amount.String())
+	assert.Equal(t, "-1.01", amount("-1.001").Round(RoundFloor).Amount.String())
+
+	// Amounts decoded from the API have no decimals and are left unrounded
+	var decoded AssetAmount
+	require.NoError(t, json.Unmarshal([]byte(`{"asset_id":"USDT-TRON","amount":"12.5"}`), &decoded))
+	assert.Equal(t, 0, decoded.DecimalDigits)
+	assert.Equal(t, "12.5", decoded.Round(RoundHalfUp).Amount.String())
+	assert.Equal(t, "12.5", decoded.Round(RoundDown).Amount.String())
+}
+
+func TestAssetAmountFormatAndBaseUnits(t *testing.T) {
+	usd := &Asset{Id: "USD", Coin: "USD", IsFiat: true, Decimals: 2, FiatAssetParams: &FiatAssetParams{Symbol: "$"}}
+	eur := &Asset{Id: "EUR", Coin: "EUR", IsFiat: true, Decimals: 2, FiatAssetParams: &FiatAssetParams{Symbol: "€"}}
+	usdt := &Asset{Id: "USDT-Ethereum", Coin: "USDT", Decimals: 6, CryptoAssetParams: &CryptoAssetParams{Network: "Ethereum"}}
+
+	assert.Equal(t, "$1,234.50", NewAssetAmount(decimal.RequireFromString("1234.5"), "USD", 2).Format(usd, "en-US"))
+	assert.Equal(t, "-$5.00", NewAssetAmount(decimal.NewFromInt(-5), "USD", 2).Format(usd, "en"))
+	assert.Equal(t, "1.234.567,89 €", NewAssetAmount(decimal.RequireFromString("1234567.891"), "EUR", 2).Format(eur, "de_DE"))
+	assert.Equal(t, "1,234.5 USDT", NewAssetAmount(decimal.RequireFromString("1234.500000"), "USDT-Ethereum", 6).Format(usdt, "xx"))
+
+	// Without an asset, a decoded amount is shown as is rather than rounded to whole units
+	var decoded AssetAmount
+	require.NoError(t, json.Unmarshal([]byte(`{"asset_id":"USDT-TRON","amount":"1234.5"}`), &decoded))
+	assert.Equal(t, "1,234.5 USDT-TRON", decoded.Format(nil, "en"))
+	assert.Equal(t, "1,234.5 USDT", decoded.Format(usdt, "en"))
+	assert.Equal(t, "1.01 USD", NewAssetAmount(decimal.RequireFromString("1.005"), "USD", 2).Format(nil, "en"))
+
+	registry := NewAssetRegistry([]*Asset{usd, usdt})
+	a, err := registry.FromBaseUnits("USDT-Ethereum", "12500000")
+	require.NoError(t, err)
+	assert.Equal(t, "12.5", a.Amount.String())
+	units, err := registry.ToBaseUnits(NewAssetAmount(decimal.RequireFromString("12.5"), "USDT-Ethereum", 0))
+	require.NoError(t, err)
+	assert.Equal(t, "12500000", units.String())
+	_, err = registry.NewAmount("USD", "1.001")
+	assert.Error(t, err)
+	_, err = registry.NewAmount("BTC", "1")
+	assert.Error(t, err)
+}
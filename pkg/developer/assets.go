@@ -2,7 +2,11 @@
 // It provides structures for asset definitions, exchange rates, and asset-related operations.
 package developer
 
-import "github.com/shopspring/decimal"
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
 
 // ================================
 // Core Types
@@ -62,6 +66,21 @@ type NetworkFee struct {
 	FeeAmount decimal.Decimal `json:"fee_amount" example:"0.50"`
 }
 
+// ExchangeRate is the price of one unit of an asset in a fiat currency.
+// The crypto amount of a fiat price is the fiat amount divided by Rate.
+type ExchangeRate struct {
+	// AssetId is the unique identifier of the priced asset
+	AssetId string `json:"asset_id" example:"USDT-TRON"`
+	// Currency is the fiat currency the rate is quoted in
+	Currency string `json:"currency" example:"USD"`
+	// Rate is the price of one unit of the asset in Currency
+	Rate decimal.Decimal `json:"rate" example:"0.9998"`
+	// UpdatedAt is the Unix timestamp when the rate was last updated
+	UpdatedAt int64 `json:"updated_at"`
+	// ExpiresAt is the Unix timestamp after which the rate should no longer be used (0 if not set)
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+}
+
 // ================================
 // Request Types
 // ================================
@@ -78,6 +97,12 @@ type NetworkFeeByAssetRequest struct {
 	AssetID string `form:"asset_id" binding:"required" example:"USDC"`
 }
 
+// ExchangeRateListRequest represents a request to get the exchange rates of payable assets
+type ExchangeRateListRequest struct {
+	// Currency is the fiat currency to quote the rates in
+	Currency string `json:"currency" form:"currency" binding:"required" example:"USD"`
+}
+
 // ================================
 // Response Types
 // ================================
@@ -93,3 +118,73 @@ type NetworkFeesResponse struct {
 	// NetworkFees is a map of network names to their fee information
 	NetworkFees map[string]*NetworkFee `json:"network_fees"`
 }
+
+// ExchangeRateListResponse contains the current exchange rates of payable assets
+type ExchangeRateListResponse struct {
+	// Rates is the list of exchange rates, one per asset
+	Rates []*ExchangeRate `json:"rates"`
+}
+
+// ================================
+// Block Explorer Links
+// ================================
+
+// txPlaceholders and addressPlaceholders are the placeholders recognized in explorer URL templates
+var (
+	txPlaceholders      = []string{"{tx_hash}", "{txHash}", "{txid}", "{tx}", "{hash}"}
+	addressPlaceholders = []string{"{address}", "{addr}"}
+)
+
+// TxURL returns the block explorer URL of a transaction, rendered from TxUrlTemplate.
+// Returns "" for fiat assets, assets without a template, or an empty hash.
+func (a *Asset) TxURL(txHash string) string {
+	if a == nil || a.CryptoAssetParams == nil || txHash == "" {
+		return ""
+	}
+	return renderExplorerURL(a.TxUrlTemplate, txHash, txPlaceholders)
+}
+
+// AddressURL returns the block explorer URL of an address, rendered from AddressUrlTemplate.
+// Returns "" for fiat assets, assets without a template, or an empty address.
+func (a *Asset) AddressURL(address string) string {
+	if a == nil || a.CryptoAssetParams == nil || address == "" {
+		return ""
+	}
+	return renderExplorerURL(a.AddressUrlTemplate, address, addressPlaceholders)
+}
+
+// renderExplorerURL substitutes value into an explorer URL template. Templates may use a
+// %s verb or a named placeholder; a template with neither is treated as a URL prefix.
+func renderExplorerURL(template, value string, placeholders []string) string {
+	if template == "" {
+		return ""
+	}
+	if strings.Contains(template, "%s") {
+		return strings.Replace(template, "%s", value, 1)
+	}
+	for _, p := range placeholders {
+		if strings.Contains(template, p) {
+			return strings.ReplaceAll(template, p, value)
+		}
+	}
+	return template + value
+}
+
+// AssetRegistry indexes assets by ID, e.g. the result of GetAllAssets
+type AssetRegistry map[string]*Asset
+
+// NewAssetRegistry builds a registry from a list of assets
+func NewAssetRegistry(assets []*Asset) AssetRegistry {
+	r := make(AssetRegistry, len(assets))
+	for _, a := range assets {
+		if a != nil {
+			r[a.Id] = a
+		}
+	}
+	return r
+}
+
+// Get returns the asset with the given ID, or nil if it is unknown
+func (r AssetRegistry) Get(id string) *Asset {
+	return r[id]
+}
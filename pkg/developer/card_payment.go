@@ -0,0 +1,185 @@
+// card_payment.go contains helpers for the fiat card payment flow.
+// A card payment is confirmed with PaymentMethodTypeCards and a FiatOption, after which the
+// charge carries the payload of the card provider (Stripe or PayerMax) that the frontend needs
+// to collect the card. Stripe payments are completed by Stripe.js with the client secret;
+// PayerMax payments are finalized by submitting the drop-in payment token.
+package developer
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// CardProvider identifies the provider processing a card charge
+type CardProvider string
+
+const (
+	// CardProviderStripe is a card charge processed by Stripe
+	CardProviderStripe CardProvider = "stripe"
+	// CardProviderPayerMax is a card charge processed by PayerMax
+	CardProviderPayerMax CardProvider = "payermax"
+)
+
+// ErrNoCardPayload is returned when a payment intent has no card charge with a provider payload
+var ErrNoCardPayload = errors.New("payment intent has no card charge with a provider payload")
+
+// CardPayment is the provider payload of a card charge, as needed by the frontend
+type CardPayment struct {
+	// Provider is the card provider processing the charge
+	Provider CardProvider
+	// Charge is the card charge
+	Charge *Charge
+	// Stripe is set when Provider is CardProviderStripe
+	Stripe *StripePayload
+	// PayerMax is set when Provider is CardProviderPayerMax
+	PayerMax *PayerMaxPayload
+}
+
+// NewCardConfirmRequest builds the request that confirms a payment intent with a card.
+//
+// Parameters:
+//   - id: The payment intent ID
+//   - currency: The fiat currency to charge (e.g., "USD")
+//   - paymentMethodID: A saved payment method ID to charge, or "" to collect a new card
+//   - savePaymentMethod: Whether to save the new card for future payments (ignored for saved cards)
+//
+// Returns:
+//   - *PaymentIntentUpdateRequest: The confirm request, ready for UpdatePaymentIntent
+func NewCardConfirmRequest(id, currency, paymentMethodID string, savePaymentMethod bool) *PaymentIntentUpdateRequest {
+	methodType := PaymentMethodTypeCards
+	return &PaymentIntentUpdateRequest{
+		ID:                id,
+		PaymentMethodType: &methodType,
+		PaymentMethodData: &PaymentMethodConfirmOptions{Fiat: NewFiatOption(currency, paymentMethodID, savePaymentMethod)},
+	}
+}
+
+// NewFiatOption builds the fiat confirmation options for a card payment.
+// A saved payment method is charged as is, so SavePaymentMethod is only set for new cards.
+//
+// Parameters:
+//   - currency: The fiat currency to charge
+//   - paymentMethodID: A saved payment method ID, or "" for a new card
+//   - savePaymentMethod: Whether to save a new card for future payments
+//
+// Returns:
+//   - *FiatOption: The fiat confirmation options
+func NewFiatOption(currency, paymentMethodID string, savePaymentMethod bool) *FiatOption {
+	option := &FiatOption{Currency: &currency}
+	if paymentMethodID != "" {
+		option.PaymentMethodID = &paymentMethodID
+	} else if savePaymentMethod {
+		option.SavePaymentMethod = &savePaymentMethod
+	}
+	return option
+}
+
+// CardPayment returns the provider payload of the most recent card charge.
+//
+// Returns:
+//   - *CardPayment: The charge and its Stripe or PayerMax payload
+//   - error: ErrNoCardPayload if no card charge carries a provider payload
+func (p *PaymentIntent) CardPayment() (*CardPayment, error) {
+	var latest *CardPayment
+	for _, charge := range p.Charges {
+		payment := cardPaymentOf(charge)
+		if payment == nil {
+			continue
+		}
+		if latest == nil || charge.Created >= latest.Charge.Created {
+			latest = payment
+		}
+	}
+	if latest == nil {
+		return nil, ErrNoCardPayload
+	}
+	return latest, nil
+}
+
+// cardPaymentOf returns the card payment of a charge, or nil if it has no provider payload
+func cardPaymentOf(charge *Charge) *CardPayment {
+	if charge == nil || (charge.PaymentMethodType != "" && charge.PaymentMethodType != PaymentMethodTypeCards) {
+		return nil
+	}
+	switch {
+	case charge.StripePayload != nil:
+		return &CardPayment{Provider: CardProviderStripe, Charge: charge, Stripe: charge.StripePayload}
+	case charge.PayerMaxPayload != nil:
+		return &CardPayment{Provider: CardProviderPayerMax, Charge: charge, PayerMax: charge.PayerMaxPayload}
+	}
+	return nil
+}
+
+// NewDropinOrder builds the request that finalizes a PayerMax card payment with the
+// payment token returned by the PayerMax drop-in component. Submit it with
+// PublicClient.CreatePayerMaxDropinOrder, which fills in the publishable key.
+//
+// Parameters:
+//   - paymentIntent: The payment intent, for its ID and client secret
+//   - paymentToken: The token returned by the PayerMax drop-in component
+//
+// Returns:
+//   - *PayerMaxDropinOrderRequest: The drop-in order request
+//   - error: Error if the payment is not a PayerMax card payment or the token is empty
+func (c *CardPayment) NewDropinOrder(paymentIntent *PaymentIntent, paymentToken string) (*PayerMaxDropinOrderRequest, error) {
+	if c.Provider != CardProviderPayerMax {
+		return nil, errors.New("drop-in orders are only used for PayerMax card payments")
+	}
+	if paymentToken == "" {
+		return nil, errors.New("payment token is required")
+	}
+	return &PayerMaxDropinOrderRequest{
+		PaymentIntentID: paymentIntent.ID,
+		ChargeID:        c.Charge.ID,
+		ClientSecret:    paymentIntent.ClientSecret,
+		PaymentToken:    paymentToken,
+	}, nil
+}
+
+// IsExpired reports whether the card expired before the given time.
+// A card is valid through the last day of its expiration month.
+//
+// Parameters:
+//   - now: The time to check against
+//
+// Returns:
+//   - bool: true if the card can no longer be charged
+func (m *PaymentMethodCard) IsExpired(now time.Time) bool {
+	if m.ExpYear == 0 || m.ExpMonth == 0 {
+		return false
+	}
+	endOfMonth := time.Date(int(m.ExpYear), time.Month(m.ExpMonth)+1, 1, 0, 0, 0, 0, time.UTC)
+	return !now.Before(endOfMonth)
+}
+
+// Find returns the saved payment method with the given ID, or nil if it is not in the list
+func (r *PaymentMethodListResponse) Find(id string) *PaymentMethodCard {
+	for _, method := range r.PaymentMethods {
+		if method != nil && method.ID == id {
+			return method
+		}
+	}
+	return nil
+}
+
+// Usable returns the saved card payment methods that have not expired
+//
+// Parameters:
+//   - now: The time to check expiration against
+//
+// Returns:
+//   - []*PaymentMethodCard: The saved cards that can still be charged
+func (r *PaymentMethodListResponse) Usable(now time.Time) []*PaymentMethodCard {
+	var usable []*PaymentMethodCard
+	for _, method := range r.PaymentMethods {
+		if method == nil || method.IsExpired(now) {
+			continue
+		}
+		if method.Type != "" && !strings.EqualFold(method.Type, "card") {
+			continue
+		}
+		usable = append(usable, method)
+	}
+	return usable
+}
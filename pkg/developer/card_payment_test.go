@@ -0,0 +1,69 @@
+// card_payment_test.go contains unit tests for the card payment helpers.
+package developer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardPayment(t *testing.T) {
+	pi := &PaymentIntent{ID: "pi_1", ClientSecret: "pi_1_secret"}
+	_, err := pi.CardPayment()
+	assert.Equal(t, ErrNoCardPayload, err)
+
+	pi.Charges = []*Charge{
+		{ID: "ch_1", Created: 100, PaymentMethodType: PaymentMethodTypeCards, StripePayload: &StripePayload{ClientSecret: "seti_1"}},
+		{ID: "ch_2", Created: 200, PaymentMethodType: PaymentMethodTypeCards, PayerMaxPayload: &PayerMaxPayload{SessionKey: "sk"}},
+		{ID: "ch_3", Created: 300, PaymentMethodType: PaymentMethodTypeCrypto},
+	}
+	payment, err := pi.CardPayment()
+	require.NoError(t, err)
+	assert.Equal(t, CardProviderPayerMax, payment.Provider)
+	assert.Equal(t, "ch_2", payment.Charge.ID)
+
+	order, err := payment.NewDropinOrder(pi, "tok_1")
+	require.NoError(t, err)
+	assert.Equal(t, &PayerMaxDropinOrderRequest{PaymentIntentID: "pi_1", ChargeID: "ch_2", ClientSecret: "pi_1_secret", PaymentToken: "tok_1"}, order)
+	_, err = payment.NewDropinOrder(pi, "")
+	assert.Error(t, err)
+
+	pi.Charges = pi.Charges[:1]
+	payment, err = pi.CardPayment()
+	require.NoError(t, err)
+	assert.Equal(t, CardProviderStripe, payment.Provider)
+	_, err = payment.NewDropinOrder(pi, "tok_1")
+	assert.Error(t, err)
+}
+
+func TestNewFiatOption(t *testing.T) {
+	saved := NewFiatOption("USD", "pm_1", true)
+	assert.Equal(t, "pm_1", *saved.PaymentMethodID)
+	assert.Nil(t, saved.SavePaymentMethod)
+
+	fresh := NewFiatOption("USD", "", true)
+	assert.Nil(t, fresh.PaymentMethodID)
+	assert.True(t, *fresh.SavePaymentMethod)
+
+	req := NewCardConfirmRequest("pi_1", "EUR", "", false)
+	assert.Equal(t, PaymentMethodTypeCards, *req.PaymentMethodType)
+	assert.Equal(t, "EUR", *req.PaymentMethodData.Fiat.Currency)
+	assert.Nil(t, req.PaymentMethodData.Fiat.SavePaymentMethod)
+}
+
+func TestSavedCards(t *testing.T) {
+	list := &PaymentMethodListResponse{PaymentMethods: []*PaymentMethodCard{
+		{ID: "pm_1", Type: "card", ExpMonth: 12, ExpYear: 2026},
+		{ID: "pm_2", Type: "card", ExpMonth: 9, ExpYear: 2026},
+	}}
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	assert.False(t, list.Find("pm_1").IsExpired(now))
+	assert.True(t, list.Find("pm_2").IsExpired(now))
+	assert.False(t, list.Find("pm_2").IsExpired(time.Date(2026, 9, 30, 23, 0, 0, 0, time.UTC)))
+	assert.Nil(t, list.Find("pm_3"))
+	usable := list.Usable(now)
+	require.Len(t, usable, 1)
+	assert.Equal(t, "pm_1", usable[0].ID)
+}
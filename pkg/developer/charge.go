@@ -228,3 +228,119 @@ type StripePayload struct {
 	// CustomerID is the Stripe customer ID if one exists
 	CustomerID *string `json:"customer_id,omitempty"`
 }
+
+// IsUnderReview reports whether the charge has an open review
+func (c *Charge) IsUnderReview() bool {
+	return c.Review != nil && c.Review.Open
+}
+
+// IsReportedFraudulent reports whether the charge was flagged as fraudulent by MartianPay or reported as fraudulent by the merchant
+func (c *Charge) IsReportedFraudulent() bool {
+	if c.FraudDetails == nil {
+		return false
+	}
+	return c.FraudDetails.MartianReport == ChargeFraudMartianReportFraudulent || c.FraudDetails.UserReport == ChargeFraudUserReportFraudulent
+}
+
+// IsValidCloseReason reports whether a merchant can close a review with this reason.
+// Disputed and redacted reviews are closed by MartianPay, not by the merchant.
+func (r ReviewClosedReason) IsValidCloseReason() bool {
+	switch r {
+	case ReviewClosedReasonApproved, ReviewClosedReasonRefunded, ReviewClosedReasonRefundedAsFraud:
+		return true
+	}
+	return false
+}
+
+// ================================
+// Request Types
+// ================================
+
+// ChargeListRequest lists charges with pagination and filters
+type ChargeListRequest struct {
+	Pagination
+	// PaymentIntent filters charges by payment intent ID
+	PaymentIntent *string `json:"payment_intent,omitempty" form:"payment_intent"`
+	// Customer filters charges by customer ID
+	Customer *string `json:"customer,omitempty" form:"customer"`
+}
+
+// ChargeFraudReportRequest reports a charge as fraudulent or safe.
+// Reports feed MartianPay's fraud detection; a fraudulent report does not refund the charge.
+type ChargeFraudReportRequest struct {
+	// ChargeID is the charge ID from URL path
+	ChargeID string `json:"-"`
+	// UserReport is the merchant's assessment of the charge (fraudulent or safe)
+	UserReport ChargeFraudUserReport `json:"user_report" binding:"required,oneof=fraudulent safe"`
+	// Description is an optional note explaining the report
+	Description *string `json:"description,omitempty"`
+}
+
+// ReviewListRequest lists reviews with pagination and filters
+type ReviewListRequest struct {
+	Pagination
+	// Open filters reviews by whether they are still open
+	Open *bool `json:"open,omitempty" form:"open"`
+	// Reason filters reviews by their current reason
+	Reason *ReviewReason `json:"reason,omitempty" form:"reason"`
+}
+
+// ReviewCloseRequest closes an open review.
+// Closing with refunded_as_fraud refunds the remaining amount of the charge and reports
+// it as fraudulent; approved lets the payment proceed.
+type ReviewCloseRequest struct {
+	// ReviewID is the review ID from URL path
+	ReviewID string `json:"-"`
+	// Reason is why the review is closed (approved, refunded, or refunded_as_fraud)
+	Reason ReviewClosedReason `json:"reason" binding:"required,oneof=approved refunded refunded_as_fraud"`
+	// RefundAddress is the address to refund crypto payments to (refunded and refunded_as_fraud only)
+	RefundAddress *string `json:"refund_address,omitempty"`
+	// Description is an optional note kept with the review
+	Description *string `json:"description,omitempty"`
+}
+
+// ================================
+// Response Types
+// ================================
+
+// ChargeGetResp represents charge get response
+type ChargeGetResp struct {
+	Charge
+}
+
+// ChargeListResp represents a paginated list of charges
+type ChargeListResp struct {
+	// Charges is the list of charges
+	Charges []*Charge `json:"charges"`
+	// Total is the total number of charges matching the filters
+	Total int64 `json:"total"`
+	// Page is the current page number
+	Page int32 `json:"page"`
+	// PageSize is the number of items per page
+	PageSize int32 `json:"page_size"`
+}
+
+// ReviewGetResp represents review get response
+type ReviewGetResp struct {
+	Review
+}
+
+// ReviewListResp represents a paginated list of reviews
+type ReviewListResp struct {
+	// Reviews is the list of reviews
+	Reviews []*Review `json:"reviews"`
+	// Total is the total number of reviews matching the filters
+	Total int64 `json:"total"`
+	// Page is the current page number
+	Page int32 `json:"page"`
+	// PageSize is the number of items per page
+	PageSize int32 `json:"page_size"`
+}
+
+// ReviewCloseResp represents the result of closing a review
+type ReviewCloseResp struct {
+	// Review is the closed review
+	Review *Review `json:"review"`
+	// Charge is the reviewed charge with its updated fraud details and refunds
+	Charge *Charge `json:"charge"`
+}
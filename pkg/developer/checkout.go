@@ -0,0 +1,382 @@
+// checkout.go contains a fluent builder for PaymentIntentCreateRequest. It checks the selected
+// variants, quantities, add-on limits, selling plan, and required addresses against a product
+// or payment link before the request is sent, and reports every problem at once.
+package developer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckoutError lists every problem found while building a checkout request
+type CheckoutError struct {
+	// Problems describes each invalid or missing field
+	Problems []string
+}
+
+func (e *CheckoutError) Error() string {
+	return "invalid checkout: " + strings.Join(e.Problems, "; ")
+}
+
+// CheckoutBuilder builds a PaymentIntentCreateRequest for a product or payment link.
+// Setters can be chained; Build validates the whole request.
+//
+//	req, err := developer.NewCheckoutForPaymentLink(link).
+//		Variant("var_large", 1).
+//		Addon("var_gift_wrap", 1).
+//		ShippingAddress(addr).
+//		TaxRegion(&developer.PaymentIntentTaxRegion{Country: "US"}).
+//		Build()
+type CheckoutBuilder struct {
+	product *Product
+	link    *PaymentLink
+	req     PaymentIntentCreateRequest
+	addons  []VariantSelectionRequest
+}
+
+// NewCheckoutForProduct starts a checkout for a product sold directly.
+// The amount and currency are computed from the selected variant prices unless set with Amount.
+func NewCheckoutForProduct(product *Product) *CheckoutBuilder {
+	return &CheckoutBuilder{product: product}
+}
+
+// NewCheckoutForPaymentLink starts a checkout through a payment link.
+// The payment link's product, primary variants, and add-on limits are enforced, and the
+// amount is computed by MartianPay.
+func NewCheckoutForPaymentLink(link *PaymentLink) *CheckoutBuilder {
+	b := &CheckoutBuilder{link: link}
+	if link != nil {
+		b.product = link.Product
+		id := link.ID
+		b.req.PaymentLinkID = &id
+	}
+	return b
+}
+
+// Variant selects the primary variant and its quantity
+func (b *CheckoutBuilder) Variant(variantID string, quantity int) *CheckoutBuilder {
+	var plan *string
+	if b.req.PrimaryVariant != nil {
+		plan = b.req.PrimaryVariant.SellingPlanID
+	}
+	b.req.PrimaryVariant = &VariantSelectionRequest{VariantID: variantID, Quantity: quantity, SellingPlanID: plan}
+	return b
+}
+
+// SellingPlan subscribes the primary variant to a selling plan
+func (b *CheckoutBuilder) SellingPlan(sellingPlanID string) *CheckoutBuilder {
+	if b.req.PrimaryVariant == nil {
+		b.req.PrimaryVariant = &VariantSelectionRequest{}
+	}
+	b.req.PrimaryVariant.SellingPlanID = &sellingPlanID
+	return b
+}
+
+// Addon adds an add-on variant with its quantity
+func (b *CheckoutBuilder) Addon(variantID string, quantity int) *CheckoutBuilder {
+	b.addons = append(b.addons, VariantSelectionRequest{VariantID: variantID, Quantity: quantity})
+	return b
+}
+
+// Amount sets the amount and currency explicitly instead of computing them from the variant prices
+func (b *CheckoutBuilder) Amount(amount, currency string) *CheckoutBuilder {
+	b.req.PaymentIntentParams.Amount = amount
+	b.req.PaymentIntentParams.Currency = currency
+	return b
+}
+
+// Customer sets the ID of the paying customer
+func (b *CheckoutBuilder) Customer(customerID string) *CheckoutBuilder {
+	b.req.Customer = &customerID
+	return b
+}
+
+// Description sets the description of the payment intent
+func (b *CheckoutBuilder) Description(description string) *CheckoutBuilder {
+	b.req.Description = &description
+	return b
+}
+
+// Metadata sets a metadata key
+func (b *CheckoutBuilder) Metadata(key, value string) *CheckoutBuilder {
+	if b.req.PaymentIntentParams.Metadata == nil {
+		b.req.PaymentIntentParams.Metadata = make(map[string]string)
+	}
+	b.req.PaymentIntentParams.Metadata[key] = value
+	return b
+}
+
+// MerchantOrderID sets the merchant's order ID (must be unique per merchant)
+func (b *CheckoutBuilder) MerchantOrderID(orderID string) *CheckoutBuilder {
+	b.req.MerchantOrderId = orderID
+	return b
+}
+
+// ReceiptEmail sets the email address the receipt is sent to
+func (b *CheckoutBuilder) ReceiptEmail(email string) *CheckoutBuilder {
+	b.req.ReceiptEmail = email
+	return b
+}
+
+// ReturnURL sets the URL the customer is redirected to after payment
+func (b *CheckoutBuilder) ReturnURL(url string) *CheckoutBuilder {
+	b.req.ReturnURL = &url
+	return b
+}
+
+// ShippingAddress sets the shipping address, required when the product collects one
+func (b *CheckoutBuilder) ShippingAddress(addr *PaymentIntentShippingAddress) *CheckoutBuilder {
+	b.req.ShippingAddress = addr
+	return b
+}
+
+// TaxRegion sets the tax jurisdiction, required when the product collects a tax address
+func (b *CheckoutBuilder) TaxRegion(region *PaymentIntentTaxRegion) *CheckoutBuilder {
+	b.req.TaxRegion = region
+	return b
+}
+
+// Build validates the checkout and returns the request
+//
+// Returns:
+//   - *PaymentIntentCreateRequest: The request, ready for CreatePaymentIntent
+//   - error: *CheckoutError listing every problem, or nil
+func (b *CheckoutBuilder) Build() (*PaymentIntentCreateRequest, error) {
+	var problems []string
+	problemf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	req := b.req
+	req.Addons = append([]VariantSelectionRequest(nil), b.addons...)
+
+	if b.link != nil && !b.link.Active {
+		problemf("payment link %s is not active", b.link.ID)
+	}
+	if b.link == nil && b.product == nil {
+		problemf("a product or payment link is required")
+	}
+
+	product := b.product
+	if product != nil {
+		if !product.Active {
+			problemf("product %s is not active", product.ID)
+		}
+		if product.Version > 0 {
+			version := product.Version
+			req.ProductVersion = &version
+		}
+		b.checkPrimary(&req, problemf)
+		b.checkAddons(&req, problemf)
+		if product.CollectShippingAddress {
+			if err := req.ShippingAddress.Validate(); err != nil {
+				problemf("%v", err)
+			}
+		}
+		if product.CollectTaxAddress {
+			if err := req.TaxRegion.Validate(); err != nil {
+				problemf("%v", err)
+			}
+		}
+	} else if req.PrimaryVariant != nil || len(req.Addons) > 0 {
+		problemf("payment link %s has no product to select variants from", b.link.ID)
+	}
+
+	if b.link == nil && req.PaymentIntentParams.Amount == "" && product != nil && len(problems) == 0 {
+		total, err := b.total(&req)
+		if err != nil {
+			problemf("%v", err)
+		} else if total != nil {
+			req.PaymentIntentParams.Amount = total.Amount.String()
+			req.PaymentIntentParams.Currency = total.AssetId
+		}
+	}
+	if b.link == nil && (req.PaymentIntentParams.Amount == "" || req.PaymentIntentParams.Currency == "") && len(problems) == 0 {
+		problemf("amount and currency are required when the product has no price")
+	}
+
+	if len(problems) > 0 {
+		return nil, &CheckoutError{Problems: problems}
+	}
+	return &req, nil
+}
+
+// checkPrimary validates the primary variant selection and the selling plan
+func (b *CheckoutBuilder) checkPrimary(req *PaymentIntentCreateRequest, problemf func(string, ...interface{})) {
+	product := b.product
+	primary := req.PrimaryVariant
+
+	if primary == nil || primary.VariantID == "" {
+		if len(product.Variants) > 0 {
+			problemf("a primary variant of product %s is required", product.ID)
+		}
+		if primary != nil && primary.SellingPlanID != nil {
+			problemf("a selling plan requires a primary variant")
+		}
+		if product.RequiresSellingPlan {
+			problemf("product %s is only sold by subscription; a selling plan is required", product.ID)
+		}
+		req.PrimaryVariant = nil
+		return
+	}
+
+	if err := primary.Validate("primary"); err != nil {
+		problemf("%v", err)
+	}
+	variant := findVariant(product, primary.VariantID)
+	if variant == nil {
+		problemf("primary variant %s does not belong to product %s", primary.VariantID, product.ID)
+		return
+	}
+	if !variant.Active {
+		problemf("primary variant %s is not active", variant.ID)
+	}
+	if variant.InventoryQuantity != nil && primary.Quantity > *variant.InventoryQuantity {
+		problemf("primary variant %s has only %d in stock", variant.ID, *variant.InventoryQuantity)
+	}
+	if b.link != nil && len(b.link.PrimaryVariants) > 0 && findLinkVariant(b.link.PrimaryVariants, variant.ID) == nil {
+		problemf("variant %s is not a primary variant of payment link %s", variant.ID, b.link.ID)
+	}
+
+	if primary.SellingPlanID == nil {
+		if product.RequiresSellingPlan {
+			problemf("product %s is only sold by subscription; a selling plan is required", product.ID)
+		}
+		return
+	}
+	groups := variant.SellingPlanGroups
+	if len(groups) == 0 {
+		groups = product.SellingPlanGroups
+	}
+	if len(groups) > 0 && !hasSellingPlan(groups, *primary.SellingPlanID) {
+		problemf("selling plan %s is not offered for variant %s", *primary.SellingPlanID, variant.ID)
+	}
+}
+
+// checkAddons validates the add-on selections against the product and the payment link's limits
+func (b *CheckoutBuilder) checkAddons(req *PaymentIntentCreateRequest, problemf func(string, ...interface{})) {
+	product := b.product
+	selected := make(map[string]int)
+	for i := range req.Addons {
+		addon := &req.Addons[i]
+		if err := addon.Validate("addon"); err != nil {
+			problemf("%v", err)
+			continue
+		}
+		if _, dup := selected[addon.VariantID]; dup {
+			problemf("addon %s is selected more than once", addon.VariantID)
+			continue
+		}
+		selected[addon.VariantID] = addon.Quantity
+
+		if req.PrimaryVariant != nil && addon.VariantID == req.PrimaryVariant.VariantID {
+			problemf("variant %s cannot be both the primary variant and an addon", addon.VariantID)
+		}
+		variant := findVariant(product, addon.VariantID)
+		if variant == nil {
+			problemf("addon %s does not belong to product %s", addon.VariantID, product.ID)
+			continue
+		}
+		if !variant.Active {
+			problemf("addon %s is not active", addon.VariantID)
+		}
+		if variant.InventoryQuantity != nil && addon.Quantity > *variant.InventoryQuantity {
+			problemf("addon %s has only %d in stock", addon.VariantID, *variant.InventoryQuantity)
+		}
+		if b.link == nil {
+			continue
+		}
+		limits := findLinkVariant(b.link.AddonVariants, addon.VariantID)
+		if limits == nil {
+			problemf("variant %s is not an addon of payment link %s", addon.VariantID, b.link.ID)
+			continue
+		}
+		if limits.MinQuantity != nil && addon.Quantity < *limits.MinQuantity {
+			problemf("addon %s quantity %d is below the minimum of %d", addon.VariantID, addon.Quantity, *limits.MinQuantity)
+		}
+		if limits.MaxQuantity != nil && addon.Quantity > *limits.MaxQuantity {
+			problemf("addon %s quantity %d is above the maximum of %d", addon.VariantID, addon.Quantity, *limits.MaxQuantity)
+		}
+	}
+
+	// Add-ons with a minimum quantity must be selected
+	if b.link != nil {
+		for _, lv := range b.link.AddonVariants {
+			if _, ok := selected[lv.VariantID]; !ok && lv.MinQuantity != nil && *lv.MinQuantity > 0 {
+				problemf("addon %s is required with a quantity of at least %d", lv.VariantID, *lv.MinQuantity)
+			}
+		}
+	}
+}
+
+// total computes the price of the selected variants, or the product price for simple products
+func (b *CheckoutBuilder) total(req *PaymentIntentCreateRequest) (*AssetAmount, error) {
+	product := b.product
+	if req.PrimaryVariant == nil {
+		price := product.FixedPrice
+		if price == nil {
+			price = product.Price
+		}
+		return price, nil
+	}
+
+	var total *AssetAmount
+	add := func(variantID string, quantity int) error {
+		variant := findVariant(product, variantID)
+		if variant.Price == nil {
+			return fmt.Errorf("variant %s has no price", variantID)
+		}
+		line := variant.Price.Mul(int64(quantity))
+		if total == nil {
+			total = line
+			return nil
+		}
+		sum, err := total.Add(line)
+		if err != nil {
+			return fmt.Errorf("cannot total variant prices: %v", err)
+		}
+		total = sum
+		return nil
+	}
+	if err := add(req.PrimaryVariant.VariantID, req.PrimaryVariant.Quantity); err != nil {
+		return nil, err
+	}
+	for _, addon := range req.Addons {
+		if err := add(addon.VariantID, addon.Quantity); err != nil {
+			return nil, err
+		}
+	}
+	return total, nil
+}
+
+func findVariant(product *Product, variantID string) *ProductVariant {
+	for _, v := range product.Variants {
+		if v != nil && v.ID == variantID {
+			return v
+		}
+	}
+	return nil
+}
+
+func findLinkVariant(variants []*PaymentLinkVariant, variantID string) *PaymentLinkVariant {
+	for _, v := range variants {
+		if v != nil && v.VariantID == variantID {
+			return v
+		}
+	}
+	return nil
+}
+
+func hasSellingPlan(groups []*SellingPlanGroupWithPlans, planID string) bool {
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		for _, p := range g.SellingPlans {
+			if p != nil && p.ID == planID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+// checkout_test.go contains unit tests for the checkout request builder.
+package developer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckoutBuilder(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	price := func(s string) *AssetAmount { return NewAssetAmount(decimal.RequireFromString(s), "USD", 2) }
+	product := &Product{
+		ID:                     "prod_1",
+		Active:                 true,
+		Version:                3,
+		CollectShippingAddress: true,
+		Variants: []*ProductVariant{
+			{ID: "var_small", Active: true, Price: price("10")},
+			{ID: "var_large", Active: true, Price: price("15.5"), InventoryQuantity: intPtr(2)},
+			{ID: "var_wrap", Active: true, Price: price("2")},
+		},
+	}
+	addr := &PaymentIntentShippingAddress{Country: "US", City: "Austin", PostalCode: "78701", Line1: "1 Main St"}
+
+	req, err := NewCheckoutForProduct(product).Variant("var_large", 2).Addon("var_wrap", 1).ShippingAddress(addr).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "33", req.Amount)
+	assert.Equal(t, "USD", req.Currency)
+	assert.Equal(t, int64(3), *req.ProductVersion)
+	assert.Len(t, req.Addons, 1)
+
+	link := &PaymentLink{
+		ID:              "plink_1",
+		Active:          true,
+		Product:         product,
+		PrimaryVariants: []*PaymentLinkVariant{{VariantID: "var_small", IsPrimary: true}},
+		AddonVariants:   []*PaymentLinkVariant{{VariantID: "var_wrap", MinQuantity: intPtr(1), MaxQuantity: intPtr(3)}},
+	}
+	_, err = NewCheckoutForPaymentLink(link).Variant("var_large", 3).Build()
+	var checkoutErr *CheckoutError
+	require.True(t, errors.As(err, &checkoutErr))
+	assert.Equal(t, []string{
+		"primary variant var_large has only 2 in stock",
+		"variant var_large is not a primary variant of payment link plink_1",
+		"addon var_wrap is required with a quantity of at least 1",
+		"shipping address is required",
+	}, checkoutErr.Problems)
+
+	req, err = NewCheckoutForPaymentLink(link).Variant("var_small", 1).Addon("var_wrap", 3).ShippingAddress(addr).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "plink_1", *req.PaymentLinkID)
+	assert.Empty(t, req.Amount, "payment link checkouts are priced by MartianPay")
+}
@@ -74,3 +74,43 @@ type Transaction struct {
 	// FeeCurrency is the asset used to pay the transaction fee (e.g., ETH for ERC-20 tokens)
 	FeeCurrency string `json:"fee_currency,omitempty"`
 }
+
+// ================================
+// Request Types
+// ================================
+
+// DepositAccountCreateRequest gets or creates the permanent deposit address of a user for an asset.
+// The address is stable: repeating the request for the same user and asset returns the same account.
+type DepositAccountCreateRequest struct {
+	// UserId is the merchant's identifier for the end user
+	UserId string `json:"user_id" binding:"required"`
+	// AssetId is the asset to receive (determines the network of the address)
+	AssetId string `json:"asset_id" binding:"required"`
+}
+
+// DepositAccountListRequest lists deposit accounts with filters
+type DepositAccountListRequest struct {
+	Pagination
+	// UserId filters deposit accounts by end user
+	UserId *string `json:"user_id,omitempty" form:"user_id"`
+	// AssetId filters deposit accounts by asset
+	AssetId *string `json:"asset_id,omitempty" form:"asset_id"`
+	// IsPermanent filters deposit accounts by permanent flag
+	IsPermanent *bool `json:"is_permanent,omitempty" form:"is_permanent"`
+}
+
+// ================================
+// Response Types
+// ================================
+
+// DepositAccountListResponse represents a paginated list of deposit accounts
+type DepositAccountListResponse struct {
+	// DepositAccounts is the list of deposit accounts
+	DepositAccounts []*DepositAccount `json:"deposit_accounts"`
+	// Total is the total number of deposit accounts matching the filters
+	Total int64 `json:"total"`
+	// Page is the current page number
+	Page int32 `json:"page"`
+	// PageSize is the number of items per page
+	PageSize int32 `json:"page_size"`
+}
@@ -0,0 +1,143 @@
+// deposit_totals.go contains helpers for reading deposits to permanent deposit addresses.
+// A deposit is credited once its transaction is confirmed on-chain and approved by AML
+// screening; until then it is pending. Refund transactions (Type 1) leave the address and
+// are never counted as deposits.
+package developer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// TransactionTypeDeposit is a transaction sending funds to a deposit address
+	TransactionTypeDeposit int32 = 0
+	// TransactionTypeRefund is a transaction returning funds to the payer
+	TransactionTypeRefund int32 = 1
+)
+
+// IsDeposit reports whether the transaction sends funds to the deposit address
+func (t *Transaction) IsDeposit() bool {
+	return t.Type == TransactionTypeDeposit
+}
+
+// IsCredited reports whether the transaction is a deposit that is confirmed on-chain and approved by AML screening.
+// Only credited deposits should be added to a user's balance.
+func (t *Transaction) IsCredited() bool {
+	return t.IsDeposit() && t.Status == "confirmed" && ParseAmlStatus(t.AmlStatus).IsApproved()
+}
+
+// IsPending reports whether the transaction is a deposit that may still be credited
+func (t *Transaction) IsPending() bool {
+	if !t.IsDeposit() || t.IsCredited() || ParseAmlStatus(t.AmlStatus).IsRejected() {
+		return false
+	}
+	return t.Status == "submitted" || t.Status == "completed" || t.Status == "confirmed"
+}
+
+// DepositTotal summarizes the deposits of one asset
+type DepositTotal struct {
+	// AssetId is the asset of the deposits
+	AssetId string `json:"asset_id"`
+	// Credited is the sum of confirmed, AML-approved deposits
+	Credited decimal.Decimal `json:"credited"`
+	// Pending is the sum of deposits that are not yet confirmed or screened
+	Pending decimal.Decimal `json:"pending"`
+	// Rejected is the sum of deposits that failed or were rejected by AML screening
+	Rejected decimal.Decimal `json:"rejected"`
+	// Transactions is the number of deposit transactions counted
+	Transactions int `json:"transactions"`
+}
+
+// DepositTotals sums the deposits of the accounts per asset.
+// Transactions are counted once per hash, so accounts from overlapping list pages can be combined.
+//
+// Parameters:
+//   - accounts: Deposit accounts, e.g. every account of one user
+//
+// Returns:
+//   - []*DepositTotal: Totals per asset, sorted by asset ID
+//   - error: Error if a transaction amount cannot be parsed
+func DepositTotals(accounts []*DepositAccount) ([]*DepositTotal, error) {
+	totals := make(map[string]*DepositTotal)
+	seen := make(map[string]bool)
+	for _, account := range accounts {
+		if account == nil {
+			continue
+		}
+		for _, tx := range account.Transactions {
+			if tx == nil || !tx.IsDeposit() {
+				continue
+			}
+			if tx.TxHash != "" {
+				if seen[tx.TxHash] {
+					continue
+				}
+				seen[tx.TxHash] = true
+			}
+			amount, err := decimal.NewFromString(tx.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing amount of transaction %s: %v", tx.TxHash, err)
+			}
+			assetID := tx.AssetId
+			if assetID == "" {
+				assetID = account.AssetId
+			}
+			total, ok := totals[assetID]
+			if !ok {
+				total = &DepositTotal{AssetId: assetID}
+				totals[assetID] = total
+			}
+			total.Transactions++
+			switch {
+			case tx.IsCredited():
+				total.Credited = total.Credited.Add(amount)
+			case tx.IsPending():
+				total.Pending = total.Pending.Add(amount)
+			default:
+				total.Rejected = total.Rejected.Add(amount)
+			}
+		}
+	}
+
+	result := make([]*DepositTotal, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, total)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].AssetId < result[j].AssetId })
+	return result, nil
+}
+
+// DepositTransactions returns the deposit transactions of the accounts, oldest first.
+// Refund transactions are left out and each transaction hash appears once.
+//
+// Parameters:
+//   - accounts: Deposit accounts, e.g. every account of one user
+//
+// Returns:
+//   - []*Transaction: The deposit transactions
+func DepositTransactions(accounts []*DepositAccount) []*Transaction {
+	var result []*Transaction
+	seen := make(map[string]bool)
+	for _, account := range accounts {
+		if account == nil {
+			continue
+		}
+		for _, tx := range account.Transactions {
+			if tx == nil || !tx.IsDeposit() {
+				continue
+			}
+			if tx.TxHash != "" {
+				if seen[tx.TxHash] {
+					continue
+				}
+				seen[tx.TxHash] = true
+			}
+			result = append(result, tx)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CreatedAt < result[j].CreatedAt })
+	return result
+}
@@ -15,13 +15,9 @@
 package developer
 
 import (
-	"bytes"
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
-	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"time"
@@ -119,68 +115,6 @@ func GenerateApiKey(keyType string, isLive bool) (key string, err error) {
 	return key, nil
 }
 
-// EncryptData encrypts data using AES-256-CBC encryption with PKCS7 padding
-// Returns the encrypted data as a base64-encoded string that includes the IV prefix
-func EncryptData(data string, encryptionKey string) (string, error) {
-	key := []byte(encryptionKey)
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	// Use fixed IV instead of random
-	iv := bytes.Repeat([]byte{0x00}, aes.BlockSize)
-
-	// Pad data to be multiple of block size
-	padding := aes.BlockSize - len(data)%aes.BlockSize
-	padtext := bytes.Repeat([]byte{byte(padding)}, padding)
-	data = data + string(padtext)
-
-	// Encrypt
-	mode := cipher.NewCBCEncrypter(block, iv)
-	ciphertext := make([]byte, len(data))
-	mode.CryptBlocks(ciphertext, []byte(data))
-
-	// Combine IV and ciphertext
-	result := append(iv, ciphertext...)
-	return base64.StdEncoding.EncodeToString(result), nil
-}
-
-// DecryptData decrypts AES-256-CBC encrypted data with PKCS7 padding
-// Takes a base64-encoded encrypted string and returns the original plaintext
-func DecryptData(encryptedData string, encryptionKey string) (string, error) {
-	key := []byte(encryptionKey)
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	// Decode base64
-	ciphertext, err := base64.StdEncoding.DecodeString(encryptedData)
-	if err != nil {
-		return "", err
-	}
-
-	if len(ciphertext) < aes.BlockSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-
-	// Extract IV
-	iv := ciphertext[:aes.BlockSize]
-	ciphertext = ciphertext[aes.BlockSize:]
-
-	// Decrypt
-	mode := cipher.NewCBCDecrypter(block, iv)
-	mode.CryptBlocks(ciphertext, ciphertext)
-
-	// Unpad
-	padding := int(ciphertext[len(ciphertext)-1])
-	if padding < 1 || padding > aes.BlockSize {
-		return "", fmt.Errorf("invalid padding")
-	}
-	return string(ciphertext[:len(ciphertext)-padding]), nil
-}
-
 const (
 	// DeveloperStatusActive indicates the resource is active and operational
 	DeveloperStatusActive = "active"
@@ -0,0 +1,353 @@
+// encryption.go contains helpers for encrypting secrets at rest, such as API keys and bank details.
+// New ciphertexts use AES-GCM with a random nonce and a versioned prefix that names the key used,
+// so keys can be rotated with a Keyring. Ciphertexts written by the earlier AES-CBC scheme are
+// still readable and can be upgraded with ReencryptData or Keyring.Reencrypt; once every stored
+// value has been upgraded, Keyring.DisableLegacy stops accepting them.
+//
+// Ciphertext format:
+//
+//	v2:<key ID>:<base64(nonce || ciphertext || tag)>
+//
+// The version and key ID are authenticated together with any caller-supplied associated data,
+// so a ciphertext cannot be relabelled with another key ID or moved to another record.
+package developer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// EncryptionVersionGCM is the version prefix of AES-GCM ciphertexts
+	EncryptionVersionGCM = "v2"
+)
+
+var (
+	// ErrDecryptionFailed is returned when a ciphertext is malformed, was tampered with, or the key is wrong
+	ErrDecryptionFailed = errors.New("decryption failed")
+	// ErrUnknownKeyID is returned when a ciphertext names a key that is not in the keyring
+	ErrUnknownKeyID = errors.New("unknown encryption key ID")
+	// ErrLegacyCiphertext is returned by a keyring with legacy ciphertexts disabled
+	ErrLegacyCiphertext = errors.New("legacy AES-CBC ciphertexts are disabled")
+)
+
+// EncryptData encrypts data with AES-GCM under a 16, 24 or 32 byte key.
+// Each call uses a random nonce, so encrypting the same data twice gives different ciphertexts.
+//
+// Parameters:
+//   - data: Plaintext to encrypt
+//   - encryptionKey: AES key (32 bytes for AES-256)
+//
+// Returns:
+//   - string: Versioned ciphertext ("v2::...")
+//   - error: Error if the key has an invalid length
+func EncryptData(data string, encryptionKey string) (string, error) {
+	return encryptGCM(data, []byte(encryptionKey), "", nil)
+}
+
+// EncryptDataWithAssociatedData is like EncryptData but binds the ciphertext to associatedData,
+// such as the ID of the record the secret belongs to. The same associated data must be passed to
+// DecryptDataWithAssociatedData.
+func EncryptDataWithAssociatedData(data string, encryptionKey string, associatedData []byte) (string, error) {
+	return encryptGCM(data, []byte(encryptionKey), "", associatedData)
+}
+
+// DecryptData decrypts a ciphertext produced by EncryptData, or a legacy AES-CBC ciphertext.
+// Legacy ciphertexts are not authenticated; after migrating, decrypt through a Keyring with
+// DisableLegacy so they are rejected.
+//
+// Parameters:
+//   - encryptedData: Ciphertext in the "v2:" format, or a legacy base64 AES-CBC ciphertext
+//   - encryptionKey: AES key the data was encrypted with
+//
+// Returns:
+//   - string: The plaintext
+//   - error: ErrDecryptionFailed if the ciphertext is malformed, tampered with, or the key is wrong
+func DecryptData(encryptedData string, encryptionKey string) (string, error) {
+	return DecryptDataWithAssociatedData(encryptedData, encryptionKey, nil)
+}
+
+// DecryptDataWithAssociatedData decrypts a ciphertext produced by EncryptDataWithAssociatedData.
+// Legacy AES-CBC ciphertexts carry no associated data, so they are rejected with
+// ErrDecryptionFailed whenever associatedData is set; otherwise a legacy value copied from
+// another record would pass for this one.
+func DecryptDataWithAssociatedData(encryptedData string, encryptionKey string, associatedData []byte) (string, error) {
+	if IsLegacyCiphertext(encryptedData) && associatedData != nil {
+		return "", ErrDecryptionFailed
+	}
+	if !IsLegacyCiphertext(encryptedData) {
+		keyID, _, err := parseCiphertext(encryptedData)
+		if err != nil {
+			return "", err
+		}
+		return decryptGCM(encryptedData, keyID, []byte(encryptionKey), associatedData)
+	}
+	return decryptLegacyCBC(encryptedData, []byte(encryptionKey))
+}
+
+// ReencryptData decrypts a ciphertext with oldKey and encrypts the plaintext with newKey.
+// Use it to migrate legacy AES-CBC ciphertexts (pass the same key twice) or to change keys.
+func ReencryptData(encryptedData string, oldKey string, newKey string) (string, error) {
+	plaintext, err := DecryptData(encryptedData, oldKey)
+	if err != nil {
+		return "", err
+	}
+	return EncryptData(plaintext, newKey)
+}
+
+// IsLegacyCiphertext reports whether encryptedData was written by the legacy AES-CBC scheme
+func IsLegacyCiphertext(encryptedData string) bool {
+	return !strings.HasPrefix(encryptedData, EncryptionVersionGCM+":")
+}
+
+// CiphertextKeyID returns the key ID recorded in a versioned ciphertext ("" for legacy ciphertexts)
+func CiphertextKeyID(encryptedData string) (string, error) {
+	if IsLegacyCiphertext(encryptedData) {
+		return "", nil
+	}
+	keyID, _, err := parseCiphertext(encryptedData)
+	return keyID, err
+}
+
+// Keyring holds several encryption keys by ID. New data is encrypted with the primary key,
+// and data encrypted with any key in the ring can be decrypted, which allows keys to be rotated.
+type Keyring struct {
+	primary        string
+	legacyKey      string
+	legacyDisabled bool
+	keys           map[string][]byte
+}
+
+// NewKeyring creates a keyring.
+//
+// Parameters:
+//   - primaryKeyID: ID of the key used for new ciphertexts; must be present in keys
+//   - keys: AES keys by ID; IDs must be non-empty and must not contain ':'
+//
+// Returns:
+//   - *Keyring: The keyring
+//   - error: Error if a key ID or key length is invalid
+func NewKeyring(primaryKeyID string, keys map[string]string) (*Keyring, error) {
+	k := &Keyring{primary: primaryKeyID, keys: make(map[string][]byte, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid encryption key ID %q", id)
+		}
+		if _, err := aes.NewCipher([]byte(key)); err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %v", id, err)
+		}
+		k.keys[id] = []byte(key)
+	}
+	if _, ok := k.keys[primaryKeyID]; !ok {
+		return nil, fmt.Errorf("primary encryption key %q is not in the keyring", primaryKeyID)
+	}
+	return k, nil
+}
+
+// SetLegacyKeyID sets the key used for legacy AES-CBC and unnamed "v2::" ciphertexts,
+// which carry no key ID (defaults to the primary key)
+func (k *Keyring) SetLegacyKeyID(keyID string) error {
+	if _, ok := k.keys[keyID]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownKeyID, keyID)
+	}
+	k.legacyKey = keyID
+	return nil
+}
+
+// DisableLegacy makes the keyring reject legacy AES-CBC ciphertexts with ErrLegacyCiphertext.
+// Call it once every stored value has been upgraded with Reencrypt, so an unauthenticated
+// ciphertext supplied by an attacker is never decrypted.
+func (k *Keyring) DisableLegacy() {
+	k.legacyDisabled = true
+}
+
+// PrimaryKeyID returns the ID of the key used for new ciphertexts
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// Encrypt encrypts data with the primary key, binding it to associatedData (may be nil)
+func (k *Keyring) Encrypt(data string, associatedData []byte) (string, error) {
+	return encryptGCM(data, k.keys[k.primary], k.primary, associatedData)
+}
+
+// Decrypt decrypts a ciphertext encrypted with any key in the ring, or a legacy ciphertext.
+// Legacy ciphertexts are only accepted when associatedData is nil, as in DecryptDataWithAssociatedData,
+// and legacy support has not been disabled.
+func (k *Keyring) Decrypt(encryptedData string, associatedData []byte) (string, error) {
+	if IsLegacyCiphertext(encryptedData) {
+		if k.legacyDisabled {
+			return "", ErrLegacyCiphertext
+		}
+		if associatedData != nil {
+			return "", ErrDecryptionFailed
+		}
+		return decryptLegacyCBC(encryptedData, k.keys[k.legacyKeyID()])
+	}
+	keyID, _, err := parseCiphertext(encryptedData)
+	if err != nil {
+		return "", err
+	}
+	lookup := keyID
+	if lookup == "" {
+		lookup = k.legacyKeyID()
+	}
+	key, ok := k.keys[lookup]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKeyID, keyID)
+	}
+	return decryptGCM(encryptedData, keyID, key, associatedData)
+}
+
+// NeedsReencrypt reports whether a ciphertext is legacy or was encrypted with a non-primary key
+func (k *Keyring) NeedsReencrypt(encryptedData string) bool {
+	keyID, err := CiphertextKeyID(encryptedData)
+	return err == nil && (IsLegacyCiphertext(encryptedData) || keyID != k.primary)
+}
+
+// Reencrypt upgrades a ciphertext to the primary key. Ciphertexts that are already current are
+// returned unchanged, so it is safe to run over every stored value during a migration.
+// Legacy ciphertexts carry no associated data; they are decrypted without it and bound to
+// associatedData as they are upgraded, so pass each value the associated data of its own record.
+//
+// Returns:
+//   - string: The current ciphertext
+//   - bool: true if the ciphertext was rewritten and should be saved
+//   - error: Error if the ciphertext cannot be decrypted
+func (k *Keyring) Reencrypt(encryptedData string, associatedData []byte) (string, bool, error) {
+	if !k.NeedsReencrypt(encryptedData) {
+		if _, err := CiphertextKeyID(encryptedData); err != nil {
+			return "", false, err
+		}
+		return encryptedData, false, nil
+	}
+	decryptAAD := associatedData
+	if IsLegacyCiphertext(encryptedData) {
+		decryptAAD = nil
+	}
+	plaintext, err := k.Decrypt(encryptedData, decryptAAD)
+	if err != nil {
+		return "", false, err
+	}
+	out, err := k.Encrypt(plaintext, associatedData)
+	if err != nil {
+		return "", false, err
+	}
+	return out, true, nil
+}
+
+func (k *Keyring) legacyKeyID() string {
+	if k.legacyKey != "" {
+		return k.legacyKey
+	}
+	return k.primary
+}
+
+// ciphertextPrefix returns the authenticated "v2:<keyID>:" prefix
+func ciphertextPrefix(keyID string) string {
+	return EncryptionVersionGCM + ":" + keyID + ":"
+}
+
+// gcmAAD binds the version and key ID to the caller's associated data
+func gcmAAD(keyID string, associatedData []byte) []byte {
+	prefix := ciphertextPrefix(keyID)
+	aad := make([]byte, 0, len(prefix)+len(associatedData))
+	aad = append(aad, prefix...)
+	return append(aad, associatedData...)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encryptGCM(data string, key []byte, keyID string, associatedData []byte) (string, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(data), gcmAAD(keyID, associatedData))
+	return ciphertextPrefix(keyID) + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// parseCiphertext splits a versioned ciphertext into its key ID and decoded body
+func parseCiphertext(encryptedData string) (string, []byte, error) {
+	parts := strings.SplitN(encryptedData, ":", 3)
+	if len(parts) != 3 || parts[0] != EncryptionVersionGCM {
+		return "", nil, ErrDecryptionFailed
+	}
+	body, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, ErrDecryptionFailed
+	}
+	return parts[1], body, nil
+}
+
+func decryptGCM(encryptedData string, keyID string, key []byte, associatedData []byte) (string, error) {
+	_, body, err := parseCiphertext(encryptedData)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(body) < aead.NonceSize()+aead.Overhead() {
+		return "", ErrDecryptionFailed
+	}
+	nonce, sealed := body[:aead.NonceSize()], body[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, gcmAAD(keyID, associatedData))
+	if err != nil {
+		return "", ErrDecryptionFailed
+	}
+	return string(plaintext), nil
+}
+
+// decryptLegacyCBC decrypts the legacy format: base64(IV || AES-CBC ciphertext) with PKCS7 padding.
+// The padding is checked in constant time, and every failure returns the same error, so the
+// result cannot be used as a padding oracle.
+func decryptLegacyCBC(encryptedData string, key []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(encryptedData)
+	if err != nil {
+		return "", ErrDecryptionFailed
+	}
+	if len(raw) < 2*aes.BlockSize || len(raw)%aes.BlockSize != 0 {
+		return "", ErrDecryptionFailed
+	}
+
+	iv := raw[:aes.BlockSize]
+	plaintext := make([]byte, len(raw)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, raw[aes.BlockSize:])
+
+	// Check every byte of the last block without branching on secret data
+	padding := plaintext[len(plaintext)-1]
+	good := subtle.ConstantTimeLessOrEq(1, int(padding)) & subtle.ConstantTimeLessOrEq(int(padding), aes.BlockSize)
+	for i := 0; i < aes.BlockSize; i++ {
+		b := plaintext[len(plaintext)-1-i]
+		inPadding := subtle.ConstantTimeLessOrEq(i+1, int(padding))
+		matches := subtle.ConstantTimeByteEq(b, padding)
+		// Bytes inside the padding must equal the padding length
+		good &= subtle.ConstantTimeSelect(inPadding, matches, 1)
+	}
+	if good != 1 {
+		return "", ErrDecryptionFailed
+	}
+	return string(plaintext[:len(plaintext)-int(padding)]), nil
+}
@@ -0,0 +1,139 @@
+// encryption_test.go contains unit tests for the authenticated encryption helpers.
+package developer
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKeyOld = "0123456789abcdef0123456789abcdef"
+	testKeyNew = "fedcba9876543210fedcba9876543210"
+)
+
+// legacyEncrypt reproduces the former zero-IV AES-CBC scheme
+func legacyEncrypt(t *testing.T, data string, key string) string {
+	block, err := aes.NewCipher([]byte(key))
+	require.NoError(t, err)
+	iv := make([]byte, aes.BlockSize)
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	padded := append([]byte(data), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	out := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, padded)
+	return base64.StdEncoding.EncodeToString(append(iv, out...))
+}
+
+func TestEncryptDataRoundTripAndTampering(t *testing.T) {
+	a, err := EncryptData("sk_live_secret", testKeyOld)
+	require.NoError(t, err)
+	b, err := EncryptData("sk_live_secret", testKeyOld)
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b, "random nonces must give distinct ciphertexts")
+	assert.True(t, strings.HasPrefix(a, "v2::"))
+
+	plain, err := DecryptData(a, testKeyOld)
+	require.NoError(t, err)
+	assert.Equal(t, "sk_live_secret", plain)
+
+	_, err = DecryptData(a, testKeyNew)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(a, "v2::"))
+	raw[len(raw)-1] ^= 1
+	_, err = DecryptData("v2::"+base64.StdEncoding.EncodeToString(raw), testKeyOld)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	bound, err := EncryptDataWithAssociatedData("IBAN DE89", testKeyOld, []byte("merchant_1"))
+	require.NoError(t, err)
+	_, err = DecryptDataWithAssociatedData(bound, testKeyOld, []byte("merchant_2"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestLegacyCiphertextsAndKeyRotation(t *testing.T) {
+	legacy := legacyEncrypt(t, "bank account 42", testKeyOld)
+	plain, err := DecryptData(legacy, testKeyOld)
+	require.NoError(t, err)
+	assert.Equal(t, "bank account 42", plain)
+
+	// Truncated or wrongly padded legacy data fails with the generic error
+	raw, _ := base64.StdEncoding.DecodeString(legacy)
+	_, err = DecryptData(base64.StdEncoding.EncodeToString(raw[:len(raw)-1]), testKeyOld)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	_, err = DecryptData(legacy, testKeyNew)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	ring, err := NewKeyring("k2", map[string]string{"k1": testKeyOld, "k2": testKeyNew})
+	require.NoError(t, err)
+	require.NoError(t, ring.SetLegacyKeyID("k1"))
+
+	upgraded, changed, err := ring.Reencrypt(legacy, nil)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, strings.HasPrefix(upgraded, "v2:k2:"))
+
+	again, changed, err := ring.Reencrypt(upgraded, nil)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, upgraded, again)
+
+	plain, err = ring.Decrypt(upgraded, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "bank account 42", plain)
+
+	// Relabelling the key ID is detected
+	_, err = ring.Decrypt(strings.Replace(upgraded, "v2:k2:", "v2:k1:", 1), nil)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestLegacyCiphertextRejectedWithAssociatedData(t *testing.T) {
+	// A legacy value copied over an AAD-bound one must not decrypt as that record
+	legacy := legacyEncrypt(t, "bank account 7", testKeyOld)
+	_, err := DecryptDataWithAssociatedData(legacy, testKeyOld, []byte("record_1"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	ring, err := NewKeyring("k1", map[string]string{"k1": testKeyOld})
+	require.NoError(t, err)
+	_, err = ring.Decrypt(legacy, []byte("record_1"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	// Migrating binds the legacy value to its record
+	upgraded, changed, err := ring.Reencrypt(legacy, []byte("record_1"))
+	require.NoError(t, err)
+	assert.True(t, changed)
+	plain, err := ring.Decrypt(upgraded, []byte("record_1"))
+	require.NoError(t, err)
+	assert.Equal(t, "bank account 7", plain)
+	_, err = ring.Decrypt(upgraded, []byte("record_2"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestKeyringDisableLegacy(t *testing.T) {
+	legacy := legacyEncrypt(t, "bank account 9", testKeyOld)
+	ring, err := NewKeyring("k1", map[string]string{"k1": testKeyOld})
+	require.NoError(t, err)
+
+	// Legacy ciphertexts are accepted by default, so they can be migrated
+	plain, err := ring.Decrypt(legacy, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "bank account 9", plain)
+	upgraded, _, err := ring.Reencrypt(legacy, nil)
+	require.NoError(t, err)
+
+	ring.DisableLegacy()
+	_, err = ring.Decrypt(legacy, nil)
+	assert.ErrorIs(t, err, ErrLegacyCiphertext)
+	_, _, err = ring.Reencrypt(legacy, nil)
+	assert.ErrorIs(t, err, ErrLegacyCiphertext)
+
+	// Upgraded values still decrypt
+	plain, err = ring.Decrypt(upgraded, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "bank account 9", plain)
+}
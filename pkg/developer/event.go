@@ -123,6 +123,76 @@ const (
 	EventTypeInvoiceVoided EventType = "invoice.voided"
 )
 
+// AllEventTypes returns every webhook event type, in declaration order
+func AllEventTypes() []EventType {
+	return []EventType{
+		EventTypePaymentIntentCreated,
+		EventTypePaymentIntentSucceeded,
+		EventTypePaymentIntentPaymentFailed,
+		EventTypePaymentIntentProcessing,
+		EventTypePaymentIntentPartiallyPaid,
+		EventTypePaymentIntentCanceled,
+		EventTypeRefundCreated,
+		EventTypeRefundSucceeded,
+		EventTypeRefundUpdated,
+		EventTypeRefundFailed,
+		EventTypePayoutCreated,
+		EventTypePayoutSucceeded,
+		EventTypePayoutUpdated,
+		EventTypePayoutFailed,
+		EventTypePayrollCreated,
+		EventTypePayrollApproved,
+		EventTypePayrollRejected,
+		EventTypePayrollCanceled,
+		EventTypePayrollExecuting,
+		EventTypePayrollCompleted,
+		EventTypePayrollFailed,
+		EventTypePayrollItemProcessing,
+		EventTypePayrollItemSucceeded,
+		EventTypePayrollItemFailed,
+		EventTypePayrollItemAddressVerification,
+		EventTypePayrollItemAddressVerified,
+		EventTypeSubscriptionCreated,
+		EventTypeSubscriptionUpdated,
+		EventTypeSubscriptionDeleted,
+		EventTypeSubscriptionPaused,
+		EventTypeSubscriptionResumed,
+		EventTypeSubscriptionTrialWill,
+		EventTypeInvoiceCreated,
+		EventTypeInvoiceFinalized,
+		EventTypeInvoicePaid,
+		EventTypeInvoicePaymentSucceeded,
+		EventTypeInvoicePaymentFailed,
+		EventTypeInvoicePaymentActionRequired,
+		EventTypeInvoiceUpcoming,
+		EventTypeInvoiceUpdated,
+		EventTypeInvoiceVoided,
+	}
+}
+
+// IsValid reports whether the event type is one of the known EventType constants
+func (t EventType) IsValid() bool {
+	for _, known := range AllEventTypes() {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the event type matches a pattern.
+// A pattern is either an exact event type, "*" for every event, or a family
+// wildcard such as "payout.*" that matches every event type with that prefix.
+func (t EventType) Matches(pattern string) bool {
+	if pattern == "*" || pattern == string(t) {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, ".*"); ok {
+		return strings.HasPrefix(string(t), prefix+".")
+	}
+	return false
+}
+
 const (
 	// EventObject is the type identifier for event objects
 	EventObject = "event"
@@ -172,16 +242,20 @@ func GetPayloadAndSignature(event *Event, secret string) ([]byte, string, error)
 		return nil, "", fmt.Errorf("failed to marshal event: %v", err)
 	}
 
-	// Get timestamp from event
-	timestamp := time.Unix(event.Created, 0)
+	// Sign with the event creation time as the timestamp
+	formattedSignature := SignPayload(payload, time.Unix(event.Created, 0), secret)
 
-	// Compute signature using developer.ComputeSignature
+	return payload, formattedSignature, nil
+}
+
+// SignPayload computes the Martian-Pay-Signature header value for a raw payload.
+// The payload is signed as-is, so stored webhook bodies can be re-signed byte for byte
+// with a new timestamp or secret when replaying them against a local handler.
+func SignPayload(payload []byte, timestamp time.Time, secret string) string {
 	signature := ComputeSignature(timestamp, payload, secret)
 
 	// Format signature as "t=timestamp,v1=signature"
-	formattedSignature := fmt.Sprintf("t=%d,v1=%s", event.Created, hex.EncodeToString(signature))
-
-	return payload, formattedSignature, nil
+	return fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), hex.EncodeToString(signature))
 }
 
 const (
@@ -285,3 +359,39 @@ func ConstructEvent(payload []byte, sigHeader string, secret string) (Event, err
 	return e, nil
 
 }
+
+// ================================
+// Request Types
+// ================================
+
+// EventListRequest lists events from the event log, newest first
+type EventListRequest struct {
+	// Page is the page number (zero-based)
+	Page int32 `json:"page" binding:"min=0" form:"page"`
+	// PageSize is the number of items per page (max 50)
+	PageSize int32 `json:"page_size" binding:"required,min=1,max=50" form:"page_size"`
+	// Type filters events by type; a trailing ".*" matches a whole family (e.g. "payout.*")
+	Type *string `json:"type,omitempty" form:"type"`
+	// CreatedGte filters events created at or after this Unix timestamp
+	CreatedGte *int64 `json:"created_gte,omitempty" form:"created_gte"`
+	// CreatedLte filters events created at or before this Unix timestamp
+	CreatedLte *int64 `json:"created_lte,omitempty" form:"created_lte"`
+	// ObjectID filters events about a single resource (e.g. a payment intent or payout ID)
+	ObjectID *string `json:"object_id,omitempty" form:"object_id"`
+}
+
+// ================================
+// Response Types
+// ================================
+
+// EventListResp represents a paginated list of events
+type EventListResp struct {
+	// Events is the list of events
+	Events []*Event `json:"events"`
+	// Total is the total number of events matching the filters
+	Total int64 `json:"total"`
+	// Page is the current page number
+	Page int32 `json:"page"`
+	// PageSize is the number of items per page
+	PageSize int32 `json:"page_size"`
+}
@@ -0,0 +1,236 @@
+// event_diff.go contains helpers for working out what changed in a `*.updated` webhook event.
+// The previous attributes of an event are compared field by field with the typed object
+// carried in the same event, producing a list of changes with their old and new values.
+package developer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldChange describes one attribute that changed in an event
+type FieldChange struct {
+	// Path is the dot-separated JSON path of the attribute (e.g., "status" or "metadata.order_id")
+	Path string `json:"path"`
+	// Old is the value before the change, as decoded from previous_attributes
+	Old interface{} `json:"old"`
+	// New is the current value of the attribute in the event object, nil if it was removed
+	New interface{} `json:"new"`
+}
+
+// AttributesDiff is the field-level difference between an event object and its previous attributes
+type AttributesDiff struct {
+	// Changes lists every changed attribute, sorted by path
+	Changes []FieldChange `json:"changes"`
+}
+
+// eventObjectFor returns a new typed object matching the resource family of an event type,
+// or nil if the family has no typed object.
+func eventObjectFor(t EventType) interface{} {
+	family, _, _ := strings.Cut(string(t), ".")
+	switch family {
+	case "payment_intent":
+		return &PaymentIntent{}
+	case "refund":
+		return &Refund{}
+	case "payout":
+		return &Payout{}
+	case "payroll":
+		return &Payroll{}
+	case "payroll_item":
+		return &PayrollItems{}
+	case "subscription":
+		return &SubscriptionDetails{}
+	case "invoice":
+		return &InvoiceDetails{}
+	}
+	return nil
+}
+
+// DecodeObject decodes the event object into the typed struct for its event type
+//
+// Returns:
+//   - interface{}: Pointer to PaymentIntent, Refund, Payout, Payroll, PayrollItems, SubscriptionDetails or InvoiceDetails
+//   - error: Error if the event has no data, an unsupported type, or a malformed object
+func (e *Event) DecodeObject() (interface{}, error) {
+	if e.Data == nil || len(e.Data.Raw) == 0 {
+		return nil, fmt.Errorf("event %s has no data object", e.ID)
+	}
+	obj := eventObjectFor(e.Type)
+	if obj == nil {
+		return nil, fmt.Errorf("event type %s has no typed object", e.Type)
+	}
+	if err := json.Unmarshal(e.Data.Raw, obj); err != nil {
+		return nil, fmt.Errorf("failed to decode %s object: %v", e.Type, err)
+	}
+	return obj, nil
+}
+
+// DecodePrevious decodes the previous attributes into v, which should be the same type as the
+// event object. Only the fields listed in previous_attributes are set.
+func (d *EventData) DecodePrevious(v interface{}) error {
+	raw, err := json.Marshal(d.PreviousAttributes)
+	if err != nil {
+		return fmt.Errorf("failed to encode previous attributes: %v", err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("failed to decode previous attributes: %v", err)
+	}
+	return nil
+}
+
+// PreviousAttributesDiff compares the previous attributes of the event with its current object
+//
+// Both sides are decoded into the typed struct for the event type (see DecodeObject and
+// DecodePrevious) and encoded again, so that values are normalized the same way the SDK would
+// read them; for example, an unchanged amount of "10.50" is not reported as changed to "10.5".
+// Only the attributes listed in previous_attributes are compared. Nested objects such as metadata
+// are compared key by key; arrays are compared as a whole. Attributes whose old and new values
+// are equal are omitted.
+//
+// Returns:
+//   - *AttributesDiff: The changed fields; empty if the event has no previous attributes
+//   - error: Error if the event object or previous attributes cannot be decoded
+func (e *Event) PreviousAttributesDiff() (*AttributesDiff, error) {
+	diff := &AttributesDiff{Changes: []FieldChange{}}
+	if e.Data == nil || len(e.Data.PreviousAttributes) == 0 {
+		return diff, nil
+	}
+
+	obj, err := e.DecodeObject()
+	if err != nil {
+		return nil, err
+	}
+	current, err := toJSONMap(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s object: %v", e.Type, err)
+	}
+
+	prev := eventObjectFor(e.Type)
+	if err := e.Data.DecodePrevious(prev); err != nil {
+		return nil, err
+	}
+	typedPrevious, err := toJSONMap(prev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode previous attributes: %v", err)
+	}
+	listed, err := toJSONMap(e.Data.PreviousAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode previous attributes: %v", err)
+	}
+
+	previous := restrictToListed(listed, typedPrevious)
+	diff.Changes = diffMaps("", previous, current, diff.Changes)
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Path < diff.Changes[j].Path
+	})
+	return diff, nil
+}
+
+// toJSONMap round-trips v through JSON so its values compare equal to decoded objects
+func toJSONMap(v interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// restrictToListed returns the normalized values of the keys listed in previous_attributes.
+// Keys the typed object does not carry (unknown or omitted when empty) keep their listed value.
+func restrictToListed(listed, typed map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(listed))
+	for key, value := range listed {
+		normalized, ok := typed[key]
+		if !ok {
+			out[key] = value
+			continue
+		}
+		listedMap, listedIsMap := value.(map[string]interface{})
+		typedMap, typedIsMap := normalized.(map[string]interface{})
+		if listedIsMap && typedIsMap {
+			out[key] = restrictToListed(listedMap, typedMap)
+			continue
+		}
+		out[key] = normalized
+	}
+	return out
+}
+
+// diffMaps appends a change for every key of previous whose value differs in current
+func diffMaps(prefix string, previous, current map[string]interface{}, changes []FieldChange) []FieldChange {
+	for key, old := range previous {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		now := current[key]
+
+		oldMap, oldIsMap := old.(map[string]interface{})
+		nowMap, nowIsMap := now.(map[string]interface{})
+		if oldIsMap && nowIsMap {
+			changes = diffMaps(path, oldMap, nowMap, changes)
+			continue
+		}
+		if !reflect.DeepEqual(old, now) {
+			changes = append(changes, FieldChange{Path: path, Old: old, New: now})
+		}
+	}
+	return changes
+}
+
+// IsEmpty reports whether no attribute changed
+func (d *AttributesDiff) IsEmpty() bool {
+	return len(d.Changes) == 0
+}
+
+// Paths returns the paths of all changed attributes, sorted
+func (d *AttributesDiff) Paths() []string {
+	paths := make([]string, 0, len(d.Changes))
+	for _, c := range d.Changes {
+		paths = append(paths, c.Path)
+	}
+	return paths
+}
+
+// Changed returns the change for the given path, if that attribute changed
+func (d *AttributesDiff) Changed(path string) (FieldChange, bool) {
+	for _, c := range d.Changes {
+		if c.Path == path {
+			return c, true
+		}
+	}
+	return FieldChange{}, false
+}
+
+// StatusChanged reports whether the status attribute changed, and from and to which values
+//
+// Returns:
+//   - from: The previous status
+//   - to: The current status
+//   - ok: True if the status changed
+func (d *AttributesDiff) StatusChanged() (from, to string, ok bool) {
+	c, ok := d.Changed("status")
+	if !ok {
+		return "", "", false
+	}
+	return stringValue(c.Old), stringValue(c.New), true
+}
+
+// stringValue formats a decoded JSON scalar as a string; nil becomes ""
+func stringValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
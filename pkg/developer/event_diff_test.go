@@ -0,0 +1,56 @@
+// event_diff_test.go contains unit tests for the previous attributes diff of updated events.
+package developer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updatedEvent builds an event of the given type from a JSON object and previous attributes
+func updatedEvent(t *testing.T, eventType EventType, object, previous string) *Event {
+	var event Event
+	raw := `{"id":"evt_1","object":"event","type":"` + string(eventType) + `","data":{"object":` + object + `,"previous_attributes":` + previous + `}}`
+	require.NoError(t, json.Unmarshal([]byte(raw), &event))
+	return &event
+}
+
+func TestPreviousAttributesDiff(t *testing.T) {
+	event := updatedEvent(t, EventTypePayoutUpdated,
+		`{"id":"po_1","status":"in_transit","metadata":{"batch":"2025-01","region":"eu"}}`,
+		`{"status":"approved","metadata":{"batch":"2024-12"}}`)
+
+	diff, err := event.PreviousAttributesDiff()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metadata.batch", "status"}, diff.Paths())
+
+	from, to, ok := diff.StatusChanged()
+	assert.True(t, ok)
+	assert.Equal(t, "approved", from)
+	assert.Equal(t, "in_transit", to)
+
+	change, ok := diff.Changed("metadata.batch")
+	require.True(t, ok)
+	assert.Equal(t, "2024-12", change.Old)
+	assert.Equal(t, "2025-01", change.New)
+}
+
+func TestPreviousAttributesDiffNormalizesPreviousValues(t *testing.T) {
+	// The amount is unchanged but formatted differently; only the status really changed
+	event := updatedEvent(t, EventTypeRefundUpdated,
+		`{"id":"re_1","status":"succeeded","amount":{"asset_id":"USDC_ETH","amount":"10.5"}}`,
+		`{"status":"pending","amount":{"asset_id":"USDC_ETH","amount":"10.50"}}`)
+
+	diff, err := event.PreviousAttributesDiff()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status"}, diff.Paths())
+}
+
+func TestPreviousAttributesDiffEmpty(t *testing.T) {
+	event := updatedEvent(t, EventTypeRefundUpdated, `{"id":"re_1","status":"succeeded"}`, `{}`)
+	diff, err := event.PreviousAttributesDiff()
+	require.NoError(t, err)
+	assert.True(t, diff.IsEmpty())
+}
@@ -0,0 +1,217 @@
+// frozen_funds.go contains helpers for working through payments frozen by AML screening.
+// A transaction rejected by AML screening freezes its funds until the merchant either
+// releases them to the balance or reverses them to the sender. FrozenFundsOf shows what
+// is still frozen on a payment intent, and BuildFrozenQueue orders many of them for review.
+package developer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// NewUnfreezeRelease builds a request that releases the frozen funds of a payment intent to the merchant balance.
+//
+// Parameters:
+//   - paymentIntentID: The payment intent with frozen funds
+//   - description: Why the funds are released, kept for compliance records
+//
+// Returns:
+//   - *UnfreezeCreateRequest: The unfreeze request
+func NewUnfreezeRelease(paymentIntentID, description string) *UnfreezeCreateRequest {
+	return &UnfreezeCreateRequest{
+		PaymentIntentID: paymentIntentID,
+		Type:            UnfreezeTypeRelease,
+		Description:     description,
+	}
+}
+
+// NewUnfreezeReverse builds a request that sends the frozen funds of a payment intent back to a source address.
+//
+// Parameters:
+//   - paymentIntentID: The payment intent with frozen funds
+//   - address: The address to return the funds to, normally the sender of the frozen transaction
+//   - externalID: Merchant reference that makes the request idempotent
+//   - description: Why the funds are reversed, kept for compliance records
+//
+// Returns:
+//   - *UnfreezeCreateRequest: The unfreeze request
+func NewUnfreezeReverse(paymentIntentID, address, externalID, description string) *UnfreezeCreateRequest {
+	return &UnfreezeCreateRequest{
+		PaymentIntentID: paymentIntentID,
+		Type:            UnfreezeTypeReverse,
+		Address:         address,
+		ExternalID:      externalID,
+		Description:     description,
+	}
+}
+
+// Validate checks the request before it is sent.
+// An empty Type is a reverse, matching the API default, and a reverse needs an address.
+func (r *UnfreezeCreateRequest) Validate() error {
+	if r.PaymentIntentID == "" {
+		return errors.New("payment intent ID is required")
+	}
+	switch r.Type {
+	case "", UnfreezeTypeReverse:
+		if strings.TrimSpace(r.Address) == "" {
+			return errors.New("address is required to reverse frozen funds")
+		}
+	case UnfreezeTypeRelease:
+	default:
+		return fmt.Errorf("unknown unfreeze type %q", r.Type)
+	}
+	return nil
+}
+
+// IsFailed reports whether the unfreeze withdrawal did not go through, leaving the funds frozen
+func (w *UnfreezeWithdraw) IsFailed() bool {
+	switch PayoutStatus(strings.ToLower(w.Status)) {
+	case PayoutStatusFailed, PayoutStatusCanceled, PayoutStatusRejected:
+		return true
+	}
+	return false
+}
+
+// IsDone reports whether the unfreeze withdrawal has completed
+func (w *UnfreezeWithdraw) IsDone() bool {
+	return PayoutStatus(strings.ToLower(w.Status)) == PayoutStatusPaid
+}
+
+// FrozenFunds is the frozen amount of one asset on a payment intent
+type FrozenFunds struct {
+	// PaymentIntentID is the payment intent holding the funds
+	PaymentIntentID string `json:"payment_intent_id"`
+	// AssetId is the frozen asset
+	AssetId string `json:"asset_id"`
+	// Frozen is the sum of transactions rejected by AML screening
+	Frozen decimal.Decimal `json:"frozen"`
+	// Unfreezing is the amount in unfreeze withdrawals that have not completed yet
+	Unfreezing decimal.Decimal `json:"unfreezing"`
+	// Unfrozen is the amount in completed unfreeze withdrawals
+	Unfrozen decimal.Decimal `json:"unfrozen"`
+	// Remaining is the amount still frozen with no unfreeze requested
+	Remaining decimal.Decimal `json:"remaining"`
+	// FrozenSince is the Unix timestamp of the earliest frozen transaction
+	FrozenSince int64 `json:"frozen_since"`
+	// Transactions are the transactions rejected by AML screening
+	Transactions []*TransactionDetails `json:"transactions"`
+	// Withdraws are the unfreeze withdrawals of this asset
+	Withdraws []*UnfreezeWithdraw `json:"withdraws,omitempty"`
+}
+
+// FrozenFundsOf returns the frozen funds of a payment intent per asset.
+// Unfreeze withdrawals that failed are ignored, so their amount counts as remaining again.
+//
+// Parameters:
+//   - pi: The payment intent with its charges and unfreeze withdrawals
+//
+// Returns:
+//   - []*FrozenFunds: Frozen funds per asset, sorted by asset ID; empty if nothing was frozen
+//   - error: Error if an amount cannot be parsed
+func FrozenFundsOf(pi *PaymentIntent) ([]*FrozenFunds, error) {
+	funds := make(map[string]*FrozenFunds)
+	fundsFor := func(assetID string) *FrozenFunds {
+		f, ok := funds[assetID]
+		if !ok {
+			f = &FrozenFunds{PaymentIntentID: pi.ID, AssetId: assetID}
+			funds[assetID] = f
+		}
+		return f
+	}
+
+	for _, charge := range pi.Charges {
+		if charge == nil {
+			continue
+		}
+		for _, tx := range charge.Transactions {
+			if tx == nil || tx.Type == "1" || !ParseAmlStatus(tx.AmlStatus).IsRejected() {
+				continue
+			}
+			amount, err := decimal.NewFromString(tx.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("invalid amount %q in transaction %s: %v", tx.Amount, tx.TxHash, err)
+			}
+			f := fundsFor(tx.AssetId)
+			f.Frozen = f.Frozen.Add(amount)
+			f.Transactions = append(f.Transactions, tx)
+			if f.FrozenSince == 0 || (tx.CreatedAt > 0 && tx.CreatedAt < f.FrozenSince) {
+				f.FrozenSince = tx.CreatedAt
+			}
+		}
+	}
+
+	for _, w := range pi.UnfreezeWithdraws {
+		if w == nil || w.IsFailed() {
+			continue
+		}
+		f := fundsFor(w.AssetID)
+		f.Withdraws = append(f.Withdraws, w)
+		var amount decimal.Decimal
+		if w.Amount != nil {
+			amount = w.Amount.Amount
+		}
+		if w.IsDone() {
+			f.Unfrozen = f.Unfrozen.Add(amount)
+		} else {
+			f.Unfreezing = f.Unfreezing.Add(amount)
+		}
+	}
+
+	result := make([]*FrozenFunds, 0, len(funds))
+	for _, f := range funds {
+		f.Remaining = f.Frozen.Sub(f.Unfreezing).Sub(f.Unfrozen)
+		if f.Remaining.Sign() < 0 {
+			f.Remaining = decimal.Zero
+		}
+		result = append(result, f)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].AssetId < result[j].AssetId })
+	return result, nil
+}
+
+// BuildFrozenQueue returns the frozen funds that still need a decision, oldest first,
+// so compliance can work through them in the order they were frozen.
+//
+// Parameters:
+//   - intents: Payment intents to check, e.g. those listed with status Frozen
+//
+// Returns:
+//   - []*FrozenFunds: Frozen funds with a remaining amount
+//   - error: Error if an amount cannot be parsed
+func BuildFrozenQueue(intents []*PaymentIntent) ([]*FrozenFunds, error) {
+	var queue []*FrozenFunds
+	for _, pi := range intents {
+		if pi == nil {
+			continue
+		}
+		funds, err := FrozenFundsOf(pi)
+		if err != nil {
+			return nil, fmt.Errorf("error reading frozen funds of %s: %v", pi.ID, err)
+		}
+		for _, f := range funds {
+			if f.Remaining.Sign() > 0 {
+				queue = append(queue, f)
+			}
+		}
+	}
+	sort.SliceStable(queue, func(i, j int) bool { return queue[i].FrozenSince < queue[j].FrozenSince })
+	return queue, nil
+}
+
+// SenderAddresses returns the distinct source addresses of the frozen transactions,
+// the usual destinations for reversing the funds
+func (f *FrozenFunds) SenderAddresses() []string {
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, tx := range f.Transactions {
+		if tx.SourceAddress != "" && !seen[tx.SourceAddress] {
+			seen[tx.SourceAddress] = true
+			addresses = append(addresses, tx.SourceAddress)
+		}
+	}
+	return addresses
+}
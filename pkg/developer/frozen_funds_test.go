@@ -0,0 +1,55 @@
+// frozen_funds_test.go contains unit tests for the frozen funds helpers.
+package developer
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrozenFunds(t *testing.T) {
+	tx := func(hash, amount, aml string, created int64) *TransactionDetails {
+		return &TransactionDetails{TxHash: hash, Amount: amount, AssetId: "USDT-TRON", Type: "0", Status: "confirmed", AmlStatus: aml, SourceAddress: "T" + hash, CreatedAt: created}
+	}
+	withdraw := func(amount int64, status string) *UnfreezeWithdraw {
+		return &UnfreezeWithdraw{AssetID: "USDT-TRON", Amount: NewAssetAmount(decimal.NewFromInt(amount), "USDT-TRON", 6), Status: status, Type: UnfreezeTypeRelease}
+	}
+	older := &PaymentIntent{ID: "pi_old", Charges: []*Charge{{Transactions: []*TransactionDetails{tx("a", "30", "rejected", 100)}}}}
+	pi := &PaymentIntent{
+		ID: "pi_1",
+		Charges: []*Charge{{Transactions: []*TransactionDetails{
+			tx("b", "50", "rejected", 300),
+			tx("c", "25", "rejected", 200),
+			tx("d", "100", "approved", 250),
+		}}},
+		UnfreezeWithdraws: []*UnfreezeWithdraw{withdraw(20, "paid"), withdraw(10, "pending"), withdraw(40, "failed")},
+	}
+
+	funds, err := FrozenFundsOf(pi)
+	require.NoError(t, err)
+	require.Len(t, funds, 1)
+	assert.Equal(t, "75", funds[0].Frozen.String())
+	assert.Equal(t, "20", funds[0].Unfrozen.String())
+	assert.Equal(t, "10", funds[0].Unfreezing.String())
+	assert.Equal(t, "45", funds[0].Remaining.String())
+	assert.Equal(t, int64(200), funds[0].FrozenSince)
+	assert.Equal(t, []string{"Tb", "Tc"}, funds[0].SenderAddresses())
+
+	done := &PaymentIntent{ID: "pi_done", Charges: older.Charges, UnfreezeWithdraws: []*UnfreezeWithdraw{withdraw(30, "paid")}}
+	queue, err := BuildFrozenQueue([]*PaymentIntent{pi, done, older})
+	require.NoError(t, err)
+	require.Len(t, queue, 2)
+	assert.Equal(t, "pi_old", queue[0].PaymentIntentID)
+	assert.Equal(t, "pi_1", queue[1].PaymentIntentID)
+}
+
+func TestUnfreezeCreateRequestValidate(t *testing.T) {
+	assert.NoError(t, NewUnfreezeRelease("pi_1", "cleared").Validate())
+	assert.NoError(t, NewUnfreezeReverse("pi_1", "Tabc", "ext_1", "").Validate())
+	assert.Error(t, NewUnfreezeReverse("pi_1", " ", "ext_1", "").Validate())
+	assert.Error(t, (&UnfreezeCreateRequest{PaymentIntentID: "pi_1"}).Validate())
+	assert.Error(t, (&UnfreezeCreateRequest{PaymentIntentID: "pi_1", Type: "unfreeze_other"}).Validate())
+	assert.Error(t, NewUnfreezeRelease("", "").Validate())
+}
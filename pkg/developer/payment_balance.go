@@ -0,0 +1,328 @@
+// payment_balance.go contains a decimal-exact calculator for how much of a payment intent has
+// been paid, how much is still owed in each asset, and how much was settled to the merchant
+// after refunds and fees. It powers "send the remaining X USDT" messages for Partially Paid intents.
+package developer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// AssetBalance is the payment progress of a payment intent in one crypto or fiat asset
+type AssetBalance struct {
+	// AssetId is the asset of the amounts
+	AssetId string `json:"asset_id"`
+	// Token is the token symbol shown to customers (e.g., "USDT"), if known
+	Token string `json:"token,omitempty"`
+	// Decimals is the number of decimal places of the asset, or -1 if unknown
+	Decimals int `json:"decimals"`
+	// Due is the amount requested in this asset by the most recent charge
+	Due decimal.Decimal `json:"due"`
+	// Received is the sum of customer transactions that are submitted, completed, or confirmed and not frozen
+	Received decimal.Decimal `json:"received"`
+	// Pending is the part of Received that is only submitted and may still fail
+	Pending decimal.Decimal `json:"pending"`
+	// Frozen is the sum of transactions rejected by AML screening; it does not count towards the amount paid
+	Frozen decimal.Decimal `json:"frozen"`
+	// Outstanding is what the customer still has to send in this asset, rounded up to the asset's decimals
+	Outstanding decimal.Decimal `json:"outstanding"`
+	// Overpaid is how much more than the amount due was received in this asset
+	Overpaid decimal.Decimal `json:"overpaid"`
+
+	created int64
+}
+
+// SettlementBalance is what the merchant keeps in one asset after refunds and fees
+type SettlementBalance struct {
+	// AssetId is the asset of the amounts
+	AssetId string `json:"asset_id"`
+	// Captured is the amount captured from the customer
+	Captured decimal.Decimal `json:"captured"`
+	// Refunded is the amount refunded to the customer
+	Refunded decimal.Decimal `json:"refunded"`
+	// Fees is the sum of transaction, network, and gas fees charged in this asset
+	Fees decimal.Decimal `json:"fees"`
+	// NetSettled is Captured minus Refunded and Fees
+	NetSettled decimal.Decimal `json:"net_settled"`
+}
+
+// PaymentBalance summarizes the payment progress and settlement of a payment intent
+type PaymentBalance struct {
+	// PaymentIntentID is the ID of the payment intent
+	PaymentIntentID string `json:"payment_intent_id"`
+	// Amount is the amount the payment intent was created for, in its pricing currency
+	Amount *AssetAmount `json:"amount"`
+	// PaidRatio is the fraction of the amount paid across all assets (1 means fully paid)
+	PaidRatio decimal.Decimal `json:"paid_ratio"`
+	// Outstanding is the unpaid part of Amount in the pricing currency
+	Outstanding decimal.Decimal `json:"outstanding"`
+	// Overpaid is the overpaid part of Amount in the pricing currency
+	Overpaid decimal.Decimal `json:"overpaid"`
+	// Assets is the payment progress per asset, most recent charge first
+	Assets []*AssetBalance `json:"assets"`
+	// Settlements is what the merchant keeps per asset, from the payment details
+	Settlements []*SettlementBalance `json:"settlements"`
+	// NetAmount is the net amount reported by MartianPay in the payment details, if any
+	NetAmount *AssetAmount `json:"net_amount,omitempty"`
+}
+
+// ComputePaymentBalance calculates how much of a payment intent has been paid and how much is
+// still owed. When the customer paid with several assets (for example after switching from USDC
+// to USDT), each asset covers its share of the total and the remainder is expressed in every asset,
+// so no exchange rate is needed.
+//
+// Parameters:
+//   - pi: The payment intent with charges and transactions expanded
+//
+// Returns:
+//   - *PaymentBalance: Outstanding, overpaid, and settled amounts
+//   - error: Error if a transaction or charge amount cannot be parsed
+func ComputePaymentBalance(pi *PaymentIntent) (*PaymentBalance, error) {
+	balance := &PaymentBalance{PaymentIntentID: pi.ID, Amount: pi.Amount}
+
+	assets := make(map[string]*AssetBalance)
+	for _, charge := range pi.Charges {
+		if charge == nil {
+			continue
+		}
+		if err := addChargeBalance(assets, charge); err != nil {
+			return nil, fmt.Errorf("error computing balance of charge %s: %v", charge.ID, err)
+		}
+	}
+	for _, ab := range assets {
+		balance.Assets = append(balance.Assets, ab)
+	}
+	sort.SliceStable(balance.Assets, func(i, j int) bool {
+		if balance.Assets[i].created != balance.Assets[j].created {
+			return balance.Assets[i].created > balance.Assets[j].created
+		}
+		return balance.Assets[i].AssetId < balance.Assets[j].AssetId
+	})
+
+	// Each asset covers Received/Due of the total
+	ratio := decimal.Zero
+	ratios := make(map[string]decimal.Decimal, len(assets))
+	for _, ab := range balance.Assets {
+		r := decimal.Zero
+		if ab.Due.Sign() > 0 {
+			r = ab.Received.Div(ab.Due)
+		} else if ab.Received.Sign() > 0 {
+			r = decimal.NewFromInt(1)
+		}
+		ratios[ab.AssetId] = r
+		ratio = ratio.Add(r)
+	}
+	if pi.CompleteOnFirstPayment && ratio.Sign() > 0 && ratio.LessThan(decimal.NewFromInt(1)) {
+		ratio = decimal.NewFromInt(1)
+	}
+	balance.PaidRatio = ratio
+
+	one := decimal.NewFromInt(1)
+	for _, ab := range balance.Assets {
+		if pi.CompleteOnFirstPayment && ratio.Sign() > 0 {
+			continue
+		}
+		// The share this asset still has to cover once the other assets are accounted for
+		share := one.Sub(ratio.Sub(ratios[ab.AssetId]))
+		remaining := ab.Due.Mul(share).Sub(ab.Received)
+		if remaining.Sign() > 0 {
+			ab.Outstanding = roundAssetAmount(remaining, ab.Decimals, true)
+		} else {
+			ab.Overpaid = roundAssetAmount(remaining.Neg(), ab.Decimals, false)
+		}
+	}
+	if pi.Amount != nil {
+		switch {
+		case ratio.LessThan(one):
+			balance.Outstanding = pi.Amount.Amount.Mul(one.Sub(ratio))
+		case ratio.GreaterThan(one):
+			balance.Overpaid = pi.Amount.Amount.Mul(ratio.Sub(one))
+		}
+		if pi.Amount.DecimalDigits > 0 {
+			balance.Outstanding = roundDecimal(balance.Outstanding, pi.Amount.DecimalDigits, RoundCeiling)
+			balance.Overpaid = roundDecimal(balance.Overpaid, pi.Amount.DecimalDigits, RoundFloor)
+		}
+	}
+
+	if details := pi.PaymentDetails; details != nil {
+		balance.Settlements = computeSettlements(details)
+		balance.NetAmount = details.NetAmount
+	}
+	return balance, nil
+}
+
+// IsFullyPaid reports whether the received amounts cover the payment intent
+func (b *PaymentBalance) IsFullyPaid() bool {
+	return b.PaidRatio.GreaterThanOrEqual(decimal.NewFromInt(1))
+}
+
+// Remaining returns what the customer still has to send in the asset of the most recent charge,
+// or nil if nothing is outstanding
+func (b *PaymentBalance) Remaining() *AssetBalance {
+	if len(b.Assets) == 0 || b.IsFullyPaid() || b.Assets[0].Outstanding.Sign() <= 0 {
+		return nil
+	}
+	return b.Assets[0]
+}
+
+// RemainingMessage returns a customer-facing message asking for the outstanding amount,
+// e.g. "Please send the remaining 12.5 USDT to complete your payment.", or "" if nothing is owed
+func (b *PaymentBalance) RemainingMessage() string {
+	ab := b.Remaining()
+	if ab == nil {
+		return ""
+	}
+	unit := ab.Token
+	if unit == "" {
+		unit = ab.AssetId
+	}
+	return fmt.Sprintf("Please send the remaining %s %s to complete your payment.", ab.Outstanding.String(), unit)
+}
+
+// addChargeBalance adds a charge's requested amount and deposit transactions to the per-asset balances
+func addChargeBalance(assets map[string]*AssetBalance, charge *Charge) error {
+	var crypto *Crypto
+	if charge.PaymentMethodOptions != nil {
+		crypto = charge.PaymentMethodOptions.Crypto
+	}
+
+	assetID := ""
+	if charge.Amount != nil {
+		assetID = charge.Amount.AssetId
+	}
+	if assetID == "" && crypto != nil && crypto.AssetId != nil {
+		assetID = *crypto.AssetId
+	}
+	ab := balanceFor(assets, assetID)
+	if charge.Created >= ab.created {
+		ab.created = charge.Created
+		if charge.Amount != nil {
+			ab.Due = charge.Amount.Amount
+			if charge.Amount.DecimalDigits > 0 {
+				ab.Decimals = charge.Amount.DecimalDigits
+			}
+		} else if crypto != nil && crypto.Amount != nil {
+			due, err := decimal.NewFromString(*crypto.Amount)
+			if err != nil {
+				return fmt.Errorf("invalid charge amount %q: %v", *crypto.Amount, err)
+			}
+			ab.Due = due
+		}
+	}
+	if crypto != nil {
+		if crypto.Token != nil && ab.Token == "" {
+			ab.Token = *crypto.Token
+		}
+		if crypto.Decimals != nil && ab.Decimals < 0 {
+			ab.Decimals = *crypto.Decimals
+		}
+	}
+
+	for _, tx := range charge.Transactions {
+		if tx == nil || tx.Type == "1" {
+			continue
+		}
+		status := strings.ToLower(tx.Status)
+		if status != "submitted" && status != "completed" && status != "confirmed" {
+			continue
+		}
+		amount, err := decimal.NewFromString(tx.Amount)
+		if err != nil {
+			return fmt.Errorf("invalid amount %q in transaction %s: %v", tx.Amount, tx.TxHash, err)
+		}
+		txBalance := ab
+		if tx.AssetId != "" && tx.AssetId != assetID {
+			txBalance = balanceFor(assets, tx.AssetId)
+		}
+		if txBalance.Token == "" {
+			txBalance.Token = tx.Token
+		}
+		if txBalance.Decimals < 0 && tx.Decimals > 0 {
+			txBalance.Decimals = tx.Decimals
+		}
+		if ParseAmlStatus(tx.AmlStatus).IsRejected() {
+			txBalance.Frozen = txBalance.Frozen.Add(amount)
+			continue
+		}
+		txBalance.Received = txBalance.Received.Add(amount)
+		if status == "submitted" {
+			txBalance.Pending = txBalance.Pending.Add(amount)
+		}
+	}
+	return nil
+}
+
+// balanceFor returns the balance of an asset, creating it if needed
+func balanceFor(assets map[string]*AssetBalance, assetID string) *AssetBalance {
+	ab, ok := assets[assetID]
+	if !ok {
+		ab = &AssetBalance{AssetId: assetID, Decimals: -1}
+		assets[assetID] = ab
+	}
+	return ab
+}
+
+// computeSettlements groups the captured, refunded, and fee amounts of the payment details by asset
+func computeSettlements(details *PaymentDetails) []*SettlementBalance {
+	byAsset := make(map[string]*SettlementBalance)
+	get := func(a *AssetAmount) *SettlementBalance {
+		s, ok := byAsset[a.AssetId]
+		if !ok {
+			s = &SettlementBalance{AssetId: a.AssetId}
+			byAsset[a.AssetId] = s
+		}
+		return s
+	}
+
+	if a := details.AmountCaptured; a != nil {
+		s := get(a)
+		s.Captured = s.Captured.Add(a.Amount)
+	}
+	if a := details.AmountRefunded; a != nil {
+		s := get(a)
+		s.Refunded = s.Refunded.Add(a.Amount)
+	}
+	fees := []*AssetAmount{details.TxFee, details.NetworkFee}
+	gasAssets := make([]string, 0, len(details.GasFee))
+	for asset := range details.GasFee {
+		gasAssets = append(gasAssets, asset)
+	}
+	sort.Strings(gasAssets)
+	for _, asset := range gasAssets {
+		fee := details.GasFee[asset]
+		if fee != nil && fee.AssetId == "" {
+			fee = &AssetAmount{AssetId: asset, Amount: fee.Amount, DecimalDigits: fee.DecimalDigits}
+		}
+		fees = append(fees, fee)
+	}
+	for _, a := range fees {
+		if a != nil {
+			s := get(a)
+			s.Fees = s.Fees.Add(a.Amount)
+		}
+	}
+
+	settlements := make([]*SettlementBalance, 0, len(byAsset))
+	for _, s := range byAsset {
+		s.NetSettled = s.Captured.Sub(s.Refunded).Sub(s.Fees)
+		settlements = append(settlements, s)
+	}
+	sort.Slice(settlements, func(i, j int) bool {
+		return settlements[i].AssetId < settlements[j].AssetId
+	})
+	return settlements
+}
+
+// roundAssetAmount rounds an amount to the asset's decimals, up or down, leaving it unchanged if they are unknown
+func roundAssetAmount(d decimal.Decimal, decimals int, up bool) decimal.Decimal {
+	if decimals < 0 {
+		return d
+	}
+	if up {
+		return roundDecimal(d, decimals, RoundCeiling)
+	}
+	return roundDecimal(d, decimals, RoundFloor)
+}
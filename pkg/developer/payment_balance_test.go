@@ -0,0 +1,57 @@
+// payment_balance_test.go contains unit tests for the payment balance calculator.
+package developer
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputePaymentBalance(t *testing.T) {
+	tx := func(amount, asset, status, aml string) *TransactionDetails {
+		return &TransactionDetails{Amount: amount, AssetId: asset, Token: "USDT", Decimals: 6, Type: "0", Status: status, AmlStatus: aml}
+	}
+	pi := &PaymentIntent{
+		ID:     "pi_1",
+		Amount: NewAssetAmount(decimal.NewFromInt(100), "USD", 2),
+		Charges: []*Charge{{
+			ID:      "ch_1",
+			Created: 100,
+			Amount:  NewAssetAmount(decimal.RequireFromString("100.000001"), "USDT-TRON", 6),
+			Transactions: []*TransactionDetails{
+				tx("30", "USDT-TRON", "confirmed", "approved"),
+				tx("20.5", "USDT-TRON", "submitted", ""),
+				tx("10", "USDT-TRON", "failed", ""),
+				tx("15", "USDT-TRON", "confirmed", "rejected"),
+			},
+		}},
+	}
+
+	b, err := ComputePaymentBalance(pi)
+	require.NoError(t, err)
+	require.Len(t, b.Assets, 1)
+	usdt := b.Assets[0]
+	assert.Equal(t, "50.5", usdt.Received.String())
+	assert.Equal(t, "20.5", usdt.Pending.String())
+	assert.Equal(t, "15", usdt.Frozen.String())
+	assert.Equal(t, "49.500001", usdt.Outstanding.String())
+	assert.False(t, b.IsFullyPaid())
+	assert.Equal(t, "Please send the remaining 49.500001 USDT to complete your payment.", b.RemainingMessage())
+
+	// The customer switches to USDC for the rest: the USDT already received covers about half
+	pi.Charges = append(pi.Charges, &Charge{
+		ID:           "ch_2",
+		Created:      200,
+		Amount:       NewAssetAmount(decimal.NewFromInt(99), "USDC-ETH", 6),
+		Transactions: []*TransactionDetails{{Amount: "50", AssetId: "USDC-ETH", Token: "USDC", Decimals: 6, Status: "confirmed"}},
+	})
+	b, err = ComputePaymentBalance(pi)
+	require.NoError(t, err)
+	assert.Equal(t, "USDC-ETH", b.Assets[0].AssetId)
+	assert.True(t, b.IsFullyPaid())
+	assert.Equal(t, "", b.RemainingMessage())
+	assert.True(t, b.Overpaid.GreaterThan(decimal.Zero))
+	assert.Equal(t, "0.994999", b.Assets[0].Overpaid.String(), "99 * (1 - 50.5/100.000001) rounded down")
+}
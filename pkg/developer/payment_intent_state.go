@@ -0,0 +1,210 @@
+// payment_intent_state.go contains the payment intent lifecycle as a state machine.
+// It encodes which status changes are possible, which statuses are final, and when a
+// payment is funded well enough to fulfill an order, so every integration applies the
+// same rules. See the PaymentIntentStatus documentation in payment_intent.go for the
+// meaning of each status.
+package developer
+
+import (
+	"fmt"
+	"sync"
+)
+
+// paymentIntentTransitions lists the direct status changes of a payment intent.
+// Statuses can be skipped when several changes happen between two observations
+// (for example Waiting → Confirmed), which CanTransition allows.
+var paymentIntentTransitions = map[PaymentIntentStatus][]PaymentIntentStatus{
+	PaymentIntentStatusCreated:       {PaymentIntentStatusWaiting, PaymentIntentStatusCancelled},
+	PaymentIntentStatusWaiting:       {PaymentIntentStatusPartiallyPaid, PaymentIntentStatusPaid, PaymentIntentStatusCancelled},
+	PaymentIntentStatusPartiallyPaid: {PaymentIntentStatusPaid, PaymentIntentStatusFrozen, PaymentIntentStatusCancelled},
+	PaymentIntentStatusPaid:          {PaymentIntentStatusCompleted, PaymentIntentStatusFrozen, PaymentIntentStatusCancelled},
+	PaymentIntentStatusCompleted:     {PaymentIntentStatusConfirmed, PaymentIntentStatusFrozen, PaymentIntentStatusCancelled},
+	PaymentIntentStatusFrozen:        {PaymentIntentStatusUnfrozen},
+	PaymentIntentStatusConfirmed:     {},
+	PaymentIntentStatusUnfrozen:      {},
+	PaymentIntentStatusCancelled:     {},
+}
+
+// AllPaymentIntentStatuses returns every payment intent status in lifecycle order
+func AllPaymentIntentStatuses() []PaymentIntentStatus {
+	return []PaymentIntentStatus{
+		PaymentIntentStatusCreated,
+		PaymentIntentStatusWaiting,
+		PaymentIntentStatusPartiallyPaid,
+		PaymentIntentStatusPaid,
+		PaymentIntentStatusCompleted,
+		PaymentIntentStatusConfirmed,
+		PaymentIntentStatusFrozen,
+		PaymentIntentStatusUnfrozen,
+		PaymentIntentStatusCancelled,
+	}
+}
+
+// IsValid reports whether the status is one of the known PaymentIntentStatus values
+func (s PaymentIntentStatus) IsValid() bool {
+	_, ok := paymentIntentTransitions[s]
+	return ok
+}
+
+// NextStatuses returns the statuses the payment intent can move to directly
+func (s PaymentIntentStatus) NextStatuses() []PaymentIntentStatus {
+	return append([]PaymentIntentStatus(nil), paymentIntentTransitions[s]...)
+}
+
+// IsTerminal reports whether no further status change is possible
+func (s PaymentIntentStatus) IsTerminal() bool {
+	next, ok := paymentIntentTransitions[s]
+	return ok && len(next) == 0
+}
+
+// IsFunded reports whether the customer has sent the full amount and the funds are not frozen.
+// Paid intents are funded by a submitted transaction that may still fail; use IsSafeToFulfill
+// to decide when to ship.
+func (s PaymentIntentStatus) IsFunded() bool {
+	switch s {
+	case PaymentIntentStatusPaid, PaymentIntentStatusCompleted, PaymentIntentStatusConfirmed:
+		return true
+	}
+	return false
+}
+
+// CanCancel reports whether the merchant can still cancel the payment intent,
+// which is only possible before any payment has been received
+func (s PaymentIntentStatus) CanCancel() bool {
+	return s == PaymentIntentStatusCreated || s == PaymentIntentStatusWaiting
+}
+
+// FulfillmentPolicy sets how settled a payment must be before an order is fulfilled
+type FulfillmentPolicy string
+
+const (
+	// FulfillOnPaid fulfills as soon as a sufficient transaction is submitted (fastest, may still fail on-chain)
+	FulfillOnPaid FulfillmentPolicy = "paid"
+	// FulfillOnCompleted fulfills once the transaction is included on-chain (may still be reorganized)
+	FulfillOnCompleted FulfillmentPolicy = "completed"
+	// FulfillOnConfirmed fulfills only after the transaction has enough confirmations (safest; the default)
+	FulfillOnConfirmed FulfillmentPolicy = "confirmed"
+)
+
+// IsSafeToFulfill reports whether the payment is settled enough to fulfill under the policy.
+// An empty policy is treated as FulfillOnConfirmed.
+func (s PaymentIntentStatus) IsSafeToFulfill(policy FulfillmentPolicy) bool {
+	switch policy {
+	case FulfillOnPaid:
+		return s.IsFunded()
+	case FulfillOnCompleted:
+		return s == PaymentIntentStatusCompleted || s == PaymentIntentStatusConfirmed
+	default:
+		return s == PaymentIntentStatusConfirmed
+	}
+}
+
+// CanTransition reports whether a payment intent can move from one status to another,
+// either directly or by passing through intermediate statuses. Staying in the same
+// status is allowed, since the same state may be reported more than once.
+func CanTransition(from, to PaymentIntentStatus) bool {
+	if !from.IsValid() || !to.IsValid() {
+		return false
+	}
+	if from == to {
+		return true
+	}
+	seen := map[PaymentIntentStatus]bool{from: true}
+	queue := []PaymentIntentStatus{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range paymentIntentTransitions[current] {
+			if next == to {
+				return true
+			}
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// InvalidTransitionError describes a payment intent status change that the lifecycle does not allow
+type InvalidTransitionError struct {
+	// PaymentIntentID is the payment intent the change was observed on
+	PaymentIntentID string
+	// From is the last status observed
+	From PaymentIntentStatus
+	// To is the newly observed status
+	To PaymentIntentStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("payment intent %s: impossible status transition %q -> %q", e.PaymentIntentID, e.From, e.To)
+}
+
+// PaymentIntentTransitionValidator tracks the last status seen for each payment intent
+// and flags impossible changes, such as a Confirmed intent reported as Waiting or an
+// out-of-order webhook delivery. It is safe for concurrent use.
+type PaymentIntentTransitionValidator struct {
+	mu   sync.Mutex
+	last map[string]PaymentIntentStatus
+}
+
+// NewPaymentIntentTransitionValidator creates an empty validator
+func NewPaymentIntentTransitionValidator() *PaymentIntentTransitionValidator {
+	return &PaymentIntentTransitionValidator{last: make(map[string]PaymentIntentStatus)}
+}
+
+// Observe records a status for a payment intent.
+// An impossible transition is reported and not recorded, so the last valid status is kept.
+//
+// Parameters:
+//   - id: Payment intent ID
+//   - status: The newly observed status
+//
+// Returns:
+//   - error: *InvalidTransitionError if the change is impossible, or an error for an unknown status
+func (v *PaymentIntentTransitionValidator) Observe(id string, status PaymentIntentStatus) error {
+	if !status.IsValid() {
+		return fmt.Errorf("payment intent %s: unknown status %q", id, status)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if from, ok := v.last[id]; ok && !CanTransition(from, status) {
+		return &InvalidTransitionError{PaymentIntentID: id, From: from, To: status}
+	}
+	v.last[id] = status
+	return nil
+}
+
+// ObserveEvent records the status carried by a payment_intent.* webhook event.
+// Events for other resources are ignored.
+func (v *PaymentIntentTransitionValidator) ObserveEvent(event *Event) error {
+	if !event.Type.Matches("payment_intent.*") {
+		return nil
+	}
+	obj, err := event.DecodeObject()
+	if err != nil {
+		return err
+	}
+	pi := obj.(*PaymentIntent)
+	status := pi.PaymentIntentStatus
+	if status == "" {
+		status = PaymentIntentStatus(pi.Status)
+	}
+	return v.Observe(pi.ID, status)
+}
+
+// Last returns the last valid status observed for a payment intent
+func (v *PaymentIntentTransitionValidator) Last(id string) (PaymentIntentStatus, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.last[id]
+	return s, ok
+}
+
+// Forget drops the tracked status of a payment intent, e.g. once it has reached a terminal status
+func (v *PaymentIntentTransitionValidator) Forget(id string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.last, id)
+}
@@ -0,0 +1,49 @@
+// payment_intent_state_test.go contains unit tests for the payment intent state machine.
+package developer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentIntentCanTransition(t *testing.T) {
+	assert.True(t, CanTransition(PaymentIntentStatusCreated, PaymentIntentStatusWaiting))
+	assert.True(t, CanTransition(PaymentIntentStatusWaiting, PaymentIntentStatusConfirmed), "skipped statuses are allowed")
+	assert.True(t, CanTransition(PaymentIntentStatusPaid, PaymentIntentStatusUnfrozen))
+	assert.True(t, CanTransition(PaymentIntentStatusConfirmed, PaymentIntentStatusConfirmed))
+	assert.False(t, CanTransition(PaymentIntentStatusConfirmed, PaymentIntentStatusWaiting))
+	assert.False(t, CanTransition(PaymentIntentStatusCancelled, PaymentIntentStatusPaid))
+	assert.False(t, CanTransition(PaymentIntentStatusFrozen, PaymentIntentStatusConfirmed))
+	assert.False(t, CanTransition("Bogus", PaymentIntentStatusPaid))
+
+	for _, s := range AllPaymentIntentStatuses() {
+		assert.True(t, s.IsValid(), s)
+	}
+	assert.True(t, PaymentIntentStatusCancelled.IsTerminal())
+	assert.False(t, PaymentIntentStatusFrozen.IsTerminal())
+	assert.True(t, PaymentIntentStatusPaid.IsFunded())
+	assert.False(t, PaymentIntentStatusFrozen.IsFunded())
+	assert.True(t, PaymentIntentStatusWaiting.CanCancel())
+	assert.False(t, PaymentIntentStatusPaid.CanCancel())
+
+	assert.True(t, PaymentIntentStatusPaid.IsSafeToFulfill(FulfillOnPaid))
+	assert.False(t, PaymentIntentStatusPaid.IsSafeToFulfill(FulfillOnCompleted))
+	assert.False(t, PaymentIntentStatusCompleted.IsSafeToFulfill(""))
+	assert.True(t, PaymentIntentStatusConfirmed.IsSafeToFulfill(""))
+}
+
+func TestPaymentIntentTransitionValidator(t *testing.T) {
+	v := NewPaymentIntentTransitionValidator()
+	assert.NoError(t, v.Observe("pi_1", PaymentIntentStatusWaiting))
+	assert.NoError(t, v.Observe("pi_1", PaymentIntentStatusConfirmed))
+
+	err := v.Observe("pi_1", PaymentIntentStatusPartiallyPaid)
+	var invalid *InvalidTransitionError
+	assert.True(t, errors.As(err, &invalid))
+	assert.Equal(t, PaymentIntentStatusConfirmed, invalid.From)
+
+	last, _ := v.Last("pi_1")
+	assert.Equal(t, PaymentIntentStatusConfirmed, last)
+}
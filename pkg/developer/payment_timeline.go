@@ -0,0 +1,333 @@
+// payment_timeline.go contains a helper that flattens a payment intent, its charges, blockchain
+// transactions, refunds, and unfreeze withdrawals into a single chronological timeline with
+// block explorer links, for support staff investigating what happened to a payment.
+package developer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimelineEntryKind identifies what happened in a timeline entry
+type TimelineEntryKind string
+
+const (
+	// TimelinePaymentIntentCreated marks the creation of the payment intent
+	TimelinePaymentIntentCreated TimelineEntryKind = "payment_intent.created"
+	// TimelineChargeCreated marks the creation of a charge (the customer picked a payment method)
+	TimelineChargeCreated TimelineEntryKind = "charge.created"
+	// TimelineTxSubmitted marks a customer transaction that is submitted but not yet on-chain
+	TimelineTxSubmitted TimelineEntryKind = "transaction.submitted"
+	// TimelineTxCompleted marks a customer transaction included on-chain
+	TimelineTxCompleted TimelineEntryKind = "transaction.completed"
+	// TimelineTxConfirmed marks a customer transaction with enough confirmations
+	TimelineTxConfirmed TimelineEntryKind = "transaction.confirmed"
+	// TimelineTxFailed marks a customer transaction that failed
+	TimelineTxFailed TimelineEntryKind = "transaction.failed"
+	// TimelineFundsFrozen marks funds frozen because a transaction was rejected by AML screening
+	TimelineFundsFrozen TimelineEntryKind = "funds.frozen"
+	// TimelineRefundCreated marks the creation of a refund
+	TimelineRefundCreated TimelineEntryKind = "refund.created"
+	// TimelineRefundSent marks a refund transaction sent to the customer
+	TimelineRefundSent TimelineEntryKind = "refund.sent"
+	// TimelineUnfrozen marks an unfreeze withdrawal (reverse to sender or release to the merchant)
+	TimelineUnfrozen TimelineEntryKind = "funds.unfrozen"
+	// TimelinePaymentIntentCancelled marks the cancellation of the payment intent
+	TimelinePaymentIntentCancelled TimelineEntryKind = "payment_intent.cancelled"
+)
+
+// TimelineEntry is one event in a payment timeline.
+// Transactions appear once, at their creation time, with their current status.
+type TimelineEntry struct {
+	// Time is the Unix timestamp of the event
+	Time int64 `json:"time"`
+	// Kind identifies what happened
+	Kind TimelineEntryKind `json:"kind"`
+	// Summary is a one-line, human-readable description
+	Summary string `json:"summary"`
+	// ObjectID is the ID of the payment intent, charge, refund, or unfreeze withdrawal the event belongs to
+	ObjectID string `json:"object_id"`
+	// Amount is the decimal amount involved, if any
+	Amount string `json:"amount,omitempty"`
+	// AssetId is the asset of the amount
+	AssetId string `json:"asset_id,omitempty"`
+	// Status is the status of the object or transaction
+	Status string `json:"status,omitempty"`
+	// AmlStatus is the AML screening status of the transaction ('', 'approved', 'rejected')
+	AmlStatus string `json:"aml_status,omitempty"`
+	// TxHash is the blockchain transaction hash
+	TxHash string `json:"tx_hash,omitempty"`
+	// Address is the counterparty address (sender of a payment, recipient of a refund or unfreeze)
+	Address string `json:"address,omitempty"`
+	// ExplorerURL links to the transaction, or to the address when there is no transaction yet
+	ExplorerURL string `json:"explorer_url,omitempty"`
+}
+
+// PaymentTimeline is the chronological history of a payment intent
+type PaymentTimeline struct {
+	// PaymentIntentID is the ID of the payment intent
+	PaymentIntentID string `json:"payment_intent_id"`
+	// Status is the current status of the payment intent
+	Status PaymentIntentStatus `json:"status"`
+	// Entries are the events, oldest first
+	Entries []*TimelineEntry `json:"entries"`
+}
+
+// BuildPaymentTimeline flattens a payment intent into a chronological timeline.
+// Transactions listed both on a charge and on its refund appear once.
+//
+// Parameters:
+//   - pi: The payment intent, as returned by GetPaymentIntent (with charges expanded)
+//   - assets: Assets used to render block explorer links; nil omits the links
+//
+// Returns:
+//   - *PaymentTimeline: The timeline, oldest entry first
+func BuildPaymentTimeline(pi *PaymentIntent, assets AssetRegistry) *PaymentTimeline {
+	b := &timelineBuilder{assets: assets, seenTx: make(map[string]bool)}
+
+	status := pi.PaymentIntentStatus
+	if status == "" {
+		status = PaymentIntentStatus(pi.Status)
+	}
+	created := &TimelineEntry{Time: pi.Created, Kind: TimelinePaymentIntentCreated, ObjectID: pi.ID, Summary: "Payment intent created"}
+	if pi.Amount != nil {
+		created.Amount, created.AssetId = pi.Amount.Amount.String(), pi.Amount.AssetId
+		created.Summary += " for " + formatTimelineAmount(created.Amount, created.AssetId)
+	}
+	b.add(created)
+
+	for _, charge := range pi.Charges {
+		if charge != nil {
+			b.addCharge(charge)
+		}
+	}
+	for _, uw := range pi.UnfreezeWithdraws {
+		if uw != nil {
+			b.addUnfreeze(uw)
+		}
+	}
+	if status == PaymentIntentStatusCancelled || pi.CanceledAt > 0 {
+		at := pi.CanceledAt
+		if at == 0 {
+			at = pi.Updated
+		}
+		summary := "Payment intent cancelled"
+		if pi.CancellationReason != "" {
+			summary += " (" + pi.CancellationReason + ")"
+		}
+		b.add(&TimelineEntry{Time: at, Kind: TimelinePaymentIntentCancelled, ObjectID: pi.ID, Summary: summary})
+	}
+
+	sort.SliceStable(b.entries, func(i, j int) bool {
+		return b.entries[i].Time < b.entries[j].Time
+	})
+	return &PaymentTimeline{PaymentIntentID: pi.ID, Status: status, Entries: b.entries}
+}
+
+// JSON returns the timeline as indented JSON
+func (t *PaymentTimeline) JSON() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+// WriteText writes the timeline as plain text, one entry per line in UTC, followed by its explorer link
+//
+// Parameters:
+//   - w: Destination writer
+//
+// Returns:
+//   - error: nil on success, error if writing fails
+func (t *PaymentTimeline) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Payment intent %s (%s)\n", t.PaymentIntentID, t.Status)
+	for _, e := range t.Entries {
+		fmt.Fprintf(&sb, "%s  %-24s %s\n", time.Unix(e.Time, 0).UTC().Format("2006-01-02 15:04:05"), e.Kind, e.Summary)
+		if e.ExplorerURL != "" {
+			fmt.Fprintf(&sb, "%46s%s\n", "", e.ExplorerURL)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// String returns the plain text form of the timeline
+func (t *PaymentTimeline) String() string {
+	var sb strings.Builder
+	_ = t.WriteText(&sb)
+	return sb.String()
+}
+
+// timelineBuilder collects entries and skips transactions that were already added
+type timelineBuilder struct {
+	assets  AssetRegistry
+	seenTx  map[string]bool
+	entries []*TimelineEntry
+}
+
+func (b *timelineBuilder) add(e *TimelineEntry) {
+	b.entries = append(b.entries, e)
+}
+
+func (b *timelineBuilder) addCharge(charge *Charge) {
+	e := &TimelineEntry{Time: charge.Created, Kind: TimelineChargeCreated, ObjectID: charge.ID}
+	e.Summary = "Charge created"
+	if charge.PaymentMethodType != "" {
+		e.Summary += " (" + string(charge.PaymentMethodType) + ")"
+	}
+	if charge.Amount != nil {
+		e.Amount, e.AssetId = charge.Amount.Amount.String(), charge.Amount.AssetId
+		e.Summary += " for " + formatTimelineAmount(e.Amount, e.AssetId)
+	}
+	if opts := charge.PaymentMethodOptions; opts != nil && opts.Crypto != nil && opts.Crypto.DepositAddress != nil {
+		e.Address = *opts.Crypto.DepositAddress
+		e.Summary += ", deposit address " + e.Address
+		assetID := e.AssetId
+		if opts.Crypto.AssetId != nil {
+			assetID = *opts.Crypto.AssetId
+		}
+		e.ExplorerURL = b.assets.Get(assetID).AddressURL(e.Address)
+	}
+	b.add(e)
+
+	// Refund transactions are taken from the refunds so they are attributed to the right refund
+	for _, refund := range charge.Refunds {
+		if refund != nil {
+			b.addRefund(refund)
+		}
+	}
+	for _, tx := range charge.Transactions {
+		if tx != nil {
+			b.addTransaction(charge.ID, tx, tx.Type == "1")
+		}
+	}
+}
+
+func (b *timelineBuilder) addRefund(refund *Refund) {
+	e := &TimelineEntry{Time: refund.Created, Kind: TimelineRefundCreated, ObjectID: refund.ID, Status: refund.Status}
+	e.Summary = "Refund created"
+	if refund.Amount != nil {
+		e.Amount, e.AssetId = refund.Amount.Amount.String(), refund.Amount.AssetId
+		e.Summary += " for " + formatTimelineAmount(e.Amount, e.AssetId)
+	}
+	if refund.RefundAddress != nil && *refund.RefundAddress != "" {
+		e.Address = *refund.RefundAddress
+		e.Summary += " to " + e.Address
+		e.ExplorerURL = b.assets.Get(e.AssetId).AddressURL(e.Address)
+	}
+	if refund.Status != "" {
+		e.Summary += " [" + refund.Status + "]"
+	}
+	if refund.FailureReason != "" {
+		e.Summary += ": " + refund.FailureReason
+	}
+	b.add(e)
+
+	for _, tx := range refund.Transactions {
+		if tx != nil {
+			b.addTransaction(refund.ID, tx, true)
+		}
+	}
+}
+
+func (b *timelineBuilder) addTransaction(objectID string, tx *TransactionDetails, refund bool) {
+	key := tx.TxHash
+	if key == "" {
+		key = tx.TxId
+	}
+	if key != "" {
+		key = fmt.Sprintf("%t:%s", refund, key)
+		if b.seenTx[key] {
+			return
+		}
+		b.seenTx[key] = true
+	}
+
+	e := &TimelineEntry{
+		Time:      tx.CreatedAt,
+		ObjectID:  objectID,
+		Amount:    tx.Amount,
+		AssetId:   tx.AssetId,
+		Status:    tx.Status,
+		AmlStatus: tx.AmlStatus,
+		TxHash:    tx.TxHash,
+	}
+	if refund && tx.RefundId != "" {
+		e.ObjectID = tx.RefundId
+	}
+	amount := formatTimelineAmount(tx.Amount, tx.Token)
+	if refund {
+		e.Kind = TimelineRefundSent
+		e.Address = tx.DestinationAddress
+		e.Summary = fmt.Sprintf("Refund transaction %s: %s to %s", strings.ToLower(tx.Status), amount, tx.DestinationAddress)
+	} else {
+		e.Kind = transactionKind(tx.Status)
+		e.Address = tx.SourceAddress
+		e.Summary = fmt.Sprintf("Transaction %s: %s from %s", strings.ToLower(tx.Status), amount, tx.SourceAddress)
+	}
+	if tx.AmlStatus != "" {
+		e.Summary += " (AML " + tx.AmlStatus + ")"
+	}
+	asset := b.assets.Get(tx.AssetId)
+	e.ExplorerURL = asset.TxURL(tx.TxHash)
+	b.add(e)
+
+	if !refund && ParseAmlStatus(tx.AmlStatus).IsRejected() {
+		frozen := *e
+		frozen.Kind = TimelineFundsFrozen
+		frozen.Summary = fmt.Sprintf("Funds frozen: %s rejected by AML screening", amount)
+		if tx.AmlInfo != nil && *tx.AmlInfo != "" {
+			frozen.Summary += " (" + *tx.AmlInfo + ")"
+		}
+		b.add(&frozen)
+	}
+}
+
+func (b *timelineBuilder) addUnfreeze(uw *UnfreezeWithdraw) {
+	e := &TimelineEntry{Time: uw.CreatedAt, Kind: TimelineUnfrozen, ObjectID: uw.ID, AssetId: uw.AssetID, Status: uw.Status, Address: uw.Address}
+	if uw.Amount != nil {
+		e.Amount = uw.Amount.Amount.String()
+	}
+	amount := formatTimelineAmount(e.Amount, e.AssetId)
+	switch uw.Type {
+	case UnfreezeTypeRelease:
+		e.Summary = fmt.Sprintf("Frozen funds released to merchant balance: %s", amount)
+	default:
+		e.Summary = fmt.Sprintf("Frozen funds reversed to sender: %s to %s", amount, uw.Address)
+		e.ExplorerURL = b.assets.Get(uw.AssetID).AddressURL(uw.Address)
+	}
+	if uw.Status != "" {
+		e.Summary += " [" + uw.Status + "]"
+	}
+	if uw.OriginalFrozenTxID != "" {
+		e.Summary += ", original tx " + uw.OriginalFrozenTxID
+	}
+	b.add(e)
+}
+
+// transactionKind maps a customer transaction status to a timeline entry kind
+func transactionKind(status string) TimelineEntryKind {
+	switch strings.ToLower(status) {
+	case "completed":
+		return TimelineTxCompleted
+	case "confirmed":
+		return TimelineTxConfirmed
+	case "failed":
+		return TimelineTxFailed
+	default:
+		return TimelineTxSubmitted
+	}
+}
+
+// formatTimelineAmount joins an amount with its asset or token, or returns "" when there is no amount
+func formatTimelineAmount(amount, unit string) string {
+	if amount == "" {
+		return ""
+	}
+	if unit == "" {
+		return amount
+	}
+	return amount + " " + unit
+}
@@ -0,0 +1,131 @@
+// payment_timeline_test.go contains unit tests for payment timelines and block explorer links.
+package developer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplorerURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"printf verb", "https://etherscan.io/tx/%s", "https://etherscan.io/tx/0xabc"},
+		{"named placeholder", "https://tronscan.org/#/transaction/{tx_hash}?lang=en", "https://tronscan.org/#/transaction/0xabc?lang=en"},
+		{"prefix", "https://solscan.io/tx/", "https://solscan.io/tx/0xabc"},
+		{"no template", "", ""},
+	}
+	for _, tt := range tests {
+		asset := &Asset{Id: "USDC_ETH", CryptoAssetParams: &CryptoAssetParams{TxUrlTemplate: tt.template}}
+		assert.Equal(t, tt.want, asset.TxURL("0xabc"), tt.name)
+	}
+
+	asset := &Asset{Id: "USDC_ETH", CryptoAssetParams: &CryptoAssetParams{AddressUrlTemplate: "https://etherscan.io/address/{address}"}}
+	assert.Equal(t, "https://etherscan.io/address/0xdef", asset.AddressURL("0xdef"))
+	assert.Empty(t, asset.AddressURL(""))
+	assert.Empty(t, (&Asset{Id: "USD", IsFiat: true}).TxURL("0xabc"))
+	var unknown *Asset
+	assert.Empty(t, unknown.TxURL("0xabc"))
+}
+
+// timelineFixture is a payment with a confirmed transaction, an AML-rejected transaction that was
+// reversed to its sender, and a refund whose transaction is also listed on the charge
+func timelineFixture() (*PaymentIntent, AssetRegistry) {
+	deposit, refundAddr, info := "0xdeposit", "0xcustomer", "sanctions"
+	assetID := "USDC_ETH"
+	refundTx := &TransactionDetails{TxHash: "0xr1", Amount: "2", AssetId: assetID, Token: "USDC", Type: "1", Status: "confirmed", DestinationAddress: refundAddr, RefundId: "re_1", CreatedAt: 500}
+	pi := &PaymentIntent{
+		ID:                  "pi_1",
+		Created:             100,
+		PaymentIntentStatus: PaymentIntentStatusConfirmed,
+		Amount:              &AssetAmount{AssetId: "USD", Amount: decimal.NewFromInt(10)},
+		Charges: []*Charge{{
+			ID:                   "ch_1",
+			Created:              150,
+			PaymentMethodType:    PaymentMethodTypeCrypto,
+			PaymentMethodOptions: &PaymentMethodOptions{Crypto: &Crypto{AssetId: &assetID, DepositAddress: &deposit}},
+			Transactions: []*TransactionDetails{
+				{TxHash: "0xt2", Amount: "3", AssetId: assetID, Token: "USDC", Type: "0", Status: "confirmed", AmlStatus: "rejected", AmlInfo: &info, SourceAddress: "0xbad", CreatedAt: 300},
+				{TxHash: "0xt1", Amount: "10", AssetId: assetID, Token: "USDC", Type: "0", Status: "confirmed", AmlStatus: "approved", SourceAddress: refundAddr, CreatedAt: 200},
+				refundTx,
+			},
+			Refunds: []*Refund{{ID: "re_1", Created: 450, Status: "succeeded", RefundAddress: &refundAddr, Amount: &AssetAmount{AssetId: assetID, Amount: decimal.NewFromInt(2)}, Transactions: []*TransactionDetails{refundTx}}},
+		}},
+		UnfreezeWithdraws: []*UnfreezeWithdraw{{ID: "uw_1", AssetID: assetID, Amount: &AssetAmount{AssetId: assetID, Amount: decimal.NewFromInt(3)}, Status: "completed", Address: "0xbad", Type: UnfreezeTypeReverse, OriginalFrozenTxID: "0xt2", CreatedAt: 400}},
+	}
+	assets := NewAssetRegistry([]*Asset{{Id: assetID, CryptoAssetParams: &CryptoAssetParams{
+		TxUrlTemplate:      "https://etherscan.io/tx/{tx_hash}",
+		AddressUrlTemplate: "https://etherscan.io/address/{address}",
+	}}})
+	return pi, assets
+}
+
+func TestBuildPaymentTimeline(t *testing.T) {
+	pi, assets := timelineFixture()
+	timeline := BuildPaymentTimeline(pi, assets)
+
+	var kinds []TimelineEntryKind
+	for i, e := range timeline.Entries {
+		kinds = append(kinds, e.Kind)
+		if i > 0 {
+			assert.LessOrEqual(t, timeline.Entries[i-1].Time, e.Time, "entries are oldest first")
+		}
+	}
+	assert.Equal(t, []TimelineEntryKind{
+		TimelinePaymentIntentCreated,
+		TimelineChargeCreated,
+		TimelineTxConfirmed,
+		TimelineTxConfirmed,
+		TimelineFundsFrozen,
+		TimelineUnfrozen,
+		TimelineRefundCreated,
+		TimelineRefundSent,
+	}, kinds, "the refund transaction listed on both the charge and the refund appears once")
+
+	charge := timeline.Entries[1]
+	assert.Equal(t, "https://etherscan.io/address/0xdeposit", charge.ExplorerURL)
+	assert.Equal(t, "https://etherscan.io/tx/0xt1", timeline.Entries[2].ExplorerURL)
+
+	frozen := timeline.Entries[4]
+	assert.Equal(t, "0xt2", frozen.TxHash)
+	assert.Contains(t, frozen.Summary, "rejected by AML screening (sanctions)")
+
+	unfrozen := timeline.Entries[5]
+	assert.Equal(t, "uw_1", unfrozen.ObjectID)
+	assert.Contains(t, unfrozen.Summary, "reversed to sender: 3 USDC_ETH to 0xbad")
+	assert.Equal(t, "https://etherscan.io/address/0xbad", unfrozen.ExplorerURL)
+
+	sent := timeline.Entries[7]
+	assert.Equal(t, "re_1", sent.ObjectID)
+	assert.Equal(t, "0xcustomer", sent.Address)
+
+	noLinks := BuildPaymentTimeline(pi, nil)
+	for _, e := range noLinks.Entries {
+		assert.Empty(t, e.ExplorerURL)
+	}
+}
+
+func TestPaymentTimelineOutput(t *testing.T) {
+	pi, assets := timelineFixture()
+	pi.Charges = nil
+	pi.UnfreezeWithdraws = []*UnfreezeWithdraw{{ID: "uw_1", AssetID: "USDC_ETH", Amount: &AssetAmount{Amount: decimal.NewFromInt(3)}, Type: UnfreezeTypeRelease, CreatedAt: 3600}}
+	timeline := BuildPaymentTimeline(pi, assets)
+
+	assert.Equal(t, "Payment intent pi_1 (Confirmed)\n"+
+		"1970-01-01 00:01:40  payment_intent.created   Payment intent created for 10 USD\n"+
+		"1970-01-01 01:00:00  funds.unfrozen           Frozen funds released to merchant balance: 3 USDC_ETH\n",
+		timeline.String())
+
+	raw, err := timeline.JSON()
+	require.NoError(t, err)
+	var decoded PaymentTimeline
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, timeline, &decoded)
+	assert.Contains(t, string(raw), `"kind": "funds.unfrozen"`)
+}
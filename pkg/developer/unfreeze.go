@@ -30,3 +30,40 @@ type UnfreezeCreateRequest struct {
 	// Description is an optional description of the unfreeze reason
 	Description string `json:"description"`
 }
+
+// UnfreezeListRequest lists unfreeze withdrawals with filters
+type UnfreezeListRequest struct {
+	Pagination
+	// PaymentIntentID filters unfreeze withdrawals by payment intent
+	PaymentIntentID *string `json:"payment_intent_id,omitempty" form:"payment_intent_id"`
+	// Type filters unfreeze withdrawals by type (unfreeze_reverse or unfreeze_release)
+	Type *string `json:"type,omitempty" form:"type"`
+	// Status filters unfreeze withdrawals by status
+	Status *string `json:"status,omitempty" form:"status"`
+}
+
+// ================================
+// Response Types
+// ================================
+
+// UnfreezeCreateResponse represents unfreeze creation response
+type UnfreezeCreateResponse struct {
+	UnfreezeWithdraw
+}
+
+// UnfreezeGetResponse represents unfreeze get response
+type UnfreezeGetResponse struct {
+	UnfreezeWithdraw
+}
+
+// UnfreezeListResponse represents a paginated list of unfreeze withdrawals
+type UnfreezeListResponse struct {
+	// UnfreezeWithdraws is the list of unfreeze withdrawals
+	UnfreezeWithdraws []*UnfreezeWithdraw `json:"unfreeze_withdraws"`
+	// Total is the total number of unfreeze withdrawals matching the filters
+	Total int64 `json:"total"`
+	// Page is the current page number
+	Page int32 `json:"page"`
+	// PageSize is the number of items per page
+	PageSize int32 `json:"page_size"`
+}
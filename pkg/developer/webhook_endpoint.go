@@ -0,0 +1,164 @@
+// webhook_endpoint.go contains request and response types for managing webhook endpoints,
+// together with the client-side validation applied before endpoints are created or updated.
+package developer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// WebhookEndpointAllEvents is the EnabledEvents entry that subscribes an endpoint to every event
+const WebhookEndpointAllEvents = "*"
+
+// ================================
+// Request Types
+// ================================
+
+// WebhookEndpointStatusUpdateRequest enables or disables a webhook endpoint
+type WebhookEndpointStatusUpdateRequest struct {
+	// Status is the new endpoint status (DeveloperStatusActive or DeveloperStatusInactive)
+	Status string `json:"status"`
+}
+
+// WebhookEndpointUpdateRequest updates a webhook endpoint.
+// Fields that are nil are omitted from the request and left unchanged.
+type WebhookEndpointUpdateRequest struct {
+	// URL is the new webhook endpoint URL
+	URL *string `json:"url,omitempty"`
+	// Description is the new description of the webhook endpoint
+	Description *string `json:"description,omitempty"`
+	// EnabledEvents replaces the subscribed event types (use ['*'] to enable all events)
+	EnabledEvents []string `json:"enabled_events,omitempty"`
+}
+
+// WebhookEndpointListRequest lists webhook endpoints
+type WebhookEndpointListRequest struct {
+	// Page is the page number (zero-based)
+	Page int32 `json:"page" binding:"min=0" form:"page"`
+	// PageSize is the number of items per page (max 50)
+	PageSize int32 `json:"page_size" binding:"required,min=1,max=50" form:"page_size"`
+}
+
+// ================================
+// Response Types
+// ================================
+
+// WebhookEndpointListResp represents a paginated list of webhook endpoints
+type WebhookEndpointListResp struct {
+	// WebhookEndpoints is the list of webhook endpoints
+	WebhookEndpoints []*WebhookEndpoint `json:"webhook_endpoints"`
+	// Total is the total number of webhook endpoints
+	Total int64 `json:"total"`
+	// Page is the current page number
+	Page int32 `json:"page"`
+	// PageSize is the number of items per page
+	PageSize int32 `json:"page_size"`
+}
+
+// ================================
+// Validation
+// ================================
+
+// ValidateWebhookURL checks that rawURL is an absolute https URL with a host
+func ValidateWebhookURL(rawURL string) error {
+	if strings.TrimSpace(rawURL) == "" {
+		return errors.New("webhook endpoint URL is required")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook endpoint URL %q: %v", rawURL, err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("webhook endpoint URL %q must use https", rawURL)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("webhook endpoint URL %q has no host", rawURL)
+	}
+	if u.User != nil {
+		return fmt.Errorf("webhook endpoint URL %q must not contain credentials", rawURL)
+	}
+	if u.Fragment != "" {
+		return fmt.Errorf("webhook endpoint URL %q must not contain a fragment", rawURL)
+	}
+	return nil
+}
+
+// ValidateEnabledEvents checks that events is either ["*"] or a list of known event types
+// and family wildcards such as "payout.*", the patterns EventType.Matches accepts
+func ValidateEnabledEvents(events []string) error {
+	if len(events) == 0 {
+		return errors.New("at least one enabled event is required (use [\"*\"] for all events)")
+	}
+	seen := make(map[string]bool, len(events))
+	for _, e := range events {
+		if e == WebhookEndpointAllEvents {
+			if len(events) > 1 {
+				return errors.New("enabled event \"*\" cannot be combined with other events")
+			}
+			continue
+		}
+		if strings.HasSuffix(e, ".*") {
+			if !matchesAnyEventType(e) {
+				return fmt.Errorf("unknown enabled event family %q", e)
+			}
+		} else if !EventType(e).IsValid() {
+			return fmt.Errorf("unknown enabled event %q", e)
+		}
+		if seen[e] {
+			return fmt.Errorf("duplicate enabled event %q", e)
+		}
+		seen[e] = true
+	}
+	return nil
+}
+
+// matchesAnyEventType reports whether a family wildcard matches at least one known event type
+func matchesAnyEventType(pattern string) bool {
+	for _, known := range AllEventTypes() {
+		if known.Matches(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateWebhookFields validates the URL and enabled events when they are set
+func validateWebhookFields(rawURL *string, events []string) error {
+	if rawURL != nil {
+		if err := ValidateWebhookURL(*rawURL); err != nil {
+			return err
+		}
+	}
+	if events != nil {
+		if err := ValidateEnabledEvents(events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the fields that are set
+func (p *WebhookEndpointParams) Validate() error {
+	return validateWebhookFields(p.URL, p.EnabledEvents)
+}
+
+// ValidateForCreate checks that the parameters are complete and valid for a new endpoint
+func (p *WebhookEndpointParams) ValidateForCreate() error {
+	if p.URL == nil {
+		return errors.New("webhook endpoint URL is required")
+	}
+	if p.EnabledEvents == nil {
+		return ValidateEnabledEvents(nil)
+	}
+	return p.Validate()
+}
+
+// Validate checks the fields that are set and that at least one field is
+func (r *WebhookEndpointUpdateRequest) Validate() error {
+	if r.URL == nil && r.Description == nil && r.EnabledEvents == nil {
+		return errors.New("webhook endpoint update has no fields to change")
+	}
+	return validateWebhookFields(r.URL, r.EnabledEvents)
+}
@@ -0,0 +1,635 @@
+// file_store.go contains a Store implementation backed by append-only JSONL segments on local disk.
+// Every state change is written as a journal entry and fsynced before the call returns, so an
+// event that has been acknowledged to MartianPay is never lost across restarts or deploys.
+package inbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxSegmentBytes is the size after which the journal rolls over to a new segment
+	DefaultMaxSegmentBytes int64 = 16 << 20
+	// DefaultAckRetention is how long acknowledged event IDs are kept for de-duplication after compaction
+	DefaultAckRetention = 7 * 24 * time.Hour
+
+	// segmentPrefix is the file name prefix of journal segments
+	segmentPrefix = "segment-"
+	// segmentSuffix is the file name suffix of journal segments
+	segmentSuffix = ".jsonl"
+	// deadLetterFile is the name of the dead-letter file inside the store directory
+	deadLetterFile = "dead-letter.jsonl"
+)
+
+// Journal operations
+const (
+	opAppend   = "append"
+	opComplete = "complete"
+	opAck      = "ack"
+	opDead     = "dead"
+	opRequeue  = "requeue"
+	opSnapshot = "snapshot"
+)
+
+// journalEntry is a single line in a journal segment
+type journalEntry struct {
+	// Op is the journal operation
+	Op string `json:"op"`
+	// EventID is the event the operation applies to
+	EventID string `json:"event_id"`
+	// At is the Unix timestamp when the operation was written
+	At int64 `json:"at"`
+	// Handler is the handler name for complete operations
+	Handler string `json:"handler,omitempty"`
+	// Record is the full record for append, dead and snapshot operations
+	Record *Record `json:"record,omitempty"`
+}
+
+// FileStoreOptions configures a FileStore
+type FileStoreOptions struct {
+	// MaxSegmentBytes is the size after which a new segment is started (default DefaultMaxSegmentBytes)
+	MaxSegmentBytes int64
+	// AckRetention is how long acknowledged event IDs survive compaction (default DefaultAckRetention)
+	AckRetention time.Duration
+}
+
+// FileStore is a Store that keeps an append-only journal of JSONL segments in a directory.
+// Dead-lettered records are additionally written to dead-letter.jsonl for inspection.
+type FileStore struct {
+	dir  string
+	opts FileStoreOptions
+
+	mu       sync.Mutex
+	closed   bool
+	segment  *os.File
+	segNum   int
+	segSize  int64
+	seq      int64
+	records  map[string]*Record
+	order    map[string]int64
+	ackedAt  map[string]int64
+	deadFile string
+}
+
+// OpenFileStore opens (or creates) a file store in dir and replays its journal.
+//
+// Parameters:
+//   - dir: Directory holding the journal segments and the dead-letter file
+//   - opts: Optional settings, nil for defaults
+//
+// Returns:
+//   - *FileStore: The opened store
+//   - error: nil on success, error if the directory or journal cannot be read
+func OpenFileStore(dir string, opts *FileStoreOptions) (*FileStore, error) {
+	s := &FileStore{
+		dir:      dir,
+		records:  make(map[string]*Record),
+		order:    make(map[string]int64),
+		ackedAt:  make(map[string]int64),
+		deadFile: filepath.Join(dir, deadLetterFile),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.MaxSegmentBytes <= 0 {
+		s.opts.MaxSegmentBytes = DefaultMaxSegmentBytes
+	}
+	if s.opts.AckRetention <= 0 {
+		s.opts.AckRetention = DefaultAckRetention
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating inbox directory: %v", err)
+	}
+
+	segments, err := s.segmentNumbers()
+	if err != nil {
+		return nil, err
+	}
+	for _, num := range segments {
+		if err := s.replay(num); err != nil {
+			return nil, err
+		}
+	}
+
+	next := 1
+	if len(segments) > 0 {
+		next = segments[len(segments)-1]
+	}
+	if err := s.openSegment(next); err != nil {
+		return nil, err
+	}
+
+	// The journal is authoritative; rebuild the dead-letter view in case a
+	// previous process stopped between writing the journal and the view.
+	if err := s.writeDeadLetterFile(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// segmentNumbers returns the numbers of all existing segments in ascending order
+func (s *FileStore) segmentNumbers() ([]int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading inbox directory: %v", err)
+	}
+	var nums []int
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		var num int
+		if _, err := fmt.Sscanf(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), "%d", &num); err != nil {
+			continue
+		}
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	return nums, nil
+}
+
+// segmentPath returns the path of the segment with the given number
+func (s *FileStore) segmentPath(num int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s%08d%s", segmentPrefix, num, segmentSuffix))
+}
+
+// replay applies every entry of a segment to the in-memory state
+func (s *FileStore) replay(num int) error {
+	f, err := os.Open(s.segmentPath(num))
+	if err != nil {
+		return fmt.Errorf("error opening segment %d: %v", num, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// A torn line is expected after a crash mid-write and is skipped;
+			// openSegment terminates it before anything else is appended.
+			continue
+		}
+		s.apply(&entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading segment %d: %v", num, err)
+	}
+	return nil
+}
+
+// apply updates the in-memory state for a journal entry
+func (s *FileStore) apply(entry *journalEntry) {
+	switch entry.Op {
+	case opAppend, opSnapshot:
+		if entry.Record == nil {
+			return
+		}
+		if _, ok := s.records[entry.EventID]; ok && entry.Op == opAppend {
+			return
+		}
+		rec := entry.Record.clone()
+		if rec.State == "" {
+			rec.State = RecordStatePending
+		}
+		s.records[entry.EventID] = rec
+		s.seq++
+		s.order[entry.EventID] = s.seq
+		if rec.State == RecordStateAcked {
+			s.ackedAt[entry.EventID] = entry.At
+		}
+	case opComplete:
+		if rec, ok := s.records[entry.EventID]; ok && !rec.HasCompleted(entry.Handler) {
+			rec.Completed = append(rec.Completed, entry.Handler)
+		}
+	case opAck:
+		rec, ok := s.records[entry.EventID]
+		if !ok {
+			// Compacted tombstone: keep the ID for de-duplication only
+			rec = &Record{EventID: entry.EventID}
+			s.records[entry.EventID] = rec
+		}
+		rec.State = RecordStateAcked
+		rec.Payload = nil
+		s.ackedAt[entry.EventID] = entry.At
+	case opDead:
+		if entry.Record == nil {
+			return
+		}
+		rec := entry.Record.clone()
+		rec.State = RecordStateDead
+		s.records[entry.EventID] = rec
+	case opRequeue:
+		if rec, ok := s.records[entry.EventID]; ok && rec.State == RecordStateDead {
+			rec.State = RecordStatePending
+			rec.FailedHandler = ""
+			rec.DeadAt = 0
+			s.seq++
+			s.order[entry.EventID] = s.seq
+		}
+	}
+}
+
+// openSegment opens the segment with the given number for appending
+func (s *FileStore) openSegment(num int) error {
+	f, err := os.OpenFile(s.segmentPath(num), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening segment %d: %v", num, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("error reading segment %d: %v", num, err)
+	}
+	size := info.Size()
+	if size > 0 && !endsWithNewline(s.segmentPath(num), size) {
+		// Terminate a line torn by a crash so the next entry starts cleanly
+		if _, err := f.Write([]byte{'\n'}); err != nil {
+			f.Close()
+			return fmt.Errorf("error repairing segment %d: %v", num, err)
+		}
+		size++
+	}
+	if s.segment != nil {
+		s.segment.Close()
+	}
+	s.segment = f
+	s.segNum = num
+	s.segSize = size
+	return nil
+}
+
+// write appends entries to the current segment, fsyncs it and applies them in memory.
+// Callers must hold s.mu.
+func (s *FileStore) write(entries ...*journalEntry) error {
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	var buf []byte
+	for _, entry := range entries {
+		if entry.At == 0 {
+			entry.At = time.Now().Unix()
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("error marshaling journal entry: %v", err)
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	if s.segSize > 0 && s.segSize+int64(len(buf)) > s.opts.MaxSegmentBytes {
+		if err := s.openSegment(s.segNum + 1); err != nil {
+			return err
+		}
+	}
+	if _, err := s.segment.Write(buf); err != nil {
+		return fmt.Errorf("error writing journal: %v", err)
+	}
+	if err := s.segment.Sync(); err != nil {
+		return fmt.Errorf("error syncing journal: %v", err)
+	}
+	s.segSize += int64(len(buf))
+
+	for _, entry := range entries {
+		s.apply(entry)
+	}
+	return nil
+}
+
+// Append stores a new pending record
+func (s *FileStore) Append(rec *Record) (bool, error) {
+	if rec == nil || rec.EventID == "" {
+		return false, fmt.Errorf("inbox: record must have an event ID")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[rec.EventID]; ok {
+		return false, nil
+	}
+	stored := rec.clone()
+	stored.State = RecordStatePending
+	if stored.ReceivedAt == 0 {
+		stored.ReceivedAt = time.Now().Unix()
+	}
+	if err := s.write(&journalEntry{Op: opAppend, EventID: rec.EventID, Record: stored}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Pending returns all pending records, oldest first
+func (s *FileStore) Pending() ([]*Record, error) {
+	return s.list(RecordStatePending)
+}
+
+// DeadLetters returns all dead-lettered records, oldest first
+func (s *FileStore) DeadLetters() ([]*Record, error) {
+	return s.list(RecordStateDead)
+}
+
+// list returns copies of all records in the given state ordered by insertion
+func (s *FileStore) list(state RecordState) ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	var result []*Record
+	for _, rec := range s.records {
+		if rec.State == state {
+			result = append(result, rec.clone())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return s.order[result[i].EventID] < s.order[result[j].EventID]
+	})
+	return result, nil
+}
+
+// Get returns the record for the given event ID
+func (s *FileStore) Get(eventID string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[eventID]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+	return rec.clone(), nil
+}
+
+// Has reports whether the store has seen the given event ID in any state
+func (s *FileStore) Has(eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.records[eventID]
+	return ok, nil
+}
+
+// Complete records that the named handler processed the event successfully
+func (s *FileStore) Complete(eventID string, handler string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[eventID]; !ok {
+		return ErrRecordNotFound
+	}
+	return s.write(&journalEntry{Op: opComplete, EventID: eventID, Handler: handler})
+}
+
+// Ack marks the event as fully processed
+func (s *FileStore) Ack(eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[eventID]; !ok {
+		return ErrRecordNotFound
+	}
+	return s.write(&journalEntry{Op: opAck, EventID: eventID})
+}
+
+// DeadLetter moves the record to the dead-letter file
+func (s *FileStore) DeadLetter(rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[rec.EventID]; !ok {
+		return ErrRecordNotFound
+	}
+	dead := rec.clone()
+	dead.State = RecordStateDead
+	if dead.DeadAt == 0 {
+		dead.DeadAt = time.Now().Unix()
+	}
+	if err := s.write(&journalEntry{Op: opDead, EventID: rec.EventID, Record: dead}); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.deadFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening dead-letter file: %v", err)
+	}
+	defer f.Close()
+	line, err := json.Marshal(dead)
+	if err != nil {
+		return fmt.Errorf("error marshaling dead letter: %v", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing dead-letter file: %v", err)
+	}
+	return f.Sync()
+}
+
+// Requeue moves the given dead-lettered events back to pending.
+// When no IDs are given every dead-lettered event is requeued.
+func (s *FileStore) Requeue(eventIDs ...string) ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(eventIDs) == 0 {
+		for id, rec := range s.records {
+			if rec.State == RecordStateDead {
+				eventIDs = append(eventIDs, id)
+			}
+		}
+		sort.Slice(eventIDs, func(i, j int) bool {
+			return s.order[eventIDs[i]] < s.order[eventIDs[j]]
+		})
+	}
+
+	var entries []*journalEntry
+	for _, id := range eventIDs {
+		rec, ok := s.records[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, id)
+		}
+		if rec.State != RecordStateDead {
+			continue
+		}
+		entries = append(entries, &journalEntry{Op: opRequeue, EventID: id})
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	if err := s.write(entries...); err != nil {
+		return nil, err
+	}
+	if err := s.writeDeadLetterFile(); err != nil {
+		return nil, err
+	}
+
+	requeued := make([]*Record, 0, len(entries))
+	for _, entry := range entries {
+		requeued = append(requeued, s.records[entry.EventID].clone())
+	}
+	return requeued, nil
+}
+
+// writeDeadLetterFile atomically rewrites the dead-letter file from the journal state.
+// Callers must hold s.mu or have exclusive access to the store.
+func (s *FileStore) writeDeadLetterFile() error {
+	var dead []*Record
+	for _, rec := range s.records {
+		if rec.State == RecordStateDead {
+			dead = append(dead, rec)
+		}
+	}
+	sort.Slice(dead, func(i, j int) bool {
+		return s.order[dead[i].EventID] < s.order[dead[j].EventID]
+	})
+	return writeRecordsAtomic(s.deadFile, dead)
+}
+
+// Compact rewrites the journal into a single segment containing only live records.
+// Pending and dead-lettered records are kept in full, acknowledged events are reduced to
+// tombstones for de-duplication and dropped entirely once older than AckRetention.
+//
+// Returns:
+//   - error: nil on success, error if the snapshot cannot be written
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	ids := make([]string, 0, len(s.records))
+	for id := range s.records {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return s.order[ids[i]] < s.order[ids[j]] })
+
+	cutoff := time.Now().Add(-s.opts.AckRetention).Unix()
+	var buf []byte
+	for _, id := range ids {
+		rec := s.records[id]
+		var entry *journalEntry
+		if rec.State == RecordStateAcked {
+			if s.ackedAt[id] < cutoff {
+				delete(s.records, id)
+				delete(s.order, id)
+				delete(s.ackedAt, id)
+				continue
+			}
+			entry = &journalEntry{Op: opAck, EventID: id, At: s.ackedAt[id]}
+		} else {
+			entry = &journalEntry{Op: opSnapshot, EventID: id, At: time.Now().Unix(), Record: rec}
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("error marshaling journal entry: %v", err)
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	old, err := s.segmentNumbers()
+	if err != nil {
+		return err
+	}
+	next := s.segNum + 1
+	tmp := s.segmentPath(next) + ".tmp"
+	if err := writeFileSync(tmp, buf); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.segmentPath(next)); err != nil {
+		return fmt.Errorf("error installing compacted segment: %v", err)
+	}
+	if err := s.openSegment(next); err != nil {
+		return err
+	}
+	for _, num := range old {
+		if num < next {
+			os.Remove(s.segmentPath(num))
+		}
+	}
+	return nil
+}
+
+// Close closes the current segment
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.segment != nil {
+		return s.segment.Close()
+	}
+	return nil
+}
+
+// writeRecordsAtomic writes records as JSONL to path via a temporary file and rename
+func writeRecordsAtomic(path string, records []*Record) error {
+	var buf []byte
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("error marshaling record: %v", err)
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, buf); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error replacing %s: %v", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// endsWithNewline reports whether the last byte of the file at path is a newline
+func endsWithNewline(path string, size int64) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, size-1); err != nil {
+		return true
+	}
+	return last[0] == '\n'
+}
+
+// writeFileSync writes data to path and fsyncs it before returning
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", filepath.Base(path), err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing %s: %v", filepath.Base(path), err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("error syncing %s: %v", filepath.Base(path), err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,470 @@
+// Package inbox provides a durable webhook inbox for MartianPay events.
+// Verified webhook payloads are persisted before MartianPay receives a 2xx response, and
+// are then processed asynchronously by worker goroutines. Each handler is retried with
+// backoff on failure, and events that exhaust their retries are moved to a dead-letter
+// file from which they can be requeued once the underlying problem is fixed.
+//
+// Example usage:
+//
+//	store, _ := inbox.OpenFileStore("/var/lib/myapp/webhooks", nil)
+//	ib := inbox.New(store, &inbox.Options{Secret: os.Getenv("MARTIANPAY_WEBHOOK_SECRET")})
+//	ib.HandleFunc("fulfill", fulfillOrder, developer.EventTypePaymentIntentSucceeded)
+//	http.Handle("/webhooks/martianpay", ib)
+//	go ib.Run(ctx)
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+const (
+	// DefaultWorkers is the number of worker goroutines used when Options.Workers is not set
+	DefaultWorkers = 4
+	// DefaultSweepInterval is how often pending records are rescanned when Options.SweepInterval is not set
+	DefaultSweepInterval = 5 * time.Second
+	// MaxPayloadBytes is the largest webhook body accepted by ServeHTTP
+	MaxPayloadBytes = 5 << 20
+)
+
+var (
+	// ErrUnverified is returned when a webhook payload fails signature verification
+	ErrUnverified = errors.New("inbox: webhook verification failed")
+	// ErrNoSecret is returned by Receive when the inbox was created without a signing secret
+	ErrNoSecret = errors.New("inbox: no webhook secret configured")
+)
+
+// HandlerFunc processes a single event. Returning an error schedules a retry,
+// unless the error is wrapped with Permanent.
+type HandlerFunc func(ctx context.Context, event *developer.Event) error
+
+// Handler is a named event handler with its own retry policy
+type Handler struct {
+	// Name identifies the handler; progress is tracked per name so it must be unique and stable across deploys
+	Name string
+	// Types restricts the handler to these event types; a trailing ".*" matches a whole family (e.g. "payout.*").
+	// An empty list matches every event.
+	Types []developer.EventType
+	// Func is the function invoked for each matching event
+	Func HandlerFunc
+	// Retry is the retry policy for this handler (zero value uses DefaultRetryPolicy)
+	Retry RetryPolicy
+}
+
+// Matches reports whether the handler should receive events of the given type
+func (h *Handler) Matches(eventType developer.EventType) bool {
+	if len(h.Types) == 0 {
+		return true
+	}
+	for _, t := range h.Types {
+		if eventType.Matches(string(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Options configures an Inbox
+type Options struct {
+	// Secret is the webhook signing secret used by Receive and ServeHTTP
+	Secret string
+	// Workers is the number of concurrent worker goroutines (default DefaultWorkers)
+	Workers int
+	// SweepInterval is how often the store is rescanned for pending records (default DefaultSweepInterval)
+	SweepInterval time.Duration
+	// OnDeadLetter is called after an event has been moved to the dead-letter file
+	OnDeadLetter func(rec *Record)
+	// OnError is called when the store fails while events are being swept, acked or dead-lettered.
+	// The affected records stay pending and are retried by a later sweep.
+	OnError func(err error)
+}
+
+// Inbox persists verified webhook events and dispatches them to handlers
+type Inbox struct {
+	store Store
+	opts  Options
+
+	mu       sync.RWMutex
+	handlers []*Handler
+	inflight map[string]bool
+	queue    chan string
+}
+
+// New creates an inbox backed by the given store.
+//
+// Parameters:
+//   - store: Store used to persist events and their processing state
+//   - opts: Optional settings, nil for defaults (Receive and ServeHTTP require Secret)
+//
+// Returns:
+//   - *Inbox: The inbox; call Run to start processing
+func New(store Store, opts *Options) *Inbox {
+	in := &Inbox{
+		store:    store,
+		inflight: make(map[string]bool),
+	}
+	if opts != nil {
+		in.opts = *opts
+	}
+	if in.opts.Workers <= 0 {
+		in.opts.Workers = DefaultWorkers
+	}
+	if in.opts.SweepInterval <= 0 {
+		in.opts.SweepInterval = DefaultSweepInterval
+	}
+	in.queue = make(chan string, in.opts.Workers*64)
+	return in
+}
+
+// Handle registers a handler.
+//
+// Returns:
+//   - error: nil on success, error if the handler has no name or function, or the name is already taken
+func (in *Inbox) Handle(h Handler) error {
+	if strings.TrimSpace(h.Name) == "" {
+		return errors.New("inbox: handler name is required")
+	}
+	if h.Func == nil {
+		return fmt.Errorf("inbox: handler %q has no function", h.Name)
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	for _, existing := range in.handlers {
+		if existing.Name == h.Name {
+			return fmt.Errorf("inbox: handler %q is already registered", h.Name)
+		}
+	}
+	h.Retry = h.Retry.withDefaults()
+	in.handlers = append(in.handlers, &h)
+	return nil
+}
+
+// HandleFunc registers fn under name for the given event types with the default retry policy
+func (in *Inbox) HandleFunc(name string, fn HandlerFunc, types ...developer.EventType) error {
+	return in.Handle(Handler{Name: name, Types: types, Func: fn})
+}
+
+// handlersFor returns the handlers matching the given event type
+func (in *Inbox) handlersFor(eventType developer.EventType) []*Handler {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+
+	var matched []*Handler
+	for _, h := range in.handlers {
+		if h.Matches(eventType) {
+			matched = append(matched, h)
+		}
+	}
+	return matched
+}
+
+// Receive verifies a webhook payload and persists it for asynchronous processing.
+// Duplicate deliveries of an already stored event are accepted without being stored again.
+//
+// Parameters:
+//   - payload: The raw request body
+//   - sigHeader: The value of the Martian-Pay-Signature header
+//
+// Returns:
+//   - *developer.Event: The verified event
+//   - bool: true if the event was newly stored, false for a duplicate delivery
+//   - error: ErrUnverified (wrapping the cause) if verification fails, or a store error
+func (in *Inbox) Receive(payload []byte, sigHeader string) (*developer.Event, bool, error) {
+	if in.opts.Secret == "" {
+		return nil, false, ErrNoSecret
+	}
+	event, err := developer.ConstructEvent(payload, sigHeader, in.opts.Secret)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrUnverified, err)
+	}
+	stored, err := in.store.Append(&Record{
+		EventID:    event.ID,
+		Type:       event.Type,
+		Payload:    append(json.RawMessage(nil), payload...),
+		ReceivedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if stored {
+		in.schedule(event.ID)
+	}
+	return &event, stored, nil
+}
+
+// Enqueue persists an event that was obtained from a trusted source, such as the Events API,
+// and schedules it for processing. Events already known to the store are ignored.
+//
+// Returns:
+//   - bool: true if the event was newly stored
+//   - error: nil on success, error if the event cannot be encoded or stored
+func (in *Inbox) Enqueue(event *developer.Event) (bool, error) {
+	if event == nil || event.ID == "" {
+		return false, errors.New("inbox: event must have an ID")
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return false, fmt.Errorf("error marshaling event: %v", err)
+	}
+	stored, err := in.store.Append(&Record{
+		EventID:    event.ID,
+		Type:       event.Type,
+		Payload:    payload,
+		ReceivedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return false, err
+	}
+	if stored {
+		in.schedule(event.ID)
+	}
+	return stored, nil
+}
+
+// ServeHTTP implements http.Handler. It responds 200 as soon as the verified event is
+// persisted, 400 if verification fails and 500 if the event cannot be stored, in which
+// case MartianPay will retry the delivery.
+func (in *Inbox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxPayloadBytes))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	if _, _, err := in.Receive(body, r.Header.Get(developer.MartianPaySignature)); err != nil {
+		if errors.Is(err, ErrUnverified) {
+			writeJSON(w, http.StatusBadRequest, "error verifying webhook signature")
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, "failed to store webhook event")
+		return
+	}
+	writeJSON(w, http.StatusOK, "success")
+}
+
+// writeJSON writes the standard webhook acknowledgement body
+func writeJSON(w http.ResponseWriter, status int, msg string) {
+	code := 0
+	if status != http.StatusOK {
+		code = status
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"code": code, "msg": msg})
+}
+
+// Run starts the worker goroutines and blocks until ctx is cancelled.
+// Events whose processing is interrupted by shutdown stay pending and are
+// picked up again by the next Run, so it is safe to stop the process at any time.
+//
+// Returns:
+//   - error: nil after a clean shutdown, or the error from the initial store scan
+func (in *Inbox) Run(ctx context.Context) error {
+	if err := in.sweep(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < in.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			in.work(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(in.opts.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil
+		case <-ticker.C:
+			// The next tick retries a failed scan
+			if err := in.sweep(); err != nil {
+				in.reportError(fmt.Errorf("error sweeping pending events: %w", err))
+			}
+		}
+	}
+}
+
+// sweep schedules every pending record that is not already queued or being processed
+func (in *Inbox) sweep() error {
+	pending, err := in.store.Pending()
+	if err != nil {
+		return err
+	}
+	for _, rec := range pending {
+		in.schedule(rec.EventID)
+	}
+	return nil
+}
+
+// schedule queues an event for processing unless it is already in flight.
+// When the queue is full the event is left for the next sweep.
+func (in *Inbox) schedule(eventID string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if in.inflight[eventID] {
+		return
+	}
+	select {
+	case in.queue <- eventID:
+		in.inflight[eventID] = true
+	default:
+	}
+}
+
+// work processes queued events until ctx is cancelled
+func (in *Inbox) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case eventID := <-in.queue:
+			in.process(ctx, eventID)
+			in.mu.Lock()
+			delete(in.inflight, eventID)
+			in.mu.Unlock()
+		}
+	}
+}
+
+// process runs every matching handler for one event and acks or dead-letters it
+func (in *Inbox) process(ctx context.Context, eventID string) {
+	rec, err := in.store.Get(eventID)
+	if err != nil {
+		in.reportError(fmt.Errorf("error loading event %s: %w", eventID, err))
+		return
+	}
+	if rec.State != RecordStatePending {
+		return
+	}
+
+	event, err := rec.Event()
+	if err != nil {
+		rec.LastError = fmt.Sprintf("error decoding event: %v", err)
+		in.deadLetter(rec)
+		return
+	}
+
+	var failed []string
+	for _, h := range in.handlersFor(event.Type) {
+		if rec.HasCompleted(h.Name) {
+			continue
+		}
+		err := in.runHandler(ctx, h, event, rec)
+		if ctx.Err() != nil {
+			// Shutting down: leave the record pending for the next run
+			return
+		}
+		if err != nil {
+			failed = append(failed, h.Name)
+			rec.LastError = fmt.Sprintf("%s: %v", h.Name, err)
+			continue
+		}
+		if err := in.store.Complete(rec.EventID, h.Name); err != nil {
+			in.reportError(fmt.Errorf("error recording handler %s for event %s: %w", h.Name, rec.EventID, err))
+			return
+		}
+		rec.Completed = append(rec.Completed, h.Name)
+	}
+
+	if len(failed) > 0 {
+		rec.FailedHandler = strings.Join(failed, ",")
+		in.deadLetter(rec)
+		return
+	}
+	if err := in.store.Ack(rec.EventID); err != nil {
+		in.reportError(fmt.Errorf("error acking event %s: %w", rec.EventID, err))
+	}
+}
+
+// runHandler invokes a handler with retries according to its policy
+func (in *Inbox) runHandler(ctx context.Context, h *Handler, event *developer.Event, rec *Record) error {
+	var err error
+	for attempt := 1; attempt <= h.Retry.MaxAttempts; attempt++ {
+		rec.Attempts++
+		if err = safeCall(ctx, h.Func, event); err == nil {
+			return nil
+		}
+		if IsPermanent(err) || attempt == h.Retry.MaxAttempts {
+			break
+		}
+		timer := time.NewTimer(h.Retry.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return err
+}
+
+// safeCall invokes fn and converts a panic into an error
+func safeCall(ctx context.Context, fn HandlerFunc, event *developer.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	return fn(ctx, event)
+}
+
+// deadLetter moves a record to the dead-letter file and notifies OnDeadLetter
+func (in *Inbox) deadLetter(rec *Record) {
+	if err := in.store.DeadLetter(rec); err != nil {
+		in.reportError(fmt.Errorf("error dead-lettering event %s: %w", rec.EventID, err))
+		return
+	}
+	if in.opts.OnDeadLetter != nil {
+		in.opts.OnDeadLetter(rec)
+	}
+}
+
+// reportError passes a store error to OnError, if set
+func (in *Inbox) reportError(err error) {
+	if in.opts.OnError != nil {
+		in.opts.OnError(err)
+	}
+}
+
+// DeadLetters returns the events currently in the dead-letter file
+func (in *Inbox) DeadLetters() ([]*Record, error) {
+	return in.store.DeadLetters()
+}
+
+// Requeue feeds dead-lettered events back into the inbox. Handlers that already
+// succeeded for an event are not run again. When no IDs are given every
+// dead-lettered event is requeued.
+//
+// Returns:
+//   - int: The number of events requeued
+//   - error: nil on success, error if an ID is unknown or the store fails
+func (in *Inbox) Requeue(eventIDs ...string) (int, error) {
+	requeued, err := in.store.Requeue(eventIDs...)
+	if err != nil {
+		return 0, err
+	}
+	for _, rec := range requeued {
+		in.schedule(rec.EventID)
+	}
+	return len(requeued), nil
+}
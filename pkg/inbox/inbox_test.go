@@ -0,0 +1,307 @@
+// inbox_test.go contains unit tests for the durable webhook inbox and its file store.
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "whsec_test_inbox"
+
+func signedEvent(t *testing.T, id string, eventType developer.EventType) ([]byte, string) {
+	event := developer.Event{
+		ID:      id,
+		Object:  developer.EventObject,
+		Created: time.Now().Unix(),
+		Type:    eventType,
+		Data:    &developer.EventData{Raw: []byte(`{"id":"pi_123","object":"payment_intent"}`)},
+	}
+	payload, sig, err := developer.GetPayloadAndSignature(&event, testSecret)
+	require.NoError(t, err)
+	return payload, sig
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
+
+func TestInboxProcessesAndDeduplicates(t *testing.T) {
+	store, err := OpenFileStore(t.TempDir(), nil)
+	require.NoError(t, err)
+	defer store.Close()
+
+	ib := New(store, &Options{Secret: testSecret, SweepInterval: 20 * time.Millisecond})
+	var mu sync.Mutex
+	var seen []string
+	require.NoError(t, ib.HandleFunc("record", func(ctx context.Context, event *developer.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, event.ID)
+		return nil
+	}, "payment_intent.*"))
+
+	payload, sig := signedEvent(t, "evt_1", developer.EventTypePaymentIntentSucceeded)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(string(payload)))
+	req.Header.Set(developer.MartianPaySignature, sig)
+	rec := httptest.NewRecorder()
+	ib.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// A redelivery of the same event is accepted but not stored twice
+	_, stored, err := ib.Receive(payload, sig)
+	require.NoError(t, err)
+	assert.False(t, stored)
+
+	// A bad signature is rejected
+	_, _, err = ib.Receive(payload, "t=1,v1=00")
+	assert.ErrorIs(t, err, ErrUnverified)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ib.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, func() bool {
+		r, err := store.Get("evt_1")
+		return err == nil && r.State == RecordStateAcked
+	})
+	cancel()
+	<-done
+
+	mu.Lock()
+	assert.Equal(t, []string{"evt_1"}, seen)
+	mu.Unlock()
+}
+
+func TestInboxDeadLetterAndRequeue(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenFileStore(dir, nil)
+	require.NoError(t, err)
+
+	ib := New(store, &Options{Secret: testSecret, SweepInterval: 20 * time.Millisecond})
+	var mu sync.Mutex
+	calls := map[string]int{}
+	broken := true
+	retry := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	require.NoError(t, ib.Handle(Handler{Name: "ok", Retry: retry, Func: func(ctx context.Context, event *developer.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls["ok"]++
+		return nil
+	}}))
+	require.NoError(t, ib.Handle(Handler{Name: "flaky", Retry: retry, Func: func(ctx context.Context, event *developer.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls["flaky"]++
+		if broken {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	}}))
+
+	payload, sig := signedEvent(t, "evt_2", developer.EventTypePayoutFailed)
+	_, _, err = ib.Receive(payload, sig)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ib.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, func() bool {
+		dead, _ := ib.DeadLetters()
+		return len(dead) == 1
+	})
+	dead, _ := ib.DeadLetters()
+	assert.Equal(t, "flaky", dead[0].FailedHandler)
+	assert.Equal(t, []string{"ok"}, dead[0].Completed)
+	mu.Lock()
+	assert.Equal(t, 1, calls["ok"])
+	assert.Equal(t, 3, calls["flaky"])
+	broken = false
+	mu.Unlock()
+	cancel()
+	<-done
+	require.NoError(t, store.Close())
+
+	// Dead letters survive a restart and only the failed handler runs again
+	store, err = OpenFileStore(dir, nil)
+	require.NoError(t, err)
+	defer store.Close()
+	dead, err = store.DeadLetters()
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+
+	ib2 := New(store, &Options{Secret: testSecret, SweepInterval: 20 * time.Millisecond})
+	ib2.handlers = ib.handlers
+	n, err := ib2.Requeue()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	done = make(chan struct{})
+	go func() {
+		ib2.Run(ctx)
+		close(done)
+	}()
+	waitFor(t, func() bool {
+		r, err := store.Get("evt_2")
+		return err == nil && r.State == RecordStateAcked
+	})
+	cancel()
+	<-done
+
+	mu.Lock()
+	assert.Equal(t, 1, calls["ok"])
+	assert.Equal(t, 4, calls["flaky"])
+	mu.Unlock()
+
+	// Compaction keeps the acknowledged ID for de-duplication
+	require.NoError(t, store.Compact())
+	has, err := store.Has("evt_2")
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+// failingAckStore is a store whose Ack always fails
+type failingAckStore struct {
+	Store
+}
+
+func (s failingAckStore) Ack(eventID string) error {
+	return errors.New("journal is read-only")
+}
+
+func TestInboxReportsStoreErrors(t *testing.T) {
+	store, err := OpenFileStore(t.TempDir(), nil)
+	require.NoError(t, err)
+	defer store.Close()
+
+	errs := make(chan error, 16)
+	ib := New(failingAckStore{store}, &Options{
+		Secret:        testSecret,
+		SweepInterval: time.Hour,
+		OnError:       func(err error) { errs <- err },
+	})
+	require.NoError(t, ib.HandleFunc("noop", func(ctx context.Context, event *developer.Event) error {
+		return nil
+	}))
+	payload, sig := signedEvent(t, "evt_3", developer.EventTypePaymentIntentSucceeded)
+	_, _, err = ib.Receive(payload, sig)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ib.Run(ctx)
+
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "error acking event evt_3")
+		assert.ErrorContains(t, err, "journal is read-only")
+	case <-time.After(5 * time.Second):
+		t.Fatal("store error was not reported")
+	}
+
+	// The handler's progress is kept and the event stays pending for the next sweep
+	rec, err := store.Get("evt_3")
+	require.NoError(t, err)
+	assert.Equal(t, RecordStatePending, rec.State)
+	assert.Equal(t, []string{"noop"}, rec.Completed)
+}
+
+// fakeEventLog serves a fixed event log, newest first, like the Events API
+type fakeEventLog struct {
+	events []*developer.Event
+}
+
+func (f *fakeEventLog) ListEvents(req *developer.EventListRequest) (*developer.EventListResp, error) {
+	var matched []*developer.Event
+	for i := len(f.events) - 1; i >= 0; i-- {
+		e := f.events[i]
+		if e.Created >= *req.CreatedGte && e.Created <= *req.CreatedLte {
+			matched = append(matched, e)
+		}
+	}
+	start := int(req.Page * req.PageSize)
+	end := start + int(req.PageSize)
+	if start > len(matched) {
+		start = len(matched)
+	}
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return &developer.EventListResp{Events: matched[start:end], Total: int64(len(matched)), Page: req.Page, PageSize: req.PageSize}, nil
+}
+
+func TestPollerResumesAndReconcilesGaps(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenFileStore(dir, nil)
+	require.NoError(t, err)
+	defer store.Close()
+	ib := New(store, &Options{Secret: testSecret})
+
+	now := time.Now().Unix()
+	log := &fakeEventLog{}
+	for i := 0; i < 60; i++ {
+		log.events = append(log.events, &developer.Event{
+			ID:      fmt.Sprintf("evt_%02d", i),
+			Object:  developer.EventObject,
+			Created: now - 120 + int64(i),
+			Type:    developer.EventTypePayoutSucceeded,
+			Data:    &developer.EventData{Raw: []byte(`{"id":"payout_1"}`)},
+		})
+	}
+
+	// One event arrived by webhook before the poller ran
+	payload, sig := signedEvent(t, "evt_10", developer.EventTypePayoutSucceeded)
+	_, _, err = ib.Receive(payload, sig)
+	require.NoError(t, err)
+
+	cursors := &FileCursorStore{Path: filepath.Join(dir, "cursor.json")}
+	poller := NewPoller(log, ib, cursors, nil)
+	n, err := poller.Poll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 59, n)
+
+	cursor, err := cursors.LoadCursor()
+	require.NoError(t, err)
+	assert.Equal(t, now-61, cursor.Created)
+	assert.Equal(t, []string{"evt_59"}, cursor.EventIDs)
+
+	// A later poll only reads new events
+	log.events = append(log.events, &developer.Event{ID: "evt_60", Created: now - 61, Type: developer.EventTypePayoutFailed})
+	n, err = poller.Poll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// Events that reached neither the webhook nor the poller are backfilled
+	log.events = append(log.events, &developer.Event{ID: "evt_late", Created: now - 200, Type: developer.EventTypePayoutFailed})
+	result, err := poller.Reconcile(context.Background(), time.Unix(now-300, 0), time.Unix(now, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 62, result.Checked)
+	assert.Equal(t, []string{"evt_late"}, result.Backfilled)
+}
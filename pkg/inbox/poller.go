@@ -0,0 +1,290 @@
+// poller.go contains a polling fallback that reads the MartianPay event log and feeds
+// events into the inbox, so handlers still run when webhook deliveries are missed.
+// The poller resumes from a persisted cursor, and Reconcile backfills any events in a
+// time range that never reached the inbox.
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+const (
+	// DefaultPollInterval is how often the event log is polled when PollerOptions.Interval is not set
+	DefaultPollInterval = time.Minute
+	// DefaultPollLookback is how far back the first poll reads when no cursor has been saved
+	DefaultPollLookback = time.Hour
+	// eventPageSize is the page size used when listing events
+	eventPageSize = 50
+)
+
+// EventLister lists events from the event log; *martianpay.Client implements it
+type EventLister interface {
+	ListEvents(req *developer.EventListRequest) (*developer.EventListResp, error)
+}
+
+// Cursor records how far the poller has read the event log
+type Cursor struct {
+	// Created is the creation time of the newest event read so far
+	Created int64 `json:"created"`
+	// EventIDs are the IDs of the events read with that creation time, which are skipped on the next poll
+	EventIDs []string `json:"event_ids"`
+}
+
+// CursorStore persists the poller cursor between runs
+type CursorStore interface {
+	// LoadCursor returns the saved cursor, or nil if none has been saved
+	LoadCursor() (*Cursor, error)
+	// SaveCursor persists the cursor
+	SaveCursor(c *Cursor) error
+}
+
+// FileCursorStore is a CursorStore that keeps the cursor in a JSON file
+type FileCursorStore struct {
+	// Path is the cursor file; it is replaced atomically on every save
+	Path string
+}
+
+// LoadCursor reads the cursor file, returning nil if it does not exist yet
+func (s *FileCursorStore) LoadCursor() (*Cursor, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cursor: %v", err)
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("error parsing cursor %s: %v", s.Path, err)
+	}
+	return &c, nil
+}
+
+// SaveCursor writes the cursor file through a temporary file and rename
+func (s *FileCursorStore) SaveCursor(c *Cursor) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("error marshaling cursor: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return fmt.Errorf("error creating cursor directory: %v", err)
+	}
+	tmp := s.Path + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		return fmt.Errorf("error replacing cursor: %v", err)
+	}
+	return nil
+}
+
+// PollerOptions configures a Poller
+type PollerOptions struct {
+	// Interval is the time between polls (default DefaultPollInterval)
+	Interval time.Duration
+	// Lookback is how far back the first poll reads when no cursor is saved (default DefaultPollLookback)
+	Lookback time.Duration
+	// Types restricts polling to these event types; a trailing ".*" matches a whole family.
+	// An empty list polls every event.
+	Types []developer.EventType
+	// OnError is called when a poll fails; the next poll retries from the same cursor
+	OnError func(err error)
+}
+
+// Poller reads the event log and enqueues events into an Inbox
+type Poller struct {
+	events  EventLister
+	inbox   *Inbox
+	cursors CursorStore
+	opts    PollerOptions
+	now     func() time.Time
+}
+
+// NewPoller creates a poller that feeds events into ib.
+//
+// Parameters:
+//   - events: Source of the event log, typically a *martianpay.Client
+//   - ib: Inbox whose handlers process the polled events
+//   - cursors: Store for the poll cursor, so polling resumes where it stopped
+//   - opts: Optional settings, nil for defaults
+//
+// Returns:
+//   - *Poller: The poller; call Run to poll periodically or Poll for a single pass
+func NewPoller(events EventLister, ib *Inbox, cursors CursorStore, opts *PollerOptions) *Poller {
+	p := &Poller{events: events, inbox: ib, cursors: cursors, now: time.Now}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.Interval <= 0 {
+		p.opts.Interval = DefaultPollInterval
+	}
+	if p.opts.Lookback <= 0 {
+		p.opts.Lookback = DefaultPollLookback
+	}
+	return p
+}
+
+// Run polls until ctx is cancelled. Poll errors are reported to OnError and retried on the next tick.
+func (p *Poller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := p.Poll(ctx); err != nil && p.opts.OnError != nil {
+			p.opts.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll reads every event created since the cursor, enqueues it, and advances the cursor.
+// Events already in the inbox (for example, delivered by webhook) are skipped by the store.
+//
+// Returns:
+//   - int: Number of events newly stored in the inbox
+//   - error: nil on success, error if listing, storing, or saving the cursor fails
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	cursor, err := p.cursors.LoadCursor()
+	if err != nil {
+		return 0, err
+	}
+	if cursor == nil {
+		cursor = &Cursor{Created: p.now().Add(-p.opts.Lookback).Unix()}
+	}
+	skip := make(map[string]bool, len(cursor.EventIDs))
+	for _, id := range cursor.EventIDs {
+		skip[id] = true
+	}
+
+	events, err := p.list(ctx, cursor.Created, p.now().Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	stored := 0
+	next := &Cursor{Created: cursor.Created, EventIDs: cursor.EventIDs}
+	for _, event := range events {
+		if skip[event.ID] {
+			continue
+		}
+		ok, err := p.inbox.Enqueue(event)
+		if err != nil {
+			// Save progress so far; the failed event is retried on the next poll
+			if saveErr := p.cursors.SaveCursor(next); saveErr != nil {
+				return stored, saveErr
+			}
+			return stored, err
+		}
+		if ok {
+			stored++
+		}
+		if event.Created > next.Created {
+			next = &Cursor{Created: event.Created}
+		}
+		next.EventIDs = append(next.EventIDs, event.ID)
+	}
+	return stored, p.cursors.SaveCursor(next)
+}
+
+// ReconcileResult summarizes a Reconcile pass
+type ReconcileResult struct {
+	// Checked is the number of events in the event log for the range
+	Checked int
+	// Backfilled are the IDs of events that were missing from the inbox and have been enqueued
+	Backfilled []string
+}
+
+// Reconcile compares the event log for a time range with the events the inbox has received,
+// and enqueues every event that is missing. It does not move the poll cursor.
+//
+// Parameters:
+//   - ctx: Context for cancellation between pages
+//   - from: Start of the range (inclusive)
+//   - to: End of the range (inclusive)
+//
+// Returns:
+//   - *ReconcileResult: Events checked and backfilled
+//   - error: nil on success, error if listing or storing fails
+func (p *Poller) Reconcile(ctx context.Context, from, to time.Time) (*ReconcileResult, error) {
+	events, err := p.list(ctx, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	result := &ReconcileResult{Checked: len(events), Backfilled: []string{}}
+	for _, event := range events {
+		has, err := p.inbox.store.Has(event.ID)
+		if err != nil {
+			return result, err
+		}
+		if has {
+			continue
+		}
+		ok, err := p.inbox.Enqueue(event)
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			result.Backfilled = append(result.Backfilled, event.ID)
+		}
+	}
+	return result, nil
+}
+
+// list returns every event created in [from, to] that matches the type filter, oldest first
+func (p *Poller) list(ctx context.Context, from, to int64) ([]*developer.Event, error) {
+	var events []*developer.Event
+	req := &developer.EventListRequest{
+		PageSize:   eventPageSize,
+		CreatedGte: &from,
+		CreatedLte: &to,
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := p.events.ListEvents(req)
+		if err != nil {
+			return nil, fmt.Errorf("error listing events: %v", err)
+		}
+		for _, event := range resp.Events {
+			if p.wants(event.Type) {
+				events = append(events, event)
+			}
+		}
+		seen := int64(req.Page+1) * int64(req.PageSize)
+		if len(resp.Events) < int(req.PageSize) || seen >= resp.Total {
+			break
+		}
+		req.Page++
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Created < events[j].Created
+	})
+	return events, nil
+}
+
+// wants reports whether the poller is configured to handle the event type
+func (p *Poller) wants(t developer.EventType) bool {
+	if len(p.opts.Types) == 0 {
+		return true
+	}
+	for _, pattern := range p.opts.Types {
+		if t.Matches(string(pattern)) {
+			return true
+		}
+	}
+	return false
+}
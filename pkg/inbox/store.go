@@ -0,0 +1,108 @@
+// store.go contains the persistence contract used by the webhook inbox.
+// A store keeps verified event payloads together with their processing state
+// so that events survive restarts between being acknowledged and being handled.
+package inbox
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// RecordState describes where a record is in the inbox lifecycle
+type RecordState string
+
+const (
+	// RecordStatePending indicates the event is stored and waiting to be handled
+	RecordStatePending RecordState = "pending"
+	// RecordStateAcked indicates every handler has processed the event successfully
+	RecordStateAcked RecordState = "acked"
+	// RecordStateDead indicates the event exhausted its retries and was moved to the dead-letter file
+	RecordStateDead RecordState = "dead"
+)
+
+var (
+	// ErrRecordNotFound is returned when a store has no record for the given event ID
+	ErrRecordNotFound = errors.New("inbox: record not found")
+	// ErrStoreClosed is returned when a store is used after Close
+	ErrStoreClosed = errors.New("inbox: store is closed")
+)
+
+// Record is a persisted webhook event together with its processing state
+type Record struct {
+	// EventID is the ID of the MartianPay event, used for de-duplication
+	EventID string `json:"event_id"`
+	// Type is the event type, kept alongside the payload for routing and filtering
+	Type developer.EventType `json:"type"`
+	// Payload is the verified raw event body exactly as it was received
+	Payload json.RawMessage `json:"payload"`
+	// ReceivedAt is the Unix timestamp when the event was first stored
+	ReceivedAt int64 `json:"received_at"`
+	// State is the current lifecycle state of the record
+	State RecordState `json:"state"`
+	// Completed lists the handlers that have already processed this event successfully
+	Completed []string `json:"completed,omitempty"`
+	// Attempts is the total number of handler attempts made for this event
+	Attempts int `json:"attempts,omitempty"`
+	// FailedHandler is the name of the handler that caused the event to be dead-lettered
+	FailedHandler string `json:"failed_handler,omitempty"`
+	// LastError is the last error returned by a handler for this event
+	LastError string `json:"last_error,omitempty"`
+	// DeadAt is the Unix timestamp when the event was moved to the dead-letter file
+	DeadAt int64 `json:"dead_at,omitempty"`
+}
+
+// Event decodes the stored payload into a developer.Event
+func (r *Record) Event() (*developer.Event, error) {
+	var event developer.Event
+	if err := json.Unmarshal(r.Payload, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// HasCompleted reports whether the named handler already processed this event
+func (r *Record) HasCompleted(handler string) bool {
+	for _, name := range r.Completed {
+		if name == handler {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a deep copy of the record so callers cannot mutate store state
+func (r *Record) clone() *Record {
+	c := *r
+	c.Payload = append(json.RawMessage(nil), r.Payload...)
+	c.Completed = append([]string(nil), r.Completed...)
+	return &c
+}
+
+// Store persists inbox records.
+// Implementations must be safe for concurrent use by multiple goroutines.
+type Store interface {
+	// Append stores a new pending record. It returns false without error when
+	// a record with the same event ID is already known to the store.
+	Append(rec *Record) (bool, error)
+	// Pending returns all pending records, oldest first
+	Pending() ([]*Record, error)
+	// Get returns the record for the given event ID
+	Get(eventID string) (*Record, error)
+	// Has reports whether the store has seen the given event ID in any state
+	Has(eventID string) (bool, error)
+	// Complete records that the named handler processed the event successfully
+	Complete(eventID string, handler string) error
+	// Ack marks the event as fully processed
+	Ack(eventID string) error
+	// DeadLetter moves the record to the dead-letter file
+	DeadLetter(rec *Record) error
+	// DeadLetters returns all dead-lettered records, oldest first
+	DeadLetters() ([]*Record, error)
+	// Requeue moves the given dead-lettered events back to pending.
+	// When no IDs are given every dead-lettered event is requeued.
+	Requeue(eventIDs ...string) ([]*Record, error)
+	// Close releases any resources held by the store
+	Close() error
+}
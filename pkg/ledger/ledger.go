@@ -0,0 +1,225 @@
+// Package ledger credits deposits to permanent deposit addresses to local user balances.
+// Each deposit is credited exactly once, no matter how often it is seen through webhooks,
+// polling, or list calls, so the ledger can be fed from any of them. A deposit is identified by
+// its transaction hash, deposit address and asset, because one transaction (a batched exchange
+// withdrawal or a multi-output UTXO transaction) can pay several deposit addresses.
+//
+// Example usage:
+//
+//	store, _ := ledger.OpenFileStore("/var/lib/myapp/ledger.jsonl")
+//	l := ledger.New(store)
+//	accounts, _ := client.ListUserDepositAccounts(userID)
+//	for _, account := range accounts {
+//		credited, _ := l.CreditAccount(account)
+//		for _, entry := range credited {
+//			notifyUser(entry)
+//		}
+//	}
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrStoreClosed is returned when a store is used after Close
+	ErrStoreClosed = errors.New("ledger: store is closed")
+	// ErrNotCredited is returned when a transaction is not a confirmed, AML-approved deposit
+	ErrNotCredited = errors.New("ledger: transaction is not a credited deposit")
+)
+
+// Entry is a deposit credited to a user's balance
+type Entry struct {
+	// TxHash is the normalized transaction hash; with DepositAddress and AssetId it makes crediting idempotent
+	TxHash string `json:"tx_hash"`
+	// UserId is the user whose balance was credited
+	UserId string `json:"user_id"`
+	// AssetId is the deposited asset
+	AssetId string `json:"asset_id"`
+	// Amount is the deposited amount in whole token units
+	Amount decimal.Decimal `json:"amount"`
+	// DepositAddress is the permanent deposit address that received the funds
+	DepositAddress string `json:"deposit_address,omitempty"`
+	// CreditedAt is the Unix timestamp when the entry was recorded
+	CreditedAt int64 `json:"credited_at"`
+}
+
+// key identifies the deposit an entry credits
+func (e *Entry) key() string {
+	return e.TxHash + "/" + NormalizeTxHash(e.DepositAddress) + "/" + e.AssetId
+}
+
+// Store persists ledger entries.
+// Implementations must be safe for concurrent use by multiple goroutines.
+type Store interface {
+	// Credit records an entry. It returns false without error when an entry with the
+	// same transaction hash, deposit address and asset already exists.
+	Credit(entry *Entry) (bool, error)
+	// Has reports whether a transaction hash has been credited to any deposit address
+	Has(txHash string) (bool, error)
+	// Entries returns the entries of a user, oldest first
+	Entries(userID string) ([]*Entry, error)
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// Ledger credits deposits to users through a Store
+type Ledger struct {
+	store Store
+	now   func() time.Time
+}
+
+// New creates a ledger backed by the given store
+func New(store Store) *Ledger {
+	return &Ledger{store: store, now: time.Now}
+}
+
+// NormalizeTxHash returns the form of a transaction hash used as the ledger key.
+// Hex hashes (EVM chains) are case-insensitive and are lowercased with a 0x prefix;
+// other encodings, such as base58 on Solana, are case-sensitive and kept as they are.
+// Deposit addresses in the key are normalized the same way.
+func NormalizeTxHash(hash string) string {
+	hash = strings.TrimSpace(hash)
+	body := strings.TrimPrefix(strings.TrimPrefix(hash, "0x"), "0X")
+	if body != hash && isHex(body) {
+		return "0x" + strings.ToLower(body)
+	}
+	return hash
+}
+
+// isHex reports whether s is a non-empty string of hexadecimal digits
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// CreditTransaction credits a deposit to a user's balance unless the same transaction was
+// already credited to the same deposit address and asset.
+//
+// Parameters:
+//   - userID: The user who owns the deposit address
+//   - account: The deposit account that received the transaction; its address and asset are recorded
+//     when the transaction does not carry them
+//   - tx: The deposit transaction
+//
+// Returns:
+//   - *Entry: The new entry, or nil if the deposit was already credited
+//   - error: ErrNotCredited if the transaction is not a confirmed, AML-approved deposit, an error if
+//     its deposit address or asset is unknown, or a store error
+func (l *Ledger) CreditTransaction(userID string, account *developer.DepositAccount, tx *developer.Transaction) (*Entry, error) {
+	if userID == "" {
+		return nil, errors.New("ledger: user ID is required")
+	}
+	if tx == nil || !tx.IsCredited() {
+		return nil, ErrNotCredited
+	}
+	hash := NormalizeTxHash(tx.TxHash)
+	if hash == "" {
+		return nil, errors.New("ledger: transaction has no hash")
+	}
+	amount, err := decimal.NewFromString(tx.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing amount of transaction %s: %v", hash, err)
+	}
+	if amount.Sign() <= 0 {
+		return nil, fmt.Errorf("ledger: transaction %s has non-positive amount %s", hash, tx.Amount)
+	}
+	entry := &Entry{
+		TxHash:         hash,
+		UserId:         userID,
+		AssetId:        tx.AssetId,
+		Amount:         amount,
+		DepositAddress: tx.DestinationAddress,
+		CreditedAt:     l.now().Unix(),
+	}
+	if account != nil {
+		if entry.DepositAddress == "" {
+			entry.DepositAddress = account.DepositAddress
+		}
+		if entry.AssetId == "" {
+			entry.AssetId = account.AssetId
+		}
+	}
+	// Without them the same transaction could later be credited again under another key
+	if entry.DepositAddress == "" || entry.AssetId == "" {
+		return nil, fmt.Errorf("ledger: deposit address and asset of transaction %s are required", hash)
+	}
+	ok, err := l.store.Credit(entry)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// CreditAccount credits every confirmed, AML-approved deposit of an account that has not been credited yet.
+// Pending deposits are skipped and will be credited by a later call once they are confirmed.
+//
+// Parameters:
+//   - account: A deposit account with its transactions; UserId identifies the user to credit
+//
+// Returns:
+//   - []*Entry: The entries credited by this call
+//   - error: Error if a transaction cannot be credited; entries credited before the error are returned
+func (l *Ledger) CreditAccount(account *developer.DepositAccount) ([]*Entry, error) {
+	if account == nil {
+		return nil, nil
+	}
+	var credited []*Entry
+	for _, tx := range account.Transactions {
+		if tx == nil || !tx.IsCredited() {
+			continue
+		}
+		entry, err := l.CreditTransaction(account.UserId, account, tx)
+		if err != nil {
+			return credited, err
+		}
+		if entry != nil {
+			credited = append(credited, entry)
+		}
+	}
+	return credited, nil
+}
+
+// Balances returns a user's credited balance per asset
+//
+// Parameters:
+//   - userID: The user
+//
+// Returns:
+//   - map[string]decimal.Decimal: The credited amount per asset ID
+//   - error: Store error
+func (l *Ledger) Balances(userID string) (map[string]decimal.Decimal, error) {
+	entries, err := l.store.Entries(userID)
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[string]decimal.Decimal)
+	for _, entry := range entries {
+		balances[entry.AssetId] = balances[entry.AssetId].Add(entry.Amount)
+	}
+	return balances, nil
+}
+
+// Entries returns a user's credited entries, oldest first
+func (l *Ledger) Entries(userID string) ([]*Entry, error) {
+	return l.store.Entries(userID)
+}
+
+// sortEntries orders entries by credit time, keeping insertion order for ties
+func sortEntries(entries []*Entry) {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreditedAt < entries[j].CreditedAt })
+}
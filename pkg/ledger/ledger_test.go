@@ -0,0 +1,171 @@
+// ledger_test.go contains unit tests for the deposit ledger.
+package ledger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAccount() *developer.DepositAccount {
+	return &developer.DepositAccount{
+		UserId:         "user_1",
+		AssetId:        "USDT-ETH",
+		DepositAddress: "0xabc",
+		IsPermanent:    true,
+		Transactions: []*developer.Transaction{
+			{TxHash: "0xAA01", Amount: "10.5", Status: "confirmed", AmlStatus: "approved", CreatedAt: 1},
+			{TxHash: "0xaa01", Amount: "10.5", Status: "confirmed", AmlStatus: "approved", CreatedAt: 1},
+			{TxHash: "0xbb02", Amount: "4", Status: "submitted", CreatedAt: 2},
+			{TxHash: "0xcc03", Amount: "1", Status: "confirmed", AmlStatus: "approved", Type: developer.TransactionTypeRefund, CreatedAt: 3},
+		},
+	}
+}
+
+func TestCreditAccountOnce(t *testing.T) {
+	l := New(NewMemoryStore())
+	account := testAccount()
+
+	credited, err := l.CreditAccount(account)
+	require.NoError(t, err)
+	require.Len(t, credited, 1)
+	assert.Equal(t, "0xaa01", credited[0].TxHash)
+	assert.Equal(t, "USDT-ETH", credited[0].AssetId)
+
+	credited, err = l.CreditAccount(account)
+	require.NoError(t, err)
+	assert.Empty(t, credited)
+
+	account.Transactions[2].Status = "confirmed"
+	account.Transactions[2].AmlStatus = "approved"
+	credited, err = l.CreditAccount(account)
+	require.NoError(t, err)
+	require.Len(t, credited, 1)
+
+	balances, err := l.Balances("user_1")
+	require.NoError(t, err)
+	assert.Equal(t, "14.5", balances["USDT-ETH"].String())
+}
+
+func TestFileStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	store, err := OpenFileStore(path)
+	require.NoError(t, err)
+	credited, err := New(store).CreditAccount(testAccount())
+	require.NoError(t, err)
+	require.Len(t, credited, 1)
+	require.NoError(t, store.Close())
+
+	// Simulate a crash in the middle of the next write
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"tx_hash":"0xbb`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	store, err = OpenFileStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	has, err := store.Has("0xAA01")
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	credited, err = New(store).CreditAccount(testAccount())
+	require.NoError(t, err)
+	assert.Empty(t, credited)
+}
+
+// failingFile writes half of the next line and then fails, like a full disk
+type failingFile struct {
+	*os.File
+	fail bool
+}
+
+func (f *failingFile) Write(p []byte) (int, error) {
+	if f.fail {
+		f.fail = false
+		n, _ := f.File.Write(p[:len(p)/2])
+		return n, errors.New("no space left on device")
+	}
+	return f.File.Write(p)
+}
+
+func TestFileStoreRollsBackFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	store, err := OpenFileStore(path)
+	require.NoError(t, err)
+	file := &failingFile{File: store.file.(*os.File)}
+	store.file = file
+
+	_, err = store.Credit(&Entry{TxHash: "0x01", UserId: "user_1", Amount: decimal.NewFromInt(1)})
+	require.NoError(t, err)
+	file.fail = true
+	_, err = store.Credit(&Entry{TxHash: "0x02", UserId: "user_1", Amount: decimal.NewFromInt(2)})
+	require.Error(t, err)
+	_, err = store.Credit(&Entry{TxHash: "0x03", UserId: "user_1", Amount: decimal.NewFromInt(3)})
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	store, err = OpenFileStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	entries, err := store.Entries("user_1")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "0x01", entries[0].TxHash)
+	assert.Equal(t, "0x03", entries[1].TxHash)
+}
+
+func TestOneTransactionPaysSeveralAddresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	store, err := OpenFileStore(path)
+	require.NoError(t, err)
+	l := New(store)
+
+	// A batched withdrawal pays two users' deposit addresses in one transaction
+	batch := func(to string, amount string) *developer.Transaction {
+		return &developer.Transaction{TxHash: "0xBA7C4", Amount: amount, Status: "confirmed", AmlStatus: "approved", DestinationAddress: to}
+	}
+	alice := &developer.DepositAccount{UserId: "alice", AssetId: "USDT-ETH", DepositAddress: "0xA11CE"}
+	bob := &developer.DepositAccount{UserId: "bob", AssetId: "USDT-ETH", DepositAddress: "0xb0b"}
+
+	entry, err := l.CreditTransaction("alice", alice, batch("0xa11ce", "5"))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	entry, err = l.CreditTransaction("bob", bob, batch("0xb0b", "7"))
+	require.NoError(t, err)
+	require.NotNil(t, entry, "the second address of the same transaction is credited")
+
+	// The same output seen again, with the address taken from the account, is not credited twice
+	entry, err = l.CreditTransaction("alice", alice, batch("", "5"))
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	_, err = l.CreditTransaction("alice", nil, batch("", "5"))
+	assert.ErrorContains(t, err, "deposit address and asset")
+	require.NoError(t, store.Close())
+
+	store, err = OpenFileStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	for user, want := range map[string]string{"alice": "5", "bob": "7"} {
+		balances, err := New(store).Balances(user)
+		require.NoError(t, err)
+		assert.Equal(t, want, balances["USDT-ETH"].String(), user)
+	}
+	entry, err = New(store).CreditTransaction("bob", bob, batch("0xB0B", "7"))
+	require.NoError(t, err)
+	assert.Nil(t, entry, "dedup survives a restart")
+}
+
+func TestNormalizeTxHash(t *testing.T) {
+	assert.Equal(t, "0xabcdef", NormalizeTxHash(" 0xABCdef "))
+	assert.Equal(t, "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnb", NormalizeTxHash("5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnb"))
+	assert.Equal(t, "0xZZ", NormalizeTxHash("0xZZ"))
+}
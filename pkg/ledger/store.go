@@ -0,0 +1,205 @@
+// store.go contains the in-memory and file-backed ledger stores.
+// The file store appends one JSON line per entry and fsyncs it before Credit returns, so a
+// deposit that has been credited is never credited again after a restart.
+package ledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// MemoryStore keeps entries in memory. It is intended for tests and single-process tools.
+type MemoryStore struct {
+	mu      sync.Mutex
+	byKey   map[string]*Entry
+	hashes  map[string]bool
+	entries []*Entry
+	closed  bool
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{byKey: make(map[string]*Entry), hashes: make(map[string]bool)}
+}
+
+// Credit records an entry unless its deposit is already known
+func (s *MemoryStore) Credit(entry *Entry) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false, ErrStoreClosed
+	}
+	return s.add(entry), nil
+}
+
+// add records an entry in memory. Callers must hold s.mu.
+func (s *MemoryStore) add(entry *Entry) bool {
+	if _, ok := s.byKey[entry.key()]; ok {
+		return false
+	}
+	stored := *entry
+	s.byKey[entry.key()] = &stored
+	s.hashes[entry.TxHash] = true
+	s.entries = append(s.entries, &stored)
+	return true
+}
+
+// Has reports whether a transaction hash has been credited to any deposit address
+func (s *MemoryStore) Has(txHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false, ErrStoreClosed
+	}
+	return s.hashes[NormalizeTxHash(txHash)], nil
+}
+
+// Entries returns the entries of a user, oldest first
+func (s *MemoryStore) Entries(userID string) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	var result []*Entry
+	for _, entry := range s.entries {
+		if entry.UserId == userID {
+			c := *entry
+			result = append(result, &c)
+		}
+	}
+	sortEntries(result)
+	return result, nil
+}
+
+// Close marks the store as closed
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// ledgerFile is the part of *os.File the file store uses
+type ledgerFile interface {
+	Write(p []byte) (int, error)
+	Sync() error
+	Truncate(size int64) error
+	Seek(offset int64, whence int) (int64, error)
+	Close() error
+}
+
+// FileStore keeps entries in memory and appends each new entry to a JSONL file
+type FileStore struct {
+	MemoryStore
+	file ledgerFile
+	// size is the length of the file up to the last complete entry
+	size int64
+	// broken is set when a failed write could not be rolled back; later writes return it
+	broken error
+}
+
+// OpenFileStore opens or creates a ledger file and loads its entries.
+// A truncated last line, left by a crash during a write, is ignored; the deposit it
+// described is credited again on the next sync because it is not in the file.
+//
+// Parameters:
+//   - path: Path of the JSONL ledger file
+//
+// Returns:
+//   - *FileStore: The opened store
+//   - error: Error if the file cannot be opened or contains an invalid entry
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{}
+	s.byKey = make(map[string]*Entry)
+	s.hashes = make(map[string]bool)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening ledger: %v", err)
+	}
+	var valid int64
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// A final line without a newline was not fully written
+			break
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error reading ledger entry at offset %d: %v", valid, err)
+		}
+		s.add(&entry)
+		valid += int64(len(line))
+	}
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error truncating ledger: %v", err)
+	}
+	if _, err := f.Seek(valid, 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error seeking ledger: %v", err)
+	}
+	s.file = f
+	s.size = valid
+	return s, nil
+}
+
+// Credit appends the entry to the file unless its deposit is already known
+func (s *FileStore) Credit(entry *Entry) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false, ErrStoreClosed
+	}
+	if s.broken != nil {
+		return false, s.broken
+	}
+	if _, ok := s.byKey[entry.key()]; ok {
+		return false, nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return false, fmt.Errorf("error marshaling ledger entry: %v", err)
+	}
+	line = append(line, '\n')
+	if _, err := s.file.Write(line); err != nil {
+		return false, s.rollback(fmt.Errorf("error writing ledger: %v", err))
+	}
+	if err := s.file.Sync(); err != nil {
+		return false, s.rollback(fmt.Errorf("error syncing ledger: %v", err))
+	}
+	s.size += int64(len(line))
+	return s.add(entry), nil
+}
+
+// rollback truncates the file to the last complete entry after a failed write, so a partial
+// line is not followed by later entries. If that fails too, the store refuses further writes.
+// Callers must hold s.mu.
+func (s *FileStore) rollback(cause error) error {
+	if err := s.file.Truncate(s.size); err != nil {
+		s.broken = fmt.Errorf("%v; error truncating ledger: %v", cause, err)
+		return s.broken
+	}
+	if _, err := s.file.Seek(s.size, 0); err != nil {
+		s.broken = fmt.Errorf("%v; error seeking ledger: %v", cause, err)
+		return s.broken
+	}
+	return cause
+}
+
+// Close closes the ledger file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.file.Close()
+}
@@ -0,0 +1,298 @@
+// Package paymenturi builds wallet-scannable payment URIs for crypto deposit addresses.
+//
+// After a payment intent is updated with PaymentMethodTypeCrypto, its charge carries a
+// deposit address, amount, and asset. FromCrypto turns that into the URI format the payer's
+// wallet understands (EIP-681 on EVM chains, TRON, or Solana Pay), which can then be
+// rendered offline with the qrcode package:
+//
+//	uri, err := paymenturi.FromCrypto(charge.PaymentMethodOptions.Crypto, asset)
+//	code, err := qrcode.Encode(uri, qrcode.LevelMedium)
+//	png, err := code.PNG(8, qrcode.DefaultBorder)
+package paymenturi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/shopspring/decimal"
+)
+
+// Scheme is a payment URI format
+type Scheme string
+
+const (
+	// SchemeEIP681 is the Ethereum payment request format (ethereum:), used by all EVM chains
+	SchemeEIP681 Scheme = "ethereum"
+	// SchemeTron is the TRON payment format (tron:) understood by TronLink and compatible wallets
+	SchemeTron Scheme = "tron"
+	// SchemeSolanaPay is the Solana Pay transfer request format (solana:)
+	SchemeSolanaPay Scheme = "solana"
+)
+
+var (
+	// ErrUnsupportedNetwork is returned for assets whose network has no supported URI scheme
+	ErrUnsupportedNetwork = errors.New("no payment URI scheme for this network")
+	// ErrTooManyDecimals is returned when an amount is more precise than the asset allows
+	ErrTooManyDecimals = errors.New("amount has more decimal places than the asset supports")
+)
+
+// Request describes a payment to encode as a URI
+type Request struct {
+	// Address is the recipient, normally the charge's deposit address
+	Address string
+	// Amount is the amount in whole token units (e.g., "12.5"); empty lets the payer choose
+	Amount string
+	// Asset is the asset to pay with; it supplies the network, chain ID, contract address and decimals
+	Asset *developer.Asset
+	// Label describes the merchant (Solana Pay only)
+	Label string
+	// Message describes the purchase (Solana Pay only)
+	Message string
+	// Memo is recorded on-chain with the transfer (Solana Pay only)
+	Memo string
+}
+
+// SchemeFor returns the URI scheme used for an asset's network.
+// EVM chains are recognized by a chain ID; TRON and Solana by the network name.
+//
+// Parameters:
+//   - asset: A crypto asset
+//
+// Returns:
+//   - Scheme: The scheme wallets on that network understand
+//   - error: ErrUnsupportedNetwork if the asset is fiat or its network has no supported scheme
+func SchemeFor(asset *developer.Asset) (Scheme, error) {
+	if asset == nil || asset.CryptoAssetParams == nil {
+		return "", fmt.Errorf("%w: not a crypto asset", ErrUnsupportedNetwork)
+	}
+	if asset.ChainId > 0 {
+		return SchemeEIP681, nil
+	}
+	network := strings.ToLower(asset.Network)
+	switch {
+	case strings.Contains(network, "tron"), strings.Contains(network, "trc20"), network == "trx":
+		return SchemeTron, nil
+	case strings.Contains(network, "solana"), network == "sol":
+		return SchemeSolanaPay, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedNetwork, asset.Network)
+}
+
+// Build encodes the request using the scheme of the asset's network
+//
+// Parameters:
+//   - req: The payment to encode
+//
+// Returns:
+//   - string: The payment URI
+//   - error: ErrUnsupportedNetwork, ErrTooManyDecimals, or an error for a missing address or invalid amount
+func Build(req *Request) (string, error) {
+	scheme, err := SchemeFor(req.Asset)
+	if err != nil {
+		return "", err
+	}
+	switch scheme {
+	case SchemeEIP681:
+		return EIP681(req)
+	case SchemeTron:
+		return Tron(req)
+	default:
+		return SolanaPay(req)
+	}
+}
+
+// FromCrypto builds the payment URI for the crypto details of a charge
+//
+// Parameters:
+//   - crypto: The charge's crypto payment details, with DepositAddress and Amount set
+//   - asset: The asset identified by crypto.AssetId, e.g. from ListAssets
+//
+// Returns:
+//   - string: The payment URI
+//   - error: Error if the deposit address is missing, the asset does not match, or Build fails
+func FromCrypto(crypto *developer.Crypto, asset *developer.Asset) (string, error) {
+	if crypto == nil || crypto.DepositAddress == nil || *crypto.DepositAddress == "" {
+		return "", errors.New("crypto payment has no deposit address")
+	}
+	if asset == nil {
+		return "", errors.New("asset is required")
+	}
+	if crypto.AssetId != nil && *crypto.AssetId != "" && *crypto.AssetId != asset.Id {
+		return "", fmt.Errorf("asset %q does not match payment asset %q", asset.Id, *crypto.AssetId)
+	}
+	req := &Request{Address: *crypto.DepositAddress, Asset: asset}
+	if crypto.Amount != nil {
+		req.Amount = *crypto.Amount
+	}
+	return Build(req)
+}
+
+// EIP681 encodes the request as an EIP-681 payment request for an EVM chain.
+// Native coins use "ethereum:<address>@<chainId>?value=<wei>"; tokens call the ERC-20
+// transfer function with "ethereum:<contract>@<chainId>/transfer?address=<address>&uint256=<units>".
+// Amounts are converted to base units using the asset's decimals.
+//
+// Parameters:
+//   - req: The payment to encode; the asset must have a chain ID
+//
+// Returns:
+//   - string: The payment URI
+//   - error: Error if the address or chain ID is missing, or the amount is invalid
+func EIP681(req *Request) (string, error) {
+	if err := req.validate(); err != nil {
+		return "", err
+	}
+	if req.Asset.ChainId <= 0 {
+		return "", fmt.Errorf("asset %q has no chain ID", req.Asset.Id)
+	}
+	units, err := req.baseUnits()
+	if err != nil {
+		return "", err
+	}
+
+	var q query
+	var uri string
+	if req.Asset.ContractAddress == "" {
+		uri = fmt.Sprintf("ethereum:%s@%d", req.Address, req.Asset.ChainId)
+		q.add("value", units)
+	} else {
+		uri = fmt.Sprintf("ethereum:%s@%d/transfer", req.Asset.ContractAddress, req.Asset.ChainId)
+		q.add("address", req.Address)
+		q.add("uint256", units)
+	}
+	return uri + q.String(), nil
+}
+
+// Tron encodes the request as a TRON payment URI: "tron:<address>?amount=<amount>",
+// with "&token=<contract>" for TRC-20 tokens. The amount is in whole token units.
+//
+// Parameters:
+//   - req: The payment to encode
+//
+// Returns:
+//   - string: The payment URI
+//   - error: Error if the address is missing or the amount is invalid
+func Tron(req *Request) (string, error) {
+	if err := req.validate(); err != nil {
+		return "", err
+	}
+	amount, err := req.decimalAmount()
+	if err != nil {
+		return "", err
+	}
+	var q query
+	q.add("amount", amount)
+	q.add("token", req.Asset.ContractAddress)
+	return "tron:" + req.Address + q.String(), nil
+}
+
+// SolanaPay encodes the request as a Solana Pay transfer request:
+// "solana:<recipient>?amount=<amount>&spl-token=<mint>&label=...&message=...&memo=...".
+// The amount is in whole token units; spl-token is omitted for native SOL.
+//
+// Parameters:
+//   - req: The payment to encode
+//
+// Returns:
+//   - string: The payment URI
+//   - error: Error if the address is missing or the amount is invalid
+func SolanaPay(req *Request) (string, error) {
+	if err := req.validate(); err != nil {
+		return "", err
+	}
+	amount, err := req.decimalAmount()
+	if err != nil {
+		return "", err
+	}
+	var q query
+	q.add("amount", amount)
+	q.add("spl-token", req.Asset.ContractAddress)
+	q.add("label", req.Label)
+	q.add("message", req.Message)
+	q.add("memo", req.Memo)
+	return "solana:" + req.Address + q.String(), nil
+}
+
+// ToBaseUnits converts an amount in whole token units to the integer amount in base units
+// (e.g., "1.5" with 6 decimals is "1500000")
+//
+// Parameters:
+//   - amount: Decimal amount in whole token units
+//   - decimals: Number of decimal places of the asset
+//
+// Returns:
+//   - string: The integer amount in base units
+//   - error: ErrTooManyDecimals if the amount is more precise than the asset, or an error for an invalid amount
+func ToBaseUnits(amount string, decimals int) (string, error) {
+	d, err := parseAmount(amount, decimals)
+	if err != nil {
+		return "", err
+	}
+	return d.Shift(int32(decimals)).String(), nil
+}
+
+// validate checks the fields every scheme needs
+func (req *Request) validate() error {
+	if req.Address == "" {
+		return errors.New("recipient address is required")
+	}
+	if req.Asset == nil || req.Asset.CryptoAssetParams == nil {
+		return errors.New("a crypto asset is required")
+	}
+	return nil
+}
+
+// baseUnits returns the amount in base units, or "" when no amount is set
+func (req *Request) baseUnits() (string, error) {
+	if req.Amount == "" {
+		return "", nil
+	}
+	return ToBaseUnits(req.Amount, req.Asset.Decimals)
+}
+
+// decimalAmount returns the amount in whole token units without exponent notation, or "" when no amount is set
+func (req *Request) decimalAmount() (string, error) {
+	if req.Amount == "" {
+		return "", nil
+	}
+	d, err := parseAmount(req.Amount, req.Asset.Decimals)
+	if err != nil {
+		return "", err
+	}
+	return d.String(), nil
+}
+
+// parseAmount parses a positive amount that fits the asset's decimals
+func parseAmount(amount string, decimals int) (decimal.Decimal, error) {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("error parsing amount %q: %v", amount, err)
+	}
+	if d.Sign() <= 0 {
+		return decimal.Zero, fmt.Errorf("amount must be positive, got %s", amount)
+	}
+	if !d.Shift(int32(decimals)).IsInteger() {
+		return decimal.Zero, fmt.Errorf("%w: %s has more than %d", ErrTooManyDecimals, amount, decimals)
+	}
+	return d, nil
+}
+
+// query builds a query string in insertion order, skipping empty values.
+// Spaces are encoded as %20, since not every wallet decodes "+" in URI parameters.
+type query []string
+
+func (q *query) add(key, value string) {
+	if value != "" {
+		*q = append(*q, key+"="+strings.ReplaceAll(url.QueryEscape(value), "+", "%20"))
+	}
+}
+
+func (q query) String() string {
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + strings.Join(q, "&")
+}
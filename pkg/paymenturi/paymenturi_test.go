@@ -0,0 +1,40 @@
+package paymenturi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromCrypto(t *testing.T) {
+	usdt := &developer.Asset{Id: "USDT-Ethereum", Decimals: 6, CryptoAssetParams: &developer.CryptoAssetParams{
+		Network: "Ethereum", ContractAddress: "0xdAC17F958D2ee523a2206206994597C13D831ec7", ChainId: 1,
+	}}
+	address, amount, assetID := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "12.5", "USDT-Ethereum"
+	crypto := &developer.Crypto{DepositAddress: &address, Amount: &amount, AssetId: &assetID}
+
+	uri, err := FromCrypto(crypto, usdt)
+	require.NoError(t, err)
+	assert.Equal(t, "ethereum:0xdAC17F958D2ee523a2206206994597C13D831ec7@1/transfer?address=0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed&uint256=12500000", uri)
+
+	eth := &developer.Asset{Id: "ETH-Ethereum", Decimals: 18, CryptoAssetParams: &developer.CryptoAssetParams{Network: "Ethereum", ChainId: 1}}
+	uri, err = EIP681(&Request{Address: address, Amount: "0.01", Asset: eth})
+	require.NoError(t, err)
+	assert.Equal(t, "ethereum:0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed@1?value=10000000000000000", uri)
+
+	_, err = EIP681(&Request{Address: address, Amount: "1.0000001", Asset: usdt})
+	assert.True(t, errors.Is(err, ErrTooManyDecimals))
+
+	usdc := &developer.Asset{Id: "USDC-Solana", Decimals: 6, CryptoAssetParams: &developer.CryptoAssetParams{
+		Network: "Solana", ContractAddress: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+	}}
+	uri, err = Build(&Request{Address: "9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin", Amount: "5", Asset: usdc, Label: "Coffee Shop"})
+	require.NoError(t, err)
+	assert.Equal(t, "solana:9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin?amount=5&spl-token=EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v&label=Coffee%20Shop", uri)
+
+	_, err = SchemeFor(&developer.Asset{Id: "BTC", CryptoAssetParams: &developer.CryptoAssetParams{Network: "Bitcoin"}})
+	assert.True(t, errors.Is(err, ErrUnsupportedNetwork))
+}
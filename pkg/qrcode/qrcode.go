@@ -0,0 +1,184 @@
+// Package qrcode is a small, dependency-free QR code encoder for rendering payment URIs
+// on point-of-sale terminals and checkout pages without network access.
+//
+// Content is encoded in byte mode (which covers every URI), the smallest version (1-40)
+// that fits the chosen error correction level is selected automatically, and the mask
+// with the lowest penalty score is applied, following ISO/IEC 18004.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Level is the error correction level of a QR code
+type Level int
+
+const (
+	// LevelLow recovers about 7% of damaged codewords
+	LevelLow Level = iota
+	// LevelMedium recovers about 15% of damaged codewords (a good default for screens)
+	LevelMedium
+	// LevelQuartile recovers about 25% of damaged codewords
+	LevelQuartile
+	// LevelHigh recovers about 30% of damaged codewords (use for printed or worn codes)
+	LevelHigh
+)
+
+// ErrTooLong is returned when the content does not fit in a version 40 QR code
+var ErrTooLong = errors.New("qrcode: content too long")
+
+// String returns the single-letter name of the level
+func (l Level) String() string {
+	switch l {
+	case LevelLow:
+		return "L"
+	case LevelMedium:
+		return "M"
+	case LevelQuartile:
+		return "Q"
+	case LevelHigh:
+		return "H"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// formatBits returns the two-bit level indicator used in the format information
+func (l Level) formatBits() int {
+	switch l {
+	case LevelLow:
+		return 1
+	case LevelMedium:
+		return 0
+	case LevelQuartile:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// Code is an encoded QR code
+type Code struct {
+	// Version is the QR code version (1-40)
+	Version int
+	// Size is the number of modules along each side (4*Version + 17), excluding the quiet zone
+	Size int
+	// Level is the error correction level
+	Level Level
+	// Mask is the data mask pattern that was applied (0-7)
+	Mask int
+
+	modules    []bool
+	isFunction []bool
+}
+
+// Black reports whether the module at column x, row y is dark.
+// Coordinates outside the symbol (such as the quiet zone) are light.
+func (c *Code) Black(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y*c.Size+x]
+}
+
+// Encode encodes content as a QR code
+//
+// Parameters:
+//   - content: Text to encode, typically a payment URI
+//   - level: Error correction level
+//
+// Returns:
+//   - *Code: The encoded QR code using the smallest version that fits
+//   - error: ErrTooLong if the content does not fit, or an error for an invalid level
+func Encode(content string, level Level) (*Code, error) {
+	if level < LevelLow || level > LevelHigh {
+		return nil, fmt.Errorf("qrcode: invalid error correction level %d", int(level))
+	}
+	data := []byte(content)
+
+	version := 0
+	for v := 1; v <= 40; v++ {
+		if 4+charCountBits(v)+len(data)*8 <= numDataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	codewords := encodeBytes(data, version, level)
+	c := &Code{
+		Version:    version,
+		Size:       version*4 + 17,
+		Level:      level,
+		modules:    make([]bool, (version*4+17)*(version*4+17)),
+		isFunction: make([]bool, (version*4+17)*(version*4+17)),
+	}
+	c.drawFunctionPatterns()
+	c.drawCodewords(addECCAndInterleave(codewords, version, level))
+
+	best, minPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); minPenalty < 0 || p < minPenalty {
+			best, minPenalty = mask, p
+		}
+		c.applyMask(mask) // masks are XOR patterns, so applying again undoes them
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+	c.Mask = best
+	c.isFunction = nil
+	return c, nil
+}
+
+// encodeBytes builds the data codewords for byte-mode content, including the mode
+// indicator, character count, terminator, and pad bytes
+func encodeBytes(data []byte, version int, level Level) []byte {
+	capacity := numDataCodewords(version, level) * 8
+	var bb bitBuffer
+	bb.append(0x4, 4)
+	bb.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	terminator := capacity - len(bb)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bb.append(0, terminator)
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+	return bb.bytes()
+}
+
+// charCountBits returns the length of the byte-mode character count field
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// bitBuffer is a sequence of bits, most significant first
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*bb = append(*bb, (value>>uint(i))&1 != 0)
+	}
+}
+
+func (bb bitBuffer) bytes() []byte {
+	out := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			out[i>>3] |= 1 << uint(7-i&7)
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// "HELLO WORLD" as version 1-M, from the ISO/IEC 18004 worked example
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ecc := reedSolomonRemainder(data, reedSolomonGenerator(10))
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, ecc)
+}
+
+func TestEncodeLayout(t *testing.T) {
+	uri := "ethereum:0xdAC17F958D2ee523a2206206994597C13D831ec7@1/transfer?address=0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed&uint256=12500000"
+	code, err := Encode(uri, LevelMedium)
+	require.NoError(t, err)
+	assert.Equal(t, 4*code.Version+17, code.Size)
+	assert.True(t, numDataCodewords(code.Version, LevelMedium) >= len(uri)+2)
+	assert.True(t, numDataCodewords(code.Version-1, LevelMedium) < len(uri)+2, "smallest version should be chosen")
+
+	// Finder patterns in three corners: dark ring, light ring, dark 3x3 centre
+	for _, corner := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+		for i := 0; i < 7; i++ {
+			assert.True(t, code.Black(corner[0]+i, corner[1]))
+			assert.True(t, code.Black(corner[0], corner[1]+i))
+		}
+		assert.False(t, code.Black(corner[0]+1, corner[1]+1))
+		assert.True(t, code.Black(corner[0]+3, corner[1]+3))
+	}
+	// Timing pattern and dark module
+	for i := 8; i < code.Size-8; i++ {
+		assert.Equal(t, i%2 == 0, code.Black(i, 6))
+		assert.Equal(t, i%2 == 0, code.Black(6, i))
+	}
+	assert.True(t, code.Black(8, code.Size-8))
+	assert.False(t, code.Black(-1, 0))
+
+	img, err := code.PNG(4, DefaultBorder)
+	require.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, (code.Size+2*DefaultBorder)*4, decoded.Bounds().Dx())
+
+	svg := code.SVG(DefaultBorder)
+	assert.True(t, strings.HasPrefix(svg, "<?xml"))
+	assert.Contains(t, svg, "viewBox=\"0 0 ")
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	_, err := Encode(strings.Repeat("x", 3000), LevelHigh)
+	assert.Equal(t, ErrTooLong, err)
+
+	code, err := Encode(strings.Repeat("x", 2953), LevelLow)
+	require.NoError(t, err)
+	assert.Equal(t, 40, code.Version)
+}
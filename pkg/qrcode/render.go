@@ -0,0 +1,108 @@
+// render.go contains the PNG and SVG renderers for encoded QR codes.
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// DefaultBorder is the quiet zone width, in modules, required by the QR code specification
+const DefaultBorder = 4
+
+// Image renders the QR code as a black-and-white image
+//
+// Parameters:
+//   - scale: Pixels per module (values below 1 are treated as 1)
+//   - border: Quiet zone width in modules (negative values are treated as 0)
+//
+// Returns:
+//   - *image.Paletted: The rendered image
+func (c *Code) Image(scale, border int) *image.Paletted {
+	if scale < 1 {
+		scale = 1
+	}
+	if border < 0 {
+		border = 0
+	}
+	side := (c.Size + border*2) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			if c.Black(x/scale-border, y/scale-border) {
+				img.Pix[y*img.Stride+x] = 1
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG writes the QR code as a PNG image
+//
+// Parameters:
+//   - w: Destination writer
+//   - scale: Pixels per module
+//   - border: Quiet zone width in modules, usually DefaultBorder
+//
+// Returns:
+//   - error: nil on success, error if encoding or writing fails
+func (c *Code) WritePNG(w io.Writer, scale, border int) error {
+	if err := png.Encode(w, c.Image(scale, border)); err != nil {
+		return fmt.Errorf("error encoding qr code png: %v", err)
+	}
+	return nil
+}
+
+// PNG returns the QR code as PNG image bytes
+//
+// Parameters:
+//   - scale: Pixels per module
+//   - border: Quiet zone width in modules, usually DefaultBorder
+//
+// Returns:
+//   - []byte: The PNG image
+//   - error: nil on success, error if encoding fails
+func (c *Code) PNG(scale, border int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.WritePNG(&buf, scale, border); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG returns the QR code as a scalable SVG document with one unit per module.
+// Set the width and height attributes (or CSS size) of the embedding element to scale it.
+//
+// Parameters:
+//   - border: Quiet zone width in modules, usually DefaultBorder
+//
+// Returns:
+//   - string: The SVG document
+func (c *Code) SVG(border int) string {
+	if border < 0 {
+		border = 0
+	}
+	side := c.Size + border*2
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Black(x, y) {
+				if path.Len() > 0 {
+					path.WriteByte(' ')
+				}
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+border, y+border)
+			}
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(&sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" version=\"1.1\" viewBox=\"0 0 %d %d\" shape-rendering=\"crispEdges\">\n", side, side)
+	sb.WriteString("\t<rect width=\"100%\" height=\"100%\" fill=\"#FFFFFF\"/>\n")
+	fmt.Fprintf(&sb, "\t<path d=\"%s\" fill=\"#000000\"/>\n", path.String())
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
@@ -0,0 +1,437 @@
+// Package quote converts fiat prices to crypto amounts with exchange rates that expire.
+// A Quoter fetches the rates of payable assets from a RateSource (normally the SDK client),
+// caches them for a TTL, and converts amounts exactly with decimal arithmetic, rounding up
+// to the asset's decimals so the merchant is never paid short.
+//
+// The intended call order is: quote the price and show it; call Refresh right before
+// confirming the payment intent, which re-quotes an expired quote so the customer can be
+// shown the new amount; then, once the payment intent is confirmed, call CheckPaymentIntent,
+// which compares the charged rate with the quote and warns if the quote had expired when the
+// charge was created or the amounts drifted apart.
+//
+// Example usage:
+//
+//	quoter := quote.NewQuoter(client, nil)
+//	quotes, _ := quoter.QuoteAll(decimal.RequireFromString("49.99"), "USD", assets)
+//	// ... the customer picks a quote ...
+//	fresh, _ := quoter.Refresh(chosen)
+//	if fresh != chosen {
+//		// show fresh.CryptoAmount to the customer again before confirming
+//	}
+//	// ... the payment intent is confirmed with the quote's asset ...
+//	if warning, _ := quoter.CheckPaymentIntent(fresh, pi); warning != nil {
+//		log.Printf("payment %s: %v", pi.ID, warning)
+//	}
+package quote
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// DefaultTTL is how long fetched rates are used when Options.TTL is not set
+	DefaultTTL = 60 * time.Second
+	// DefaultTolerance is the relative difference between quoted and charged amounts allowed when Options.Tolerance is not set
+	DefaultTolerance = 0.005
+	// divisionPrecision is the number of extra decimal places kept when dividing by a rate
+	divisionPrecision = 8
+)
+
+var (
+	// ErrNoRate is returned when the rate source has no rate for an asset
+	ErrNoRate = errors.New("quote: no exchange rate for asset")
+	// ErrNotCrypto is returned when quoting a fiat asset
+	ErrNotCrypto = errors.New("quote: asset is not a crypto asset")
+	// ErrQuoteExpired is returned by Validate for a quote past its expiry
+	ErrQuoteExpired = errors.New("quote: quote has expired")
+)
+
+// RateSource provides exchange rates. *martianpay.Client implements it.
+type RateSource interface {
+	// ListExchangeRates returns the current rates of payable assets in a fiat currency
+	ListExchangeRates(currency string) (*developer.ExchangeRateListResponse, error)
+}
+
+// Options configures a Quoter
+type Options struct {
+	// TTL is how long fetched rates are used before they are fetched again; defaults to DefaultTTL.
+	// A rate's own ExpiresAt shortens it.
+	TTL time.Duration
+	// Tolerance is the relative difference between the quoted and charged crypto amounts
+	// that CheckPaymentIntent accepts; defaults to DefaultTolerance (0.5%)
+	Tolerance float64
+	// OnStale is called with every warning CheckPaymentIntent returns
+	OnStale func(*StaleRateWarning)
+}
+
+// Quote is the crypto amount of a fiat price at one exchange rate
+type Quote struct {
+	// AssetId is the asset to pay with
+	AssetId string `json:"asset_id"`
+	// Token is the token symbol shown to the customer
+	Token string `json:"token"`
+	// Decimals is the number of decimal places of the asset
+	Decimals int `json:"decimals"`
+	// Currency is the fiat currency of FiatAmount
+	Currency string `json:"currency"`
+	// FiatAmount is the price in fiat
+	FiatAmount decimal.Decimal `json:"fiat_amount"`
+	// Rate is the price of one unit of the asset in Currency
+	Rate decimal.Decimal `json:"rate"`
+	// CryptoAmount is FiatAmount divided by Rate, rounded up to Decimals
+	CryptoAmount decimal.Decimal `json:"crypto_amount"`
+	// FetchedAt is when the rate was fetched
+	FetchedAt time.Time `json:"fetched_at"`
+	// ExpiresAt is when the quote stops being valid
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IsExpired reports whether the quote is no longer valid at the given time
+func (q *Quote) IsExpired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
+
+// Validate checks that the quote can still be used to confirm a payment
+//
+// Returns:
+//   - error: ErrQuoteExpired (with the expiry time) if the quote is expired at now
+func (q *Quote) Validate(now time.Time) error {
+	if q.IsExpired(now) {
+		return fmt.Errorf("%w at %s", ErrQuoteExpired, q.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// String formats the quote for display, e.g. "49.99 USD = 50.005002 USDT"
+func (q *Quote) String() string {
+	return fmt.Sprintf("%s %s = %s %s", q.FiatAmount.StringFixed(2), q.Currency, q.CryptoAmount.StringFixed(int32(q.Decimals)), q.Token)
+}
+
+// rateSet is the cached rates of one currency
+type rateSet struct {
+	rates     map[string]*developer.ExchangeRate
+	fetchedAt time.Time
+	expiresAt time.Time
+}
+
+// rateFetch is an in-flight fetch of the rates of one currency, shared by concurrent callers
+type rateFetch struct {
+	done chan struct{}
+	set  *rateSet
+	err  error
+}
+
+// Quoter quotes fiat prices in crypto using cached exchange rates. It is safe for concurrent use.
+// Rates are fetched without holding the cache lock, and concurrent quotes for a currency whose
+// rates are missing share one fetch, so a slow rate source only delays quotes for that currency.
+type Quoter struct {
+	source RateSource
+	opts   Options
+	now    func() time.Time
+
+	mu       sync.Mutex
+	cache    map[string]*rateSet
+	inflight map[string]*rateFetch
+	// generation is increased by Invalidate so fetches started before it are not cached
+	generation uint64
+}
+
+// NewQuoter creates a quoter.
+//
+// Parameters:
+//   - source: Where rates are fetched from, normally the SDK client
+//   - opts: Cache and tolerance settings; nil uses the defaults
+//
+// Returns:
+//   - *Quoter: The quoter
+func NewQuoter(source RateSource, opts *Options) *Quoter {
+	q := &Quoter{source: source, now: time.Now, cache: make(map[string]*rateSet), inflight: make(map[string]*rateFetch)}
+	if opts != nil {
+		q.opts = *opts
+	}
+	if q.opts.TTL <= 0 {
+		q.opts.TTL = DefaultTTL
+	}
+	if q.opts.Tolerance <= 0 {
+		q.opts.Tolerance = DefaultTolerance
+	}
+	return q
+}
+
+// Quote converts a fiat price to the crypto amount of one asset.
+//
+// Parameters:
+//   - fiatAmount: The price in fiat
+//   - currency: The fiat currency of the price
+//   - asset: The crypto asset to pay with
+//
+// Returns:
+//   - *Quote: The crypto amount with the rate and its expiry
+//   - error: ErrNotCrypto, ErrNoRate, or an error fetching rates
+func (q *Quoter) Quote(fiatAmount decimal.Decimal, currency string, asset *developer.Asset) (*Quote, error) {
+	if asset == nil || asset.IsFiat || asset.CryptoAssetParams == nil {
+		return nil, ErrNotCrypto
+	}
+	token := asset.Token
+	if token == "" {
+		token = asset.Coin
+	}
+	return q.quote(fiatAmount, currency, asset.Id, token, asset.Decimals)
+}
+
+// Refresh returns a quote that is valid for confirming a payment. Call it right before the
+// payment intent is confirmed: a quote that is still valid is returned as is, and an expired
+// one is quoted again for the same price and asset at the current rate.
+//
+// Parameters:
+//   - quote: The quote shown to the customer
+//
+// Returns:
+//   - *Quote: quote itself if still valid, otherwise a new quote whose amount should be shown to the customer again
+//   - error: ErrNoRate or an error fetching rates
+func (q *Quoter) Refresh(quote *Quote) (*Quote, error) {
+	if quote.Validate(q.now()) == nil {
+		return quote, nil
+	}
+	return q.quote(quote.FiatAmount, quote.Currency, quote.AssetId, quote.Token, quote.Decimals)
+}
+
+// quote converts a fiat price to the crypto amount of an asset at the cached rate
+func (q *Quoter) quote(fiatAmount decimal.Decimal, currency, assetID, token string, decimals int) (*Quote, error) {
+	if fiatAmount.Sign() <= 0 {
+		return nil, fmt.Errorf("quote: amount must be positive, got %s", fiatAmount)
+	}
+	set, err := q.rates(currency)
+	if err != nil {
+		return nil, err
+	}
+	rate, ok := set.rates[assetID]
+	if !ok || rate.Rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w %s in %s", ErrNoRate, assetID, currency)
+	}
+	return &Quote{
+		AssetId:      assetID,
+		Token:        token,
+		Decimals:     decimals,
+		Currency:     strings.ToUpper(currency),
+		FiatAmount:   fiatAmount,
+		Rate:         rate.Rate,
+		CryptoAmount: Convert(fiatAmount, rate.Rate, decimals),
+		FetchedAt:    set.fetchedAt,
+		ExpiresAt:    set.expiresAt,
+	}, nil
+}
+
+// QuoteAll quotes a fiat price for every payable crypto asset that has a rate.
+// Assets without a rate are skipped, so a storefront can show whatever is available.
+//
+// Parameters:
+//   - fiatAmount: The price in fiat
+//   - currency: The fiat currency of the price
+//   - assets: Candidate assets, e.g. from GetAllAssets
+//
+// Returns:
+//   - []*Quote: One quote per payable crypto asset with a rate, in the order of assets
+//   - error: Error fetching rates or for a non-positive amount
+func (q *Quoter) QuoteAll(fiatAmount decimal.Decimal, currency string, assets []*developer.Asset) ([]*Quote, error) {
+	var quotes []*Quote
+	for _, asset := range assets {
+		if asset == nil || !asset.Payable || asset.IsFiat || asset.CryptoAssetParams == nil {
+			continue
+		}
+		quote, err := q.Quote(fiatAmount, currency, asset)
+		if errors.Is(err, ErrNoRate) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+// Invalidate drops the cached rates of all currencies, so the next quote fetches fresh rates.
+// Fetches already in flight still answer their callers but are not cached.
+func (q *Quoter) Invalidate() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.cache = make(map[string]*rateSet)
+	q.inflight = make(map[string]*rateFetch)
+	q.generation++
+}
+
+// rates returns the cached rates of a currency, fetching them when missing or expired
+func (q *Quoter) rates(currency string) (*rateSet, error) {
+	currency = strings.ToUpper(currency)
+	now := q.now()
+
+	q.mu.Lock()
+	if set, ok := q.cache[currency]; ok && now.Before(set.expiresAt) {
+		q.mu.Unlock()
+		return set, nil
+	}
+	if f, ok := q.inflight[currency]; ok {
+		q.mu.Unlock()
+		<-f.done
+		return f.set, f.err
+	}
+	f := &rateFetch{done: make(chan struct{})}
+	q.inflight[currency] = f
+	generation := q.generation
+	q.mu.Unlock()
+
+	f.set, f.err = q.fetch(currency, now)
+
+	q.mu.Lock()
+	if q.generation == generation {
+		delete(q.inflight, currency)
+		if f.err == nil {
+			q.cache[currency] = f.set
+		}
+	}
+	q.mu.Unlock()
+	close(f.done)
+	return f.set, f.err
+}
+
+// fetch loads the rates of a currency from the source. It must not be called with q.mu held.
+func (q *Quoter) fetch(currency string, now time.Time) (*rateSet, error) {
+	response, err := q.source.ListExchangeRates(currency)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching exchange rates: %v", err)
+	}
+	set := &rateSet{rates: make(map[string]*developer.ExchangeRate), fetchedAt: now, expiresAt: now.Add(q.opts.TTL)}
+	for _, rate := range response.Rates {
+		if rate == nil || rate.AssetId == "" {
+			continue
+		}
+		set.rates[rate.AssetId] = rate
+		if rate.ExpiresAt > 0 {
+			if expires := time.Unix(rate.ExpiresAt, 0); expires.Before(set.expiresAt) {
+				set.expiresAt = expires
+			}
+		}
+	}
+	return set, nil
+}
+
+// Convert returns the crypto amount of a fiat amount at a rate, rounded up to the asset's decimals
+//
+// Parameters:
+//   - fiatAmount: The amount in fiat
+//   - rate: The price of one unit of the asset in fiat; must be positive
+//   - decimals: Number of decimal places of the asset
+//
+// Returns:
+//   - decimal.Decimal: The crypto amount
+func Convert(fiatAmount, rate decimal.Decimal, decimals int) decimal.Decimal {
+	return fiatAmount.DivRound(rate, int32(decimals+divisionPrecision)).RoundCeil(int32(decimals))
+}
+
+// StaleRateWarning reports that a payment intent was confirmed at a different rate than the customer was quoted
+type StaleRateWarning struct {
+	// PaymentIntentID is the confirmed payment intent
+	PaymentIntentID string `json:"payment_intent_id"`
+	// Quote is the quote the customer saw
+	Quote *Quote `json:"quote"`
+	// ChargedRate is the exchange rate of the charge, if reported
+	ChargedRate decimal.Decimal `json:"charged_rate"`
+	// ChargedAmount is the crypto amount of the charge
+	ChargedAmount decimal.Decimal `json:"charged_amount"`
+	// Expired is true when the quote had expired when the charge was created
+	Expired bool `json:"expired"`
+	// Difference is the relative difference between the charged and quoted crypto amounts
+	Difference decimal.Decimal `json:"difference"`
+}
+
+// Error describes the warning
+func (w *StaleRateWarning) Error() string {
+	var reasons []string
+	if w.Expired {
+		reasons = append(reasons, fmt.Sprintf("quote expired at %s", w.Quote.ExpiresAt.UTC().Format(time.RFC3339)))
+	}
+	if !w.Difference.IsZero() {
+		reasons = append(reasons, fmt.Sprintf("charged %s %s instead of quoted %s (%s%%)",
+			w.ChargedAmount, w.Quote.Token, w.Quote.CryptoAmount, w.Difference.Shift(2).StringFixed(2)))
+	}
+	return fmt.Sprintf("stale exchange rate for %s: %s", w.Quote.AssetId, strings.Join(reasons, ", "))
+}
+
+// CheckPaymentIntent compares a confirmed payment intent with the quote the customer saw.
+// It returns a warning when the quote had expired by the time the charge was created or the
+// charged crypto amount differs from the quoted one by more than the tolerance; both mean the
+// price shown was not the price charged. Expiry is judged by the charge's Created time (or the
+// payment intent's Updated time), so the check can run later from a webhook or a batch job.
+//
+// Parameters:
+//   - quote: The quote shown to the customer
+//   - pi: The payment intent after confirmation with the quote's asset
+//
+// Returns:
+//   - *StaleRateWarning: The warning, or nil if the charge matches the quote
+//   - error: Error if the payment intent has no crypto charge for the quote's asset or its amounts cannot be parsed
+func (q *Quoter) CheckPaymentIntent(quote *Quote, pi *developer.PaymentIntent) (*StaleRateWarning, error) {
+	crypto, created := latestCrypto(pi, quote.AssetId)
+	if crypto == nil || crypto.Amount == nil {
+		return nil, fmt.Errorf("quote: payment intent %s has no %s charge", pi.ID, quote.AssetId)
+	}
+	charged, err := decimal.NewFromString(*crypto.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing charged amount %q: %v", *crypto.Amount, err)
+	}
+
+	w := &StaleRateWarning{PaymentIntentID: pi.ID, Quote: quote, ChargedAmount: charged}
+	if crypto.ExchangeRate != nil && *crypto.ExchangeRate != "" {
+		if rate, err := decimal.NewFromString(*crypto.ExchangeRate); err == nil {
+			w.ChargedRate = rate
+		}
+	}
+	confirmedAt := q.now()
+	switch {
+	case created > 0:
+		confirmedAt = time.Unix(created, 0)
+	case pi.Updated > 0:
+		confirmedAt = time.Unix(pi.Updated, 0)
+	}
+	w.Expired = quote.IsExpired(confirmedAt)
+	if quote.CryptoAmount.Sign() > 0 {
+		diff := charged.Sub(quote.CryptoAmount).DivRound(quote.CryptoAmount, 6)
+		if diff.Abs().GreaterThan(decimal.NewFromFloat(q.opts.Tolerance)) {
+			w.Difference = diff
+		}
+	}
+	if !w.Expired && w.Difference.IsZero() {
+		return nil, nil
+	}
+	if q.opts.OnStale != nil {
+		q.opts.OnStale(w)
+	}
+	return w, nil
+}
+
+// latestCrypto returns the crypto details and creation time of the most recent charge for the asset
+func latestCrypto(pi *developer.PaymentIntent, assetID string) (*developer.Crypto, int64) {
+	var latest *developer.Crypto
+	var created int64
+	for _, charge := range pi.Charges {
+		if charge == nil || charge.PaymentMethodOptions == nil || charge.PaymentMethodOptions.Crypto == nil {
+			continue
+		}
+		crypto := charge.PaymentMethodOptions.Crypto
+		if crypto.AssetId == nil || *crypto.AssetId != assetID {
+			continue
+		}
+		if latest == nil || charge.Created >= created {
+			latest, created = crypto, charge.Created
+		}
+	}
+	return latest, created
+}
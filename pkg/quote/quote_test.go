@@ -0,0 +1,257 @@
+// quote_test.go contains unit tests for exchange-rate quotes.
+package quote
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct {
+	rates []*developer.ExchangeRate
+	calls int
+	err   error
+}
+
+func (s *fakeSource) ListExchangeRates(currency string) (*developer.ExchangeRateListResponse, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &developer.ExchangeRateListResponse{Rates: s.rates}, nil
+}
+
+// slowSource blocks fetches of EUR rates until release is closed
+type slowSource struct {
+	release chan struct{}
+	mu      sync.Mutex
+	calls   map[string]int
+}
+
+func (s *slowSource) ListExchangeRates(currency string) (*developer.ExchangeRateListResponse, error) {
+	s.mu.Lock()
+	s.calls[currency]++
+	s.mu.Unlock()
+	if currency == "EUR" {
+		<-s.release
+	}
+	return &developer.ExchangeRateListResponse{Rates: []*developer.ExchangeRate{{AssetId: "USDC_ETH", Rate: decimal.NewFromInt(1)}}}, nil
+}
+
+func cryptoAsset(id, token string, decimals int) *developer.Asset {
+	return &developer.Asset{Id: id, Payable: true, Decimals: decimals, CryptoAssetParams: &developer.CryptoAssetParams{Token: token}}
+}
+
+func newTestQuoter(source RateSource, opts *Options, now *time.Time) *Quoter {
+	q := NewQuoter(source, opts)
+	q.now = func() time.Time { return *now }
+	return q
+}
+
+func TestQuoteConvertsAndRoundsUp(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	source := &fakeSource{rates: []*developer.ExchangeRate{
+		{AssetId: "USDT_ETH", Currency: "USD", Rate: decimal.RequireFromString("0.9997")},
+		{AssetId: "ETH", Currency: "USD", Rate: decimal.RequireFromString("3000")},
+	}}
+	q := newTestQuoter(source, nil, &now)
+
+	quote, err := q.Quote(decimal.RequireFromString("49.99"), "usd", cryptoAsset("USDT_ETH", "USDT", 6))
+	require.NoError(t, err)
+	assert.Equal(t, "50.005002", quote.CryptoAmount.String())
+	assert.Equal(t, "USD", quote.Currency)
+	assert.Equal(t, now.Add(DefaultTTL), quote.ExpiresAt)
+
+	quote, err = q.Quote(decimal.NewFromInt(100), "USD", cryptoAsset("ETH", "ETH", 18))
+	require.NoError(t, err)
+	assert.Equal(t, "0.033333333333333334", quote.CryptoAmount.String())
+
+	_, err = q.Quote(decimal.NewFromInt(1), "USD", cryptoAsset("BTC", "BTC", 8))
+	assert.True(t, errors.Is(err, ErrNoRate))
+	_, err = q.Quote(decimal.NewFromInt(1), "USD", &developer.Asset{Id: "USD", IsFiat: true})
+	assert.Equal(t, ErrNotCrypto, err)
+	assert.Equal(t, 1, source.calls)
+}
+
+func TestQuoteCacheExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	source := &fakeSource{rates: []*developer.ExchangeRate{
+		{AssetId: "USDC_ETH", Rate: decimal.NewFromInt(1), ExpiresAt: now.Add(20 * time.Second).Unix()},
+	}}
+	q := newTestQuoter(source, &Options{TTL: time.Minute}, &now)
+	asset := cryptoAsset("USDC_ETH", "USDC", 6)
+
+	quote, err := q.Quote(decimal.NewFromInt(10), "USD", asset)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(20*time.Second), quote.ExpiresAt, "server expiry shortens the TTL")
+
+	now = now.Add(10 * time.Second)
+	_, err = q.Quote(decimal.NewFromInt(10), "USD", asset)
+	require.NoError(t, err)
+	assert.Equal(t, 1, source.calls)
+
+	now = now.Add(10 * time.Second)
+	assert.True(t, quote.IsExpired(now))
+	_, err = q.Quote(decimal.NewFromInt(10), "USD", asset)
+	require.NoError(t, err)
+	assert.Equal(t, 2, source.calls)
+}
+
+func TestSlowFetchDoesNotBlockOtherCurrencies(t *testing.T) {
+	source := &slowSource{release: make(chan struct{}), calls: make(map[string]int)}
+	q := NewQuoter(source, nil)
+	asset := cryptoAsset("USDC_ETH", "USDC", 6)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := q.Quote(decimal.NewFromInt(1), "EUR", asset)
+			assert.NoError(t, err)
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := q.Quote(decimal.NewFromInt(1), "USD", asset)
+		assert.NoError(t, err)
+		q.Invalidate()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("USD quote blocked by the EUR fetch")
+	}
+
+	close(source.release)
+	wg.Wait()
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	assert.LessOrEqual(t, source.calls["EUR"], 2, "concurrent EUR quotes share a fetch")
+}
+
+func TestQuoteAllSkipsUnquotable(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	source := &fakeSource{rates: []*developer.ExchangeRate{
+		{AssetId: "USDC_ETH", Rate: decimal.NewFromInt(1)},
+		{AssetId: "USDT_TRON", Rate: decimal.NewFromInt(1)},
+	}}
+	q := newTestQuoter(source, nil, &now)
+	unpayable := cryptoAsset("USDT_TRON", "USDT", 6)
+	unpayable.Payable = false
+
+	quotes, err := q.QuoteAll(decimal.NewFromInt(5), "USD", []*developer.Asset{
+		cryptoAsset("USDC_ETH", "USDC", 6), unpayable, cryptoAsset("BTC", "BTC", 8), {Id: "USD", IsFiat: true, Payable: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, quotes, 1)
+	assert.Equal(t, "USDC_ETH", quotes[0].AssetId)
+
+	source.err = errors.New("unavailable")
+	q.Invalidate()
+	_, err = q.QuoteAll(decimal.NewFromInt(5), "USD", []*developer.Asset{cryptoAsset("USDC_ETH", "USDC", 6)})
+	assert.Error(t, err)
+}
+
+func confirmedIntent(assetID, amount, rate string) *developer.PaymentIntent {
+	return &developer.PaymentIntent{ID: "pi_1", Charges: []*developer.Charge{{
+		PaymentMethodOptions: &developer.PaymentMethodOptions{Crypto: &developer.Crypto{AssetId: &assetID, Amount: &amount, ExchangeRate: &rate}},
+	}}}
+}
+
+func TestCheckPaymentIntent(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	source := &fakeSource{rates: []*developer.ExchangeRate{{AssetId: "USDC_ETH", Rate: decimal.NewFromInt(1)}}}
+	var warned []*StaleRateWarning
+	q := newTestQuoter(source, &Options{OnStale: func(w *StaleRateWarning) { warned = append(warned, w) }}, &now)
+	quote, err := q.Quote(decimal.NewFromInt(100), "USD", cryptoAsset("USDC_ETH", "USDC", 6))
+	require.NoError(t, err)
+
+	warning, err := q.CheckPaymentIntent(quote, confirmedIntent("USDC_ETH", "100.2", "0.998"))
+	require.NoError(t, err)
+	assert.Nil(t, warning, "within tolerance")
+
+	warning, err = q.CheckPaymentIntent(quote, confirmedIntent("USDC_ETH", "102", "0.98"))
+	require.NoError(t, err)
+	require.NotNil(t, warning)
+	assert.False(t, warning.Expired)
+	assert.Equal(t, "0.02", warning.Difference.String())
+	assert.Equal(t, "0.98", warning.ChargedRate.String())
+
+	now = now.Add(2 * DefaultTTL)
+	warning, err = q.CheckPaymentIntent(quote, confirmedIntent("USDC_ETH", "100", "1"))
+	require.NoError(t, err)
+	require.NotNil(t, warning)
+	assert.True(t, warning.Expired)
+	assert.Contains(t, warning.Error(), "quote expired")
+	assert.Len(t, warned, 2)
+
+	_, err = q.CheckPaymentIntent(quote, confirmedIntent("ETH", "1", "3000"))
+	assert.Error(t, err)
+}
+
+func TestCheckPaymentIntentUsesConfirmationTime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	source := &fakeSource{rates: []*developer.ExchangeRate{{AssetId: "USDC_ETH", Rate: decimal.NewFromInt(1)}}}
+	q := newTestQuoter(source, nil, &now)
+	quote, err := q.Quote(decimal.NewFromInt(100), "USD", cryptoAsset("USDC_ETH", "USDC", 6))
+	require.NoError(t, err)
+
+	// The check runs long after the quote expired, but the charge was created while it was valid
+	now = now.Add(time.Hour)
+	pi := confirmedIntent("USDC_ETH", "100", "1")
+	pi.Charges[0].Created = quote.ExpiresAt.Add(-time.Second).Unix()
+	warning, err := q.CheckPaymentIntent(quote, pi)
+	require.NoError(t, err)
+	assert.Nil(t, warning)
+
+	pi.Charges[0].Created = quote.ExpiresAt.Add(time.Second).Unix()
+	warning, err = q.CheckPaymentIntent(quote, pi)
+	require.NoError(t, err)
+	require.NotNil(t, warning)
+	assert.True(t, warning.Expired)
+
+	// Without a charge time the payment intent's update time is used
+	pi.Charges[0].Created = 0
+	pi.Updated = quote.FetchedAt.Unix()
+	warning, err = q.CheckPaymentIntent(quote, pi)
+	require.NoError(t, err)
+	assert.Nil(t, warning)
+}
+
+func TestRefreshBeforeConfirming(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	source := &fakeSource{rates: []*developer.ExchangeRate{{AssetId: "USDC_ETH", Rate: decimal.NewFromInt(1)}}}
+	q := newTestQuoter(source, nil, &now)
+	quote, err := q.Quote(decimal.NewFromInt(100), "USD", cryptoAsset("USDC_ETH", "USDC", 6))
+	require.NoError(t, err)
+	require.NoError(t, quote.Validate(now))
+
+	fresh, err := q.Refresh(quote)
+	require.NoError(t, err)
+	assert.Same(t, quote, fresh, "a valid quote is kept")
+
+	now = now.Add(DefaultTTL)
+	assert.ErrorIs(t, quote.Validate(now), ErrQuoteExpired)
+	source.rates = []*developer.ExchangeRate{{AssetId: "USDC_ETH", Rate: decimal.RequireFromString("0.8")}}
+	fresh, err = q.Refresh(quote)
+	require.NoError(t, err)
+	assert.NotSame(t, quote, fresh)
+	assert.Equal(t, "125", fresh.CryptoAmount.String())
+	assert.Equal(t, "USDC", fresh.Token)
+	assert.Equal(t, now.Add(DefaultTTL), fresh.ExpiresAt)
+	assert.NoError(t, fresh.Validate(now))
+
+	source.rates = nil
+	now = now.Add(DefaultTTL)
+	_, err = q.Refresh(fresh)
+	assert.ErrorIs(t, err, ErrNoRate)
+}
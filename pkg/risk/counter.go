@@ -0,0 +1,100 @@
+// counter.go contains the counter store used by velocity and amount history rules.
+// The engine records one observation per payment intent in each series it tracks (for
+// example all payment intents of a customer) and reads them back by time. Recording is
+// idempotent by observation ID, so the same payment intent seen in several webhook events
+// is counted once. Implement CounterStore on Redis or a database to share counters
+// between processes.
+package risk
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultRetention is how long MemoryCounterStore keeps observations when Retention is not set
+const DefaultRetention = 30 * 24 * time.Hour
+
+// Observation is one payment intent recorded in a series
+type Observation struct {
+	// ID identifies the payment intent; a series holds each ID once
+	ID string `json:"id"`
+	// Time is when the payment intent was created
+	Time time.Time `json:"time"`
+	// Value is the payment intent amount, used by amount history rules
+	Value decimal.Decimal `json:"value"`
+}
+
+// CounterStore keeps series of observations.
+// Implementations must be safe for concurrent use by multiple goroutines.
+type CounterStore interface {
+	// Record adds an observation to the series. It returns false without error when the
+	// series already holds an observation with the same ID.
+	Record(key string, obs Observation) (bool, error)
+	// Since returns the observations of the series at or after since, oldest first
+	Since(key string, since time.Time) ([]Observation, error)
+}
+
+// MemoryCounterStore keeps observations in memory and drops them after Retention
+type MemoryCounterStore struct {
+	// Retention is how long observations are kept; defaults to DefaultRetention
+	Retention time.Duration
+
+	mu     sync.Mutex
+	series map[string][]Observation
+}
+
+// NewMemoryCounterStore creates an empty in-memory counter store
+func NewMemoryCounterStore() *MemoryCounterStore {
+	return &MemoryCounterStore{series: make(map[string][]Observation)}
+}
+
+// Record adds an observation unless its ID is already in the series
+func (s *MemoryCounterStore) Record(key string, obs Observation) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.series == nil {
+		s.series = make(map[string][]Observation)
+	}
+
+	list := s.prune(key, obs.Time)
+	for _, existing := range list {
+		if existing.ID == obs.ID {
+			return false, nil
+		}
+	}
+	i := sort.Search(len(list), func(i int) bool { return list[i].Time.After(obs.Time) })
+	list = append(list, Observation{})
+	copy(list[i+1:], list[i:])
+	list[i] = obs
+	s.series[key] = list
+	return true, nil
+}
+
+// Since returns the observations of the series at or after since, oldest first
+func (s *MemoryCounterStore) Since(key string, since time.Time) ([]Observation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := s.series[key]
+	i := sort.Search(len(list), func(i int) bool { return !list[i].Time.Before(since) })
+	return append([]Observation(nil), list[i:]...), nil
+}
+
+// prune drops observations older than the retention relative to now and returns the remaining series.
+// Callers must hold s.mu.
+func (s *MemoryCounterStore) prune(key string, now time.Time) []Observation {
+	retention := s.Retention
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	list := s.series[key]
+	cutoff := now.Add(-retention)
+	i := sort.Search(len(list), func(i int) bool { return !list[i].Time.Before(cutoff) })
+	if i > 0 {
+		list = append(list[:0:0], list[i:]...)
+		s.series[key] = list
+	}
+	return list
+}
@@ -0,0 +1,405 @@
+// engine.go contains the embeddable risk rules engine.
+// The engine scores a payment intent against the configured rules, using the customer,
+// amount, and the review data of its charges (IP address, geolocation, browser session),
+// and explains every rule that hit. It also implements Policy, so it can be chained with
+// the AML ThresholdPolicy and drive the same fulfillment hooks.
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/shopspring/decimal"
+)
+
+// ErrUnsupportedEvent is returned by ScoreEvent for events that do not carry a payment intent
+var ErrUnsupportedEvent = errors.New("risk: event does not carry a payment intent")
+
+// Subject is the data a payment intent is scored on
+type Subject struct {
+	// ID is the payment intent ID
+	ID string
+	// CustomerID is the customer ID, if known
+	CustomerID string
+	// Email is the receipt or customer email
+	Email string
+	// Amount is the payment intent amount
+	Amount decimal.Decimal
+	// Currency is the currency of Amount
+	Currency string
+	// IPAddress is the IP address the payment came from
+	IPAddress string
+	// IPCountry is the two-letter country code of IPAddress
+	IPCountry string
+	// ShippingCountry is the two-letter country code of the shipping address. Payment intents
+	// do not return their shipping address, so set it from the order or the create request.
+	ShippingCountry string
+	// Browser is the browser of the payment session
+	Browser string
+	// Device is the device of the payment session
+	Device string
+	// Time is when the payment intent was created
+	Time time.Time
+}
+
+// NewSubject collects the scoring data of a payment intent.
+// Session and IP data are taken from the most recent charge with a review.
+//
+// Parameters:
+//   - pi: The payment intent
+//
+// Returns:
+//   - *Subject: The scoring data; ShippingCountry is left empty
+func NewSubject(pi *developer.PaymentIntent) *Subject {
+	s := &Subject{ID: pi.ID, Currency: pi.Currency, Email: pi.ReceiptEmail}
+	if pi.Created > 0 {
+		s.Time = time.Unix(pi.Created, 0)
+	}
+	if pi.Amount != nil {
+		s.Amount = pi.Amount.Amount
+		if s.Currency == "" {
+			s.Currency = pi.Amount.AssetId
+		}
+	}
+	if pi.Customer != nil {
+		s.CustomerID = pi.Customer.ID
+		if s.Email == "" && pi.Customer.Email != nil {
+			s.Email = *pi.Customer.Email
+		}
+	}
+
+	var review *developer.Review
+	var reviewed int64
+	for _, charge := range pi.Charges {
+		if charge != nil && charge.Review != nil && (review == nil || charge.Created >= reviewed) {
+			review, reviewed = charge.Review, charge.Created
+		}
+	}
+	if review != nil {
+		s.IPAddress = review.IPAddress
+		if review.IPAddressLocation != nil {
+			s.IPCountry = review.IPAddressLocation.Country
+		}
+		if review.Session != nil {
+			s.Browser = review.Session.Browser
+			s.Device = review.Session.Device
+		}
+	}
+	return s
+}
+
+// value returns the subject attribute a velocity rule groups by
+func (s *Subject) value(key VelocityKey) string {
+	switch key {
+	case VelocityKeyCustomer:
+		return s.CustomerID
+	case VelocityKeyEmail:
+		return strings.ToLower(s.Email)
+	case VelocityKeyIP:
+		return s.IPAddress
+	case VelocityKeyDevice:
+		if s.Device == "" {
+			return ""
+		}
+		return s.Device + "|" + s.Browser
+	}
+	return ""
+}
+
+// RuleHit explains one rule that matched
+type RuleHit struct {
+	// Rule is the name of the rule
+	Rule string `json:"rule"`
+	// Type is the kind of check
+	Type RuleType `json:"type"`
+	// Score is the score the rule added
+	Score float64 `json:"score"`
+	// Tags are the tags the rule attached
+	Tags []string `json:"tags,omitempty"`
+	// Explanation describes why the rule matched
+	Explanation string `json:"explanation"`
+}
+
+// Result is the outcome of scoring a payment intent
+type Result struct {
+	// SubjectID is the payment intent ID
+	SubjectID string `json:"subject_id"`
+	// Score is the sum of the scores of all hits
+	Score float64 `json:"score"`
+	// Decision follows from Score and the configured thresholds
+	Decision Decision `json:"decision"`
+	// Tags are the distinct tags of all hits
+	Tags []string `json:"tags,omitempty"`
+	// Hits are the rules that matched, in config order
+	Hits []*RuleHit `json:"hits,omitempty"`
+}
+
+// HasTag reports whether a hit attached the tag
+func (r *Result) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Engine scores payment intents against a rule configuration
+type Engine struct {
+	cfg   *Config
+	store CounterStore
+	now   func() time.Time
+}
+
+// NewEngine creates a rules engine.
+//
+// Parameters:
+//   - cfg: The rule configuration
+//   - store: The counter store for velocity and amount history rules; an in-memory store is used when nil
+//
+// Returns:
+//   - *Engine: The engine
+//   - error: Error if the configuration is invalid
+func NewEngine(cfg *Config, store CounterStore) (*Engine, error) {
+	if cfg == nil {
+		return nil, errors.New("risk: config is required")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if store == nil {
+		store = NewMemoryCounterStore()
+	}
+	return &Engine{cfg: cfg, store: store, now: time.Now}, nil
+}
+
+// Score records the subject in the counter store and evaluates every enabled rule.
+// Scoring the same payment intent again, for example from a later webhook event, does not
+// count it twice.
+//
+// Parameters:
+//   - s: The scoring data
+//
+// Returns:
+//   - *Result: The score, decision, tags, and rule hits
+//   - error: Error from the counter store
+func (e *Engine) Score(s *Subject) (*Result, error) {
+	if s.ID == "" {
+		return nil, errors.New("risk: subject ID is required")
+	}
+	if s.Time.IsZero() {
+		s.Time = e.now()
+	}
+	if err := e.record(s); err != nil {
+		return nil, err
+	}
+
+	result := &Result{SubjectID: s.ID, Decision: DecisionFulfill}
+	seenTags := make(map[string]bool)
+	for _, rule := range e.cfg.Rules {
+		if rule.Disabled {
+			continue
+		}
+		explanation, err := e.check(rule, s)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating rule %q: %v", rule.Name, err)
+		}
+		if explanation == "" {
+			continue
+		}
+		hit := &RuleHit{Rule: rule.Name, Type: rule.Type, Score: rule.Score, Tags: rule.tags(), Explanation: explanation}
+		result.Hits = append(result.Hits, hit)
+		result.Score += rule.Score
+		for _, tag := range hit.Tags {
+			if !seenTags[tag] {
+				seenTags[tag] = true
+				result.Tags = append(result.Tags, tag)
+			}
+		}
+	}
+
+	switch {
+	case e.cfg.RefundScore > 0 && result.Score >= e.cfg.RefundScore:
+		result.Decision = DecisionRefund
+	case e.cfg.HoldScore > 0 && result.Score >= e.cfg.HoldScore:
+		result.Decision = DecisionHold
+	}
+	return result, nil
+}
+
+// ScorePaymentIntent scores a payment intent.
+//
+// Parameters:
+//   - pi: The payment intent
+//   - shippingCountry: The shipping country from the order or create request, or "" if unknown
+//
+// Returns:
+//   - *Result: The score, decision, tags, and rule hits
+//   - error: Error from the counter store
+func (e *Engine) ScorePaymentIntent(pi *developer.PaymentIntent, shippingCountry string) (*Result, error) {
+	s := NewSubject(pi)
+	s.ShippingCountry = shippingCountry
+	return e.Score(s)
+}
+
+// ScoreEvent scores the payment intent carried by a webhook event.
+//
+// Parameters:
+//   - event: A payment_intent.* webhook event
+//
+// Returns:
+//   - *Result: The score, decision, tags, and rule hits
+//   - error: ErrUnsupportedEvent for other events, or an error decoding the event
+func (e *Engine) ScoreEvent(event *developer.Event) (*Result, error) {
+	if !strings.HasPrefix(string(event.Type), "payment_intent.") {
+		return nil, ErrUnsupportedEvent
+	}
+	obj, err := event.DecodeObject()
+	if err != nil {
+		return nil, err
+	}
+	pi, ok := obj.(*developer.PaymentIntent)
+	if !ok {
+		return nil, ErrUnsupportedEvent
+	}
+	s := NewSubject(pi)
+	if s.Time.IsZero() && event.Created > 0 {
+		s.Time = time.Unix(event.Created, 0)
+	}
+	return e.Score(s)
+}
+
+// Evaluate scores the payment intent as a Policy, with each rule hit as a reason
+func (e *Engine) Evaluate(pi *developer.PaymentIntent) (*Assessment, error) {
+	result, err := e.ScorePaymentIntent(pi, "")
+	if err != nil {
+		return nil, err
+	}
+	a := &Assessment{Decision: result.Decision}
+	for _, hit := range result.Hits {
+		a.Reasons = append(a.Reasons, fmt.Sprintf("%s: %s", hit.Rule, hit.Explanation))
+	}
+	return a, nil
+}
+
+// record adds the subject to every series used by the rules
+func (e *Engine) record(s *Subject) error {
+	obs := Observation{ID: s.ID, Time: s.Time, Value: s.Amount}
+	recorded := make(map[string]bool)
+	for _, rule := range e.cfg.Rules {
+		if rule.Disabled {
+			continue
+		}
+		key := seriesKey(rule, s)
+		if key == "" || recorded[key] {
+			continue
+		}
+		recorded[key] = true
+		if _, err := e.store.Record(key, obs); err != nil {
+			return fmt.Errorf("error recording %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// seriesKey returns the counter series a rule reads for the subject, or "" if it uses none
+func seriesKey(rule *Rule, s *Subject) string {
+	switch rule.Type {
+	case RuleTypeVelocity:
+		if value := s.value(rule.Key); value != "" {
+			return fmt.Sprintf("velocity:%s:%s", rule.Key, value)
+		}
+	case RuleTypeAmountSpike:
+		if s.CustomerID != "" && s.Currency != "" {
+			return fmt.Sprintf("amount:%s:%s", s.CustomerID, strings.ToUpper(s.Currency))
+		}
+	}
+	return ""
+}
+
+// check evaluates one rule and returns its explanation, or "" if it did not match
+func (e *Engine) check(rule *Rule, s *Subject) (string, error) {
+	switch rule.Type {
+	case RuleTypeVelocity:
+		return e.checkVelocity(rule, s)
+	case RuleTypeAmountSpike:
+		return e.checkAmountSpike(rule, s)
+	case RuleTypeCountryMismatch:
+		if s.IPCountry != "" && s.ShippingCountry != "" && !strings.EqualFold(s.IPCountry, s.ShippingCountry) {
+			return fmt.Sprintf("IP country %s differs from shipping country %s", strings.ToUpper(s.IPCountry), strings.ToUpper(s.ShippingCountry)), nil
+		}
+	case RuleTypeIPCountry:
+		for _, country := range rule.Countries {
+			if s.IPCountry != "" && strings.EqualFold(country, s.IPCountry) {
+				return fmt.Sprintf("IP country %s is listed", strings.ToUpper(s.IPCountry)), nil
+			}
+		}
+	case RuleTypeAmountAbove:
+		if rule.Currency != "" && !strings.EqualFold(rule.Currency, s.Currency) {
+			return "", nil
+		}
+		limit, _ := decimal.NewFromString(rule.Amount)
+		if s.Amount.GreaterThan(limit) {
+			return fmt.Sprintf("amount %s %s is above %s", s.Amount, s.Currency, limit), nil
+		}
+	}
+	return "", nil
+}
+
+// checkVelocity counts the subject's group within the window ending at the subject's time
+func (e *Engine) checkVelocity(rule *Rule, s *Subject) (string, error) {
+	key := seriesKey(rule, s)
+	if key == "" {
+		return "", nil
+	}
+	window := time.Duration(rule.Window)
+	observations, err := e.store.Since(key, s.Time.Add(-window))
+	if err != nil {
+		return "", err
+	}
+	count := 0
+	for _, obs := range observations {
+		if !obs.Time.After(s.Time) {
+			count++
+		}
+	}
+	if count > rule.Max {
+		return fmt.Sprintf("%d payment intents for %s %s within %s (max %d)", count, rule.Key, s.value(rule.Key), window, rule.Max), nil
+	}
+	return "", nil
+}
+
+// checkAmountSpike compares the amount with the average of the customer's other payment intents
+func (e *Engine) checkAmountSpike(rule *Rule, s *Subject) (string, error) {
+	key := seriesKey(rule, s)
+	if key == "" {
+		return "", nil
+	}
+	observations, err := e.store.Since(key, time.Time{})
+	if err != nil {
+		return "", err
+	}
+	var history []decimal.Decimal
+	for _, obs := range observations {
+		if obs.ID != s.ID {
+			history = append(history, obs.Value)
+		}
+	}
+	minHistory := rule.MinHistory
+	if minHistory == 0 {
+		minHistory = 1
+	}
+	if len(history) < minHistory {
+		return "", nil
+	}
+	average := decimal.Avg(history[0], history[1:]...)
+	limit := average.Mul(decimal.NewFromFloat(rule.Multiplier))
+	if s.Amount.GreaterThan(limit) {
+		return fmt.Sprintf("amount %s %s is above %g times the customer's average of %s over %d payments",
+			s.Amount, s.Currency, rule.Multiplier, average.Round(2), len(history)), nil
+	}
+	return "", nil
+}
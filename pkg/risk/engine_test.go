@@ -0,0 +1,111 @@
+// engine_test.go contains unit tests for the risk rules engine.
+package risk
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRules = `{
+  "hold_score": 30,
+  "refund_score": 80,
+  "rules": [
+    {"name": "customer_velocity", "type": "velocity", "key": "customer", "window": "1h", "max": 2, "score": 20},
+    {"name": "ip_shipping_mismatch", "type": "country_mismatch", "score": 15, "tags": ["geo"]},
+    {"name": "amount_spike", "type": "amount_spike", "multiplier": 3, "min_history": 2, "score": 25},
+    {"name": "blocked_country", "type": "ip_country", "countries": ["kp"], "score": 100, "tags": ["geo", "blocked"]},
+    {"name": "large_amount", "type": "amount_above", "amount": "1000", "currency": "USD", "score": 5, "disabled": true}
+  ]
+}`
+
+func testIntent(id string, amount int64, created time.Time, country string) *developer.PaymentIntent {
+	return &developer.PaymentIntent{
+		ID:       id,
+		Currency: "USD",
+		Amount:   developer.NewAssetAmount(decimal.NewFromInt(amount), "USD", 2),
+		Created:  created.Unix(),
+		Customer: &developer.Customer{ID: "cus_1"},
+		Charges: []*developer.Charge{{Review: &developer.Review{
+			IPAddress:         "203.0.113.7",
+			IPAddressLocation: &developer.ReviewIPAddressLocation{Country: country},
+		}}},
+	}
+}
+
+func TestEngineScore(t *testing.T) {
+	cfg, err := LoadConfig(strings.NewReader(testRules))
+	require.NoError(t, err)
+	engine, err := NewEngine(cfg, nil)
+	require.NoError(t, err)
+
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"pi_1", "pi_2"} {
+		result, err := engine.ScorePaymentIntent(testIntent(id, 100, start.Add(time.Duration(i)*time.Minute), "US"), "US")
+		require.NoError(t, err)
+		assert.Empty(t, result.Hits, id)
+		assert.Equal(t, DecisionFulfill, result.Decision)
+	}
+
+	// Scoring the same intent again does not count it twice
+	result, err := engine.ScorePaymentIntent(testIntent("pi_2", 100, start.Add(time.Minute), "US"), "US")
+	require.NoError(t, err)
+	assert.Empty(t, result.Hits)
+
+	result, err = engine.ScorePaymentIntent(testIntent("pi_3", 500, start.Add(2*time.Minute), "DE"), "US")
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 3)
+	assert.Equal(t, []string{"customer_velocity", "ip_shipping_mismatch", "amount_spike"},
+		[]string{result.Hits[0].Rule, result.Hits[1].Rule, result.Hits[2].Rule})
+	assert.Contains(t, result.Hits[0].Explanation, "3 payment intents for customer cus_1")
+	assert.Contains(t, result.Hits[2].Explanation, "average of 100")
+	assert.Equal(t, float64(60), result.Score)
+	assert.Equal(t, DecisionHold, result.Decision)
+	assert.True(t, result.HasTag("geo"))
+
+	// Outside the velocity window
+	result, err = engine.ScorePaymentIntent(testIntent("pi_4", 100, start.Add(2*time.Hour), "KP"), "")
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "blocked_country", result.Hits[0].Rule)
+	assert.Equal(t, DecisionRefund, result.Decision)
+}
+
+func TestEngineScoreEvent(t *testing.T) {
+	cfg, err := LoadConfig(strings.NewReader(testRules))
+	require.NoError(t, err)
+	engine, err := NewEngine(cfg, NewMemoryCounterStore())
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(testIntent("pi_1", 100, time.Now(), "KP"))
+	require.NoError(t, err)
+	event := &developer.Event{ID: "evt_1", Type: developer.EventTypePaymentIntentCreated, Data: &developer.EventData{Raw: raw}}
+	result, err := engine.ScoreEvent(event)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionRefund, result.Decision)
+
+	_, err = engine.ScoreEvent(&developer.Event{Type: "payout.created"})
+	assert.Equal(t, ErrUnsupportedEvent, err)
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	_, err := LoadConfig(strings.NewReader(`{"rules": [
+		{"name": "a", "type": "velocity", "key": "wallet", "window": "1h"},
+		{"name": "a", "type": "amount_spike"},
+		{"name": "c", "type": "nope"}
+	]}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown velocity key "wallet"`)
+	assert.Contains(t, err.Error(), `rule name "a" is used more than once`)
+	assert.Contains(t, err.Error(), `unknown rule type "nope"`)
+
+	var d Duration
+	require.NoError(t, json.Unmarshal([]byte(`90`), &d))
+	assert.Equal(t, 90*time.Second, time.Duration(d))
+}
@@ -0,0 +1,261 @@
+// Package risk decides whether a paid payment intent may be fulfilled.
+// A Policy reads the AML screening results of the payment's transactions and returns a
+// Decision: fulfill the order, hold it for manual review, or refund the customer. Policies
+// are pluggable; ThresholdPolicy covers score thresholds and rule names, and Chain combines
+// several policies so the most severe decision wins. Engine adds velocity, geolocation,
+// and amount rules loaded from a declarative config, backed by a pluggable CounterStore.
+//
+// Example usage:
+//
+//	policy := &risk.ThresholdPolicy{HoldScore: 5, RefundScore: 8, RefundRules: []string{"sanctions"}}
+//	hooks := &risk.Hooks{OnFulfill: ship, OnHold: queueForReview, OnRefund: refund}
+//	assessment, err := hooks.Apply(policy, paymentIntent)
+package risk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// Decision is what to do with a payment intent
+type Decision string
+
+const (
+	// DecisionFulfill allows the order to be fulfilled
+	DecisionFulfill Decision = "fulfill"
+	// DecisionHold keeps the order for manual review
+	DecisionHold Decision = "hold"
+	// DecisionRefund returns the payment to the customer instead of fulfilling
+	DecisionRefund Decision = "refund"
+)
+
+// severity orders decisions from least to most restrictive
+func (d Decision) severity() int {
+	switch d {
+	case DecisionFulfill:
+		return 0
+	case DecisionHold:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Assessment is the outcome of a policy for a payment intent
+type Assessment struct {
+	// Decision is what to do with the payment intent
+	Decision Decision `json:"decision"`
+	// Reasons explains every condition that led to a hold or refund
+	Reasons []string `json:"reasons,omitempty"`
+	// MaxScore is the highest AML score among the payment's transactions
+	MaxScore float64 `json:"max_score"`
+	// RuleNames lists the distinct AML rules triggered by the payment's transactions
+	RuleNames []string `json:"rule_names,omitempty"`
+}
+
+// escalate raises the decision if d is more severe and records the reason
+func (a *Assessment) escalate(d Decision, reason string) {
+	if d.severity() > a.Decision.severity() {
+		a.Decision = d
+	}
+	a.Reasons = append(a.Reasons, reason)
+}
+
+// Policy decides whether a payment intent may be fulfilled
+type Policy interface {
+	// Evaluate assesses the payment intent
+	Evaluate(pi *developer.PaymentIntent) (*Assessment, error)
+}
+
+// PolicyFunc adapts a function to the Policy interface
+type PolicyFunc func(pi *developer.PaymentIntent) (*Assessment, error)
+
+// Evaluate calls f(pi)
+func (f PolicyFunc) Evaluate(pi *developer.PaymentIntent) (*Assessment, error) {
+	return f(pi)
+}
+
+// ThresholdPolicy decides by AML scores and triggered rule names.
+// Scores at or above RefundScore, or a rule in RefundRules, lead to a refund; scores at or
+// above HoldScore, a rule in HoldRules, or a transaction rejected by AML screening lead to
+// a hold. A zero threshold is not checked. As a fulfillment guard it fails closed: a payment
+// intent without incoming transactions (for example a webhook payload whose charges are not
+// expanded) and transactions that have not been screened yet are held unless allowed.
+// Card charges settle off-chain and are not AML screened, so they are exempt from both checks.
+type ThresholdPolicy struct {
+	// HoldScore is the AML score from which a payment is held for review
+	HoldScore float64
+	// RefundScore is the AML score from which a payment is refunded
+	RefundScore float64
+	// HoldRules are AML rule names that hold a payment, compared ignoring case
+	HoldRules []string
+	// RefundRules are AML rule names that refund a payment, compared ignoring case
+	RefundRules []string
+	// AllowUnscreened fulfills payments with transactions that have not been AML screened yet
+	// instead of holding them
+	AllowUnscreened bool
+	// Fulfillment, when set, also holds payments that are not settled enough to fulfill under it
+	Fulfillment developer.FulfillmentPolicy
+}
+
+// Evaluate assesses the incoming transactions of the payment intent
+func (p *ThresholdPolicy) Evaluate(pi *developer.PaymentIntent) (*Assessment, error) {
+	a := &Assessment{Decision: DecisionFulfill}
+	seenRules := make(map[string]bool)
+
+	incoming, offChain := 0, 0
+	for _, charge := range pi.Charges {
+		if charge == nil {
+			continue
+		}
+		if !isOnChain(charge.PaymentMethodType) {
+			offChain++
+			continue
+		}
+		for _, tx := range charge.Transactions {
+			if tx == nil || tx.Type == "1" {
+				continue
+			}
+			incoming++
+			result, err := tx.AmlResult()
+			if err != nil {
+				return nil, fmt.Errorf("error reading AML info of transaction %s: %v", tx.TxHash, err)
+			}
+			p.assessTransaction(a, tx.TxHash, result)
+			for _, rule := range result.Info.RuleNames {
+				if key := strings.ToLower(rule); !seenRules[key] {
+					seenRules[key] = true
+					a.RuleNames = append(a.RuleNames, rule)
+				}
+			}
+		}
+	}
+
+	if incoming == 0 && offChain == 0 {
+		a.escalate(DecisionHold, fmt.Sprintf("payment intent %s has no incoming transactions to assess", pi.ID))
+	}
+	if p.Fulfillment != "" && !pi.PaymentIntentStatus.IsSafeToFulfill(p.Fulfillment) {
+		a.escalate(DecisionHold, fmt.Sprintf("payment intent status %s is not settled under the %s policy", pi.PaymentIntentStatus, p.Fulfillment))
+	}
+	return a, nil
+}
+
+// assessTransaction applies the thresholds and rules to one transaction
+func (p *ThresholdPolicy) assessTransaction(a *Assessment, txHash string, result *developer.AmlResult) {
+	score := result.Info.Score
+	if score > a.MaxScore {
+		a.MaxScore = score
+	}
+	switch {
+	case p.RefundScore > 0 && score >= p.RefundScore:
+		a.escalate(DecisionRefund, fmt.Sprintf("transaction %s has AML score %g (refund threshold %g)", txHash, score, p.RefundScore))
+	case p.HoldScore > 0 && score >= p.HoldScore:
+		a.escalate(DecisionHold, fmt.Sprintf("transaction %s has AML score %g (hold threshold %g)", txHash, score, p.HoldScore))
+	}
+	for _, rule := range result.Info.RuleNames {
+		switch {
+		case containsFold(p.RefundRules, rule):
+			a.escalate(DecisionRefund, fmt.Sprintf("transaction %s triggered AML rule %q", txHash, rule))
+		case containsFold(p.HoldRules, rule):
+			a.escalate(DecisionHold, fmt.Sprintf("transaction %s triggered AML rule %q", txHash, rule))
+		}
+	}
+	if result.Status.IsRejected() {
+		a.escalate(DecisionHold, fmt.Sprintf("transaction %s was rejected by AML screening and its funds are frozen", txHash))
+	} else if !p.AllowUnscreened && !result.Status.IsScreened() {
+		a.escalate(DecisionHold, fmt.Sprintf("transaction %s has not been AML screened", txHash))
+	}
+}
+
+// isOnChain reports whether charges of the given payment method type are paid by on-chain
+// transactions. An unknown or missing type counts as on-chain so that the policy fails closed.
+func isOnChain(t developer.PaymentMethodType) bool {
+	switch t {
+	case developer.PaymentMethodTypeCards, developer.PaymentMethodTypeOthers:
+		return false
+	}
+	return true
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Chain evaluates every policy and returns the most severe decision with all reasons
+func Chain(policies ...Policy) Policy {
+	return PolicyFunc(func(pi *developer.PaymentIntent) (*Assessment, error) {
+		combined := &Assessment{Decision: DecisionFulfill}
+		seenRules := make(map[string]bool)
+		for _, policy := range policies {
+			a, err := policy.Evaluate(pi)
+			if err != nil {
+				return nil, err
+			}
+			if a.Decision.severity() > combined.Decision.severity() {
+				combined.Decision = a.Decision
+			}
+			combined.Reasons = append(combined.Reasons, a.Reasons...)
+			if a.MaxScore > combined.MaxScore {
+				combined.MaxScore = a.MaxScore
+			}
+			for _, rule := range a.RuleNames {
+				if key := strings.ToLower(rule); !seenRules[key] {
+					seenRules[key] = true
+					combined.RuleNames = append(combined.RuleNames, rule)
+				}
+			}
+		}
+		return combined, nil
+	})
+}
+
+// Hooks are the actions taken for each decision
+type Hooks struct {
+	// OnFulfill is called when the payment may be fulfilled
+	OnFulfill func(pi *developer.PaymentIntent, a *Assessment) error
+	// OnHold is called when the payment needs manual review
+	OnHold func(pi *developer.PaymentIntent, a *Assessment) error
+	// OnRefund is called when the payment should be refunded; if nil, OnHold is called instead
+	OnRefund func(pi *developer.PaymentIntent, a *Assessment) error
+}
+
+// Apply evaluates the policy and calls the hook for its decision.
+// A missing hook is skipped, except that a refund without OnRefund falls back to OnHold,
+// so a payment is never fulfilled by accident.
+//
+// Parameters:
+//   - policy: The policy to evaluate
+//   - pi: The payment intent to assess
+//
+// Returns:
+//   - *Assessment: The policy's assessment
+//   - error: Error from the policy or the hook
+func (h *Hooks) Apply(policy Policy, pi *developer.PaymentIntent) (*Assessment, error) {
+	a, err := policy.Evaluate(pi)
+	if err != nil {
+		return nil, err
+	}
+	hook := h.OnHold
+	switch a.Decision {
+	case DecisionFulfill:
+		hook = h.OnFulfill
+	case DecisionRefund:
+		if h.OnRefund != nil {
+			hook = h.OnRefund
+		}
+	}
+	if hook != nil {
+		if err := hook(pi, a); err != nil {
+			return a, err
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,121 @@
+// policy_test.go contains unit tests for the fulfillment risk policies.
+package risk
+
+import (
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func paymentWith(status developer.PaymentIntentStatus, txs ...*developer.TransactionDetails) *developer.PaymentIntent {
+	return &developer.PaymentIntent{ID: "pi_1", PaymentIntentStatus: status, Charges: []*developer.Charge{{Transactions: txs}}}
+}
+
+func tx(hash, aml, info string) *developer.TransactionDetails {
+	return &developer.TransactionDetails{TxHash: hash, Type: "0", Status: "confirmed", AmlStatus: aml, AmlInfo: &info}
+}
+
+func TestThresholdPolicy(t *testing.T) {
+	policy := &ThresholdPolicy{HoldScore: 5, RefundScore: 8, HoldRules: []string{"gambling"}, RefundRules: []string{"sanctions"}}
+
+	tests := []struct {
+		name     string
+		pi       *developer.PaymentIntent
+		decision Decision
+	}{
+		{"clean", paymentWith(developer.PaymentIntentStatusConfirmed, tx("a", "approved", "score=1")), DecisionFulfill},
+		{"hold score", paymentWith(developer.PaymentIntentStatusConfirmed, tx("a", "approved", "score=5")), DecisionHold},
+		{"hold rule", paymentWith(developer.PaymentIntentStatusConfirmed, tx("a", "approved", "Gambling")), DecisionHold},
+		{"refund score", paymentWith(developer.PaymentIntentStatusConfirmed, tx("a", "approved", "score=2"), tx("b", "approved", "score=9")), DecisionRefund},
+		{"refund rule", paymentWith(developer.PaymentIntentStatusConfirmed, tx("a", "approved", "gambling;sanctions")), DecisionRefund},
+		{"rejected", paymentWith(developer.PaymentIntentStatusFrozen, tx("a", "rejected", "")), DecisionHold},
+	}
+	for _, tt := range tests {
+		a, err := policy.Evaluate(tt.pi)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.decision, a.Decision, tt.name)
+		if tt.decision != DecisionFulfill {
+			assert.NotEmpty(t, a.Reasons, tt.name)
+		}
+	}
+
+	strict := &ThresholdPolicy{Fulfillment: developer.FulfillOnConfirmed}
+	a, err := strict.Evaluate(paymentWith(developer.PaymentIntentStatusPaid, tx("a", "", "")))
+	require.NoError(t, err)
+	assert.Equal(t, DecisionHold, a.Decision)
+	assert.Len(t, a.Reasons, 2)
+}
+
+func TestThresholdPolicyFailsClosed(t *testing.T) {
+	policy := &ThresholdPolicy{HoldScore: 5}
+
+	// Charges not expanded in a webhook payload
+	a, err := policy.Evaluate(&developer.PaymentIntent{ID: "pi_1", PaymentIntentStatus: developer.PaymentIntentStatusConfirmed})
+	require.NoError(t, err)
+	assert.Equal(t, DecisionHold, a.Decision)
+
+	// Only a refund transaction
+	refund := tx("r", "approved", "")
+	refund.Type = "1"
+	a, err = policy.Evaluate(paymentWith(developer.PaymentIntentStatusConfirmed, refund))
+	require.NoError(t, err)
+	assert.Equal(t, DecisionHold, a.Decision)
+
+	a, err = policy.Evaluate(paymentWith(developer.PaymentIntentStatusConfirmed, tx("a", "", "")))
+	require.NoError(t, err)
+	assert.Equal(t, DecisionHold, a.Decision)
+
+	policy.AllowUnscreened = true
+	a, err = policy.Evaluate(paymentWith(developer.PaymentIntentStatusConfirmed, tx("a", "", "")))
+	require.NoError(t, err)
+	assert.Equal(t, DecisionFulfill, a.Decision)
+}
+
+func TestThresholdPolicyCardCharges(t *testing.T) {
+	policy := &ThresholdPolicy{HoldScore: 5, Fulfillment: developer.FulfillOnConfirmed}
+	card := &developer.PaymentIntent{ID: "pi_1", PaymentIntentStatus: developer.PaymentIntentStatusConfirmed, Charges: []*developer.Charge{
+		{ID: "ch_1", PaymentMethodType: developer.PaymentMethodTypeCards},
+	}}
+
+	// Card charges carry no on-chain transactions to screen
+	a, err := policy.Evaluate(card)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionFulfill, a.Decision, a.Reasons)
+
+	// The settlement guard still applies
+	card.PaymentIntentStatus = developer.PaymentIntentStatusPaid
+	a, err = policy.Evaluate(card)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionHold, a.Decision)
+
+	// An earlier crypto charge with a high-risk transaction is still assessed
+	card.PaymentIntentStatus = developer.PaymentIntentStatusConfirmed
+	card.Charges = append(card.Charges, &developer.Charge{ID: "ch_0", PaymentMethodType: developer.PaymentMethodTypeCrypto,
+		Transactions: []*developer.TransactionDetails{tx("a", "approved", "score=7")}})
+	a, err = policy.Evaluate(card)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionHold, a.Decision)
+}
+
+func TestHooks(t *testing.T) {
+	var called []string
+	record := func(name string) func(*developer.PaymentIntent, *Assessment) error {
+		return func(*developer.PaymentIntent, *Assessment) error {
+			called = append(called, name)
+			return nil
+		}
+	}
+	hooks := &Hooks{OnFulfill: record("fulfill"), OnHold: record("hold")}
+	policy := Chain(&ThresholdPolicy{RefundScore: 8}, &ThresholdPolicy{HoldRules: []string{"mixer"}})
+
+	a, err := hooks.Apply(policy, paymentWith(developer.PaymentIntentStatusConfirmed, tx("a", "approved", "score=9;mixer")))
+	require.NoError(t, err)
+	assert.Equal(t, DecisionRefund, a.Decision)
+	assert.Equal(t, []string{"mixer"}, a.RuleNames)
+
+	_, err = hooks.Apply(policy, paymentWith(developer.PaymentIntentStatusConfirmed, tx("a", "approved", "")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hold", "fulfill"}, called)
+}
@@ -0,0 +1,220 @@
+// rules.go contains the declarative rule configuration of the risk rules engine.
+// A Config is usually loaded from JSON:
+//
+//	{
+//	  "hold_score": 50,
+//	  "refund_score": 90,
+//	  "rules": [
+//	    {"name": "customer_velocity", "type": "velocity", "key": "customer", "window": "1h", "max": 5, "score": 40},
+//	    {"name": "ip_shipping_mismatch", "type": "country_mismatch", "score": 25, "tags": ["geo"]},
+//	    {"name": "amount_spike", "type": "amount_spike", "multiplier": 3, "min_history": 3, "score": 30},
+//	    {"name": "blocked_country", "type": "ip_country", "countries": ["KP", "IR"], "score": 100},
+//	    {"name": "large_amount", "type": "amount_above", "amount": "5000", "score": 10}
+//	  ]
+//	}
+package risk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RuleType is the kind of check a rule performs
+type RuleType string
+
+const (
+	// RuleTypeVelocity hits when more than Max payment intents share the same Key within Window
+	RuleTypeVelocity RuleType = "velocity"
+	// RuleTypeCountryMismatch hits when the IP country differs from the shipping country
+	RuleTypeCountryMismatch RuleType = "country_mismatch"
+	// RuleTypeAmountSpike hits when the amount exceeds the customer's average amount times Multiplier
+	RuleTypeAmountSpike RuleType = "amount_spike"
+	// RuleTypeAmountAbove hits when the amount exceeds Amount
+	RuleTypeAmountAbove RuleType = "amount_above"
+	// RuleTypeIPCountry hits when the IP country is one of Countries
+	RuleTypeIPCountry RuleType = "ip_country"
+)
+
+// VelocityKey is the attribute payment intents are grouped by in a velocity rule
+type VelocityKey string
+
+const (
+	// VelocityKeyCustomer groups payment intents by customer ID
+	VelocityKeyCustomer VelocityKey = "customer"
+	// VelocityKeyEmail groups payment intents by receipt or customer email
+	VelocityKeyEmail VelocityKey = "email"
+	// VelocityKeyIP groups payment intents by IP address
+	VelocityKeyIP VelocityKey = "ip"
+	// VelocityKeyDevice groups payment intents by browser session device
+	VelocityKeyDevice VelocityKey = "device"
+)
+
+// Duration is a time.Duration that reads from JSON as a string such as "1h" or "30m", or as a number of seconds
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		parsed, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %v", text, err)
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// MarshalJSON writes the duration as a string such as "1h0m0s"
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Rule is one configured check. Only the fields of its Type are used.
+type Rule struct {
+	// Name identifies the rule in hits and tags
+	Name string `json:"name"`
+	// Type is the kind of check
+	Type RuleType `json:"type"`
+	// Score is added to the total score when the rule hits
+	Score float64 `json:"score"`
+	// Tags are attached to the result when the rule hits; the rule name is used when empty
+	Tags []string `json:"tags,omitempty"`
+	// Disabled turns the rule off without removing it from the config
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Key is the grouping attribute of a velocity rule
+	Key VelocityKey `json:"key,omitempty"`
+	// Window is the time span of a velocity rule
+	Window Duration `json:"window,omitempty"`
+	// Max is the number of payment intents a velocity rule allows within Window
+	Max int `json:"max,omitempty"`
+
+	// Multiplier is the factor over the customer's average amount of an amount_spike rule
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// MinHistory is the number of earlier payment intents an amount_spike rule needs before it applies (default 1)
+	MinHistory int `json:"min_history,omitempty"`
+	// Amount is the threshold of an amount_above rule, in the payment intent's currency
+	Amount string `json:"amount,omitempty"`
+	// Currency restricts an amount_above rule to one currency; empty applies to all
+	Currency string `json:"currency,omitempty"`
+
+	// Countries are the two-letter country codes of an ip_country rule
+	Countries []string `json:"countries,omitempty"`
+}
+
+// Config is the declarative configuration of an Engine
+type Config struct {
+	// Rules are evaluated in order
+	Rules []*Rule `json:"rules"`
+	// HoldScore is the total score from which a payment intent is held (0 disables holding)
+	HoldScore float64 `json:"hold_score"`
+	// RefundScore is the total score from which a payment intent is refunded (0 disables refunding)
+	RefundScore float64 `json:"refund_score"`
+}
+
+// LoadConfig reads and validates a JSON rule configuration
+//
+// Parameters:
+//   - r: The JSON configuration
+//
+// Returns:
+//   - *Config: The parsed configuration
+//   - error: Error if the JSON is malformed or a rule is invalid
+func LoadConfig(r io.Reader) (*Config, error) {
+	var cfg Config
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("error parsing risk rules: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks that every rule has a unique name and the fields its type needs
+func (c *Config) Validate() error {
+	var problems []string
+	names := make(map[string]bool)
+	for i, rule := range c.Rules {
+		if rule == nil {
+			problems = append(problems, fmt.Sprintf("rule %d is empty", i))
+			continue
+		}
+		if rule.Name == "" {
+			problems = append(problems, fmt.Sprintf("rule %d has no name", i))
+		} else if names[rule.Name] {
+			problems = append(problems, fmt.Sprintf("rule name %q is used more than once", rule.Name))
+		}
+		names[rule.Name] = true
+		if err := rule.validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("rule %q: %v", rule.Name, err))
+		}
+	}
+	if c.HoldScore > 0 && c.RefundScore > 0 && c.RefundScore < c.HoldScore {
+		problems = append(problems, "refund_score must not be below hold_score")
+	}
+	if len(problems) > 0 {
+		return errors.New("invalid risk rules: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validate checks the fields used by the rule's type
+func (r *Rule) validate() error {
+	switch r.Type {
+	case RuleTypeVelocity:
+		switch r.Key {
+		case VelocityKeyCustomer, VelocityKeyEmail, VelocityKeyIP, VelocityKeyDevice:
+		default:
+			return fmt.Errorf("unknown velocity key %q", r.Key)
+		}
+		if r.Window <= 0 {
+			return errors.New("window must be positive")
+		}
+		if r.Max < 0 {
+			return errors.New("max must not be negative")
+		}
+	case RuleTypeAmountSpike:
+		if r.Multiplier <= 0 {
+			return errors.New("multiplier must be positive")
+		}
+		if r.MinHistory < 0 {
+			return errors.New("min_history must not be negative")
+		}
+	case RuleTypeAmountAbove:
+		if _, err := decimal.NewFromString(r.Amount); err != nil {
+			return fmt.Errorf("invalid amount %q", r.Amount)
+		}
+	case RuleTypeIPCountry:
+		if len(r.Countries) == 0 {
+			return errors.New("countries must not be empty")
+		}
+	case RuleTypeCountryMismatch:
+	default:
+		return fmt.Errorf("unknown rule type %q", r.Type)
+	}
+	return nil
+}
+
+// tags returns the tags attached when the rule hits
+func (r *Rule) tags() []string {
+	if len(r.Tags) > 0 {
+		return r.Tags
+	}
+	return []string{r.Name}
+}
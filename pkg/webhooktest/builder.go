@@ -0,0 +1,312 @@
+// Package webhooktest builds realistic, correctly signed MartianPay webhook events for tests.
+//
+// Every event type in developer.AllEventTypes has a default payload whose object is fully
+// populated and consistent with the event (a payment_intent.partially_paid event carries a
+// partially funded charge, a payout.failed event carries a failure message, and so on).
+// Tests adjust the payload with the typed mutators and then sign it:
+//
+//	req := webhooktest.New(developer.EventTypePaymentIntentSucceeded).
+//	    PaymentIntent(func(pi *developer.PaymentIntent) { pi.MerchantOrderId = "order-42" }).
+//	    Request("/v1/webhook_test", webhooktest.DefaultSecret)
+//	handler.ServeHTTP(rec, req)
+package webhooktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// DefaultSecret is a webhook signing secret tests can share with the handler under test
+const DefaultSecret = "whsec_test_webhooktest"
+
+// APIVersion is the API version stamped on generated events
+const APIVersion = "2025-01-22"
+
+// Builder assembles a single webhook event. Mutator errors are recorded and returned by Event.
+type Builder struct {
+	event    developer.Event
+	object   interface{}
+	previous map[string]interface{}
+	err      error
+}
+
+// New returns a builder for eventType whose payload is the default fixture for that type
+//
+// Parameters:
+//   - eventType: Webhook event type; unknown types get an empty object payload
+//
+// Returns:
+//   - *Builder: Builder to customize and sign the event
+func New(eventType developer.EventType) *Builder {
+	object, previous := defaultObject(eventType)
+	return &Builder{
+		event: developer.Event{
+			ID:         "evt_test_" + strings.NewReplacer(".", "_").Replace(string(eventType)),
+			Object:     developer.EventObject,
+			APIVersion: APIVersion,
+			Created:    baseTime.Add(30 * time.Minute).Unix(),
+			Type:       eventType,
+		},
+		object:   object,
+		previous: previous,
+	}
+}
+
+// WithID sets the event ID
+func (b *Builder) WithID(id string) *Builder {
+	b.event.ID = id
+	return b
+}
+
+// WithCreated sets the event creation time
+func (b *Builder) WithCreated(t time.Time) *Builder {
+	b.event.Created = t.Unix()
+	return b
+}
+
+// WithLivemode marks the event as a live mode event
+func (b *Builder) WithLivemode(livemode bool) *Builder {
+	b.event.Livemode = livemode
+	return b
+}
+
+// WithPreviousAttributes sets the previous_attributes of the event data
+func (b *Builder) WithPreviousAttributes(previous map[string]interface{}) *Builder {
+	b.previous = previous
+	return b
+}
+
+// WithObject replaces the event payload with any value that marshals to the API object
+func (b *Builder) WithObject(object interface{}) *Builder {
+	b.object = object
+	return b
+}
+
+// PaymentIntent modifies the payload of a payment_intent.* event
+func (b *Builder) PaymentIntent(fn func(pi *developer.PaymentIntent)) *Builder {
+	if pi, ok := b.object.(*developer.PaymentIntent); ok {
+		fn(pi)
+	} else {
+		b.mismatch("payment intent")
+	}
+	return b
+}
+
+// Refund modifies the payload of a refund.* event
+func (b *Builder) Refund(fn func(refund *developer.Refund)) *Builder {
+	if refund, ok := b.object.(*developer.Refund); ok {
+		fn(refund)
+	} else {
+		b.mismatch("refund")
+	}
+	return b
+}
+
+// Payout modifies the payload of a payout.* event
+func (b *Builder) Payout(fn func(payout *developer.Payout)) *Builder {
+	if payout, ok := b.object.(*developer.Payout); ok {
+		fn(payout)
+	} else {
+		b.mismatch("payout")
+	}
+	return b
+}
+
+// Payroll modifies the payload of a payroll.* event
+func (b *Builder) Payroll(fn func(payroll *developer.Payroll)) *Builder {
+	if payroll, ok := b.object.(*developer.Payroll); ok {
+		fn(payroll)
+	} else {
+		b.mismatch("payroll")
+	}
+	return b
+}
+
+// PayrollItem modifies the payload of a payroll_item.* event
+func (b *Builder) PayrollItem(fn func(item *developer.PayrollItems)) *Builder {
+	if item, ok := b.object.(*developer.PayrollItems); ok {
+		fn(item)
+	} else {
+		b.mismatch("payroll item")
+	}
+	return b
+}
+
+// Subscription modifies the payload of a subscription.* event
+func (b *Builder) Subscription(fn func(sub *developer.SubscriptionDetails)) *Builder {
+	if sub, ok := b.object.(*developer.SubscriptionDetails); ok {
+		fn(sub)
+	} else {
+		b.mismatch("subscription")
+	}
+	return b
+}
+
+// Invoice modifies the payload of an invoice.* event
+func (b *Builder) Invoice(fn func(invoice *developer.InvoiceDetails)) *Builder {
+	if invoice, ok := b.object.(*developer.InvoiceDetails); ok {
+		fn(invoice)
+	} else {
+		b.mismatch("invoice")
+	}
+	return b
+}
+
+func (b *Builder) mismatch(want string) {
+	if b.err == nil {
+		b.err = fmt.Errorf("webhooktest: %s event payload is %T, not a %s", b.event.Type, b.object, want)
+	}
+}
+
+// Event returns the assembled event with Data.Raw and Data.Object populated
+func (b *Builder) Event() (*developer.Event, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	raw, err := json.Marshal(b.object)
+	if err != nil {
+		return nil, fmt.Errorf("webhooktest: failed to marshal event object: %v", err)
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, fmt.Errorf("webhooktest: event object is not a JSON object: %v", err)
+	}
+	event := b.event
+	event.Data = &developer.EventData{
+		Object:             object,
+		PreviousAttributes: b.previous,
+		Raw:                raw,
+	}
+	return &event, nil
+}
+
+// MustEvent is like Event but panics on error
+func (b *Builder) MustEvent() *developer.Event {
+	event, err := b.Event()
+	if err != nil {
+		panic(err)
+	}
+	return event
+}
+
+// Signed returns the event payload and its MartianPay-Signature header value
+//
+// Parameters:
+//   - secret: Webhook signing secret the handler under test verifies with
+//
+// Returns:
+//   - []byte: JSON payload to deliver as the request body
+//   - string: Value for the MartianPay-Signature header
+//   - error: Error if the event cannot be assembled
+func (b *Builder) Signed(secret string) ([]byte, string, error) {
+	event, err := b.Event()
+	if err != nil {
+		return nil, "", err
+	}
+	return developer.GetPayloadAndSignature(event, secret)
+}
+
+// Request returns a signed POST request for target, ready for a handler's ServeHTTP.
+// It panics if the event cannot be assembled, like httptest.NewRequest does for bad input.
+func (b *Builder) Request(target string, secret string) *http.Request {
+	payload, sig, err := b.Signed(secret)
+	if err != nil {
+		panic(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(string(payload)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(developer.MartianPaySignature, sig)
+	return req
+}
+
+// defaultObject returns the fixture payload and previous attributes for an event type
+func defaultObject(eventType developer.EventType) (interface{}, map[string]interface{}) {
+	switch eventType {
+	case developer.EventTypePaymentIntentCreated:
+		return NewPaymentIntent(developer.PaymentIntentStatusCreated), nil
+	case developer.EventTypePaymentIntentProcessing:
+		return NewPaymentIntent(developer.PaymentIntentStatusPaid), nil
+	case developer.EventTypePaymentIntentPartiallyPaid:
+		return NewPaymentIntent(developer.PaymentIntentStatusPartiallyPaid), nil
+	case developer.EventTypePaymentIntentSucceeded:
+		return NewPaymentIntent(developer.PaymentIntentStatusConfirmed), nil
+	case developer.EventTypePaymentIntentPaymentFailed:
+		return NewPaymentIntent(developer.PaymentIntentStatusFrozen), nil
+	case developer.EventTypePaymentIntentCanceled:
+		return NewPaymentIntent(developer.PaymentIntentStatusCancelled), nil
+
+	case developer.EventTypeRefundCreated:
+		return NewRefund("Pending"), nil
+	case developer.EventTypeRefundSucceeded:
+		return NewRefund("Success"), nil
+	case developer.EventTypeRefundUpdated:
+		return NewRefund("Success"), map[string]interface{}{"status": "Pending"}
+	case developer.EventTypeRefundFailed:
+		return NewRefund("Failed"), nil
+
+	case developer.EventTypePayoutCreated:
+		return NewPayout(developer.PayoutStatusPending), nil
+	case developer.EventTypePayoutSucceeded:
+		return NewPayout(developer.PayoutStatusPaid), nil
+	case developer.EventTypePayoutUpdated:
+		return NewPayout(developer.PayoutStatusInTransit), map[string]interface{}{"status": string(developer.PayoutStatusApproved)}
+	case developer.EventTypePayoutFailed:
+		return NewPayout(developer.PayoutStatusFailed), nil
+
+	case developer.EventTypePayrollCreated:
+		return NewPayroll("pending"), nil
+	case developer.EventTypePayrollApproved:
+		return NewPayroll("approved"), nil
+	case developer.EventTypePayrollRejected:
+		return NewPayroll("rejected"), nil
+	case developer.EventTypePayrollCanceled:
+		return NewPayroll("canceled"), nil
+	case developer.EventTypePayrollExecuting:
+		return NewPayroll("executing"), nil
+	case developer.EventTypePayrollCompleted:
+		return NewPayroll("completed"), nil
+	case developer.EventTypePayrollFailed:
+		return NewPayroll("failed"), nil
+
+	case developer.EventTypePayrollItemProcessing:
+		return NewPayrollItem("processing"), nil
+	case developer.EventTypePayrollItemSucceeded:
+		return NewPayrollItem("succeeded"), nil
+	case developer.EventTypePayrollItemFailed:
+		return NewPayrollItem("failed"), nil
+	case developer.EventTypePayrollItemAddressVerification:
+		return NewPayrollItem("address_verification_sent"), nil
+	case developer.EventTypePayrollItemAddressVerified:
+		return NewPayrollItem("address_verified"), nil
+
+	case developer.EventTypeSubscriptionCreated:
+		return NewSubscription(developer.SubscriptionStatusIncomplete), nil
+	case developer.EventTypeSubscriptionUpdated:
+		return NewSubscription(developer.SubscriptionStatusActive), map[string]interface{}{"status": string(developer.SubscriptionStatusIncomplete)}
+	case developer.EventTypeSubscriptionDeleted:
+		return NewSubscription(developer.SubscriptionStatusCanceled), nil
+	case developer.EventTypeSubscriptionPaused:
+		return NewSubscription(developer.SubscriptionStatusPaused), nil
+	case developer.EventTypeSubscriptionResumed, developer.EventTypeSubscriptionTrialWill:
+		return NewSubscription(developer.SubscriptionStatusActive), nil
+
+	case developer.EventTypeInvoiceCreated:
+		return NewInvoice("draft"), nil
+	case developer.EventTypeInvoiceFinalized, developer.EventTypeInvoicePaymentFailed,
+		developer.EventTypeInvoicePaymentActionRequired, developer.EventTypeInvoiceUpcoming:
+		return NewInvoice("open"), nil
+	case developer.EventTypeInvoicePaid, developer.EventTypeInvoicePaymentSucceeded:
+		return NewInvoice("paid"), nil
+	case developer.EventTypeInvoiceUpdated:
+		return NewInvoice("open"), map[string]interface{}{"status": "draft"}
+	case developer.EventTypeInvoiceVoided:
+		return NewInvoice("void"), nil
+	}
+	return map[string]interface{}{}, nil
+}
@@ -0,0 +1,485 @@
+// fixtures.go contains constructors for realistic, fully populated API objects
+// used as the data payload of test webhook events.
+package webhooktest
+
+import (
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/shopspring/decimal"
+)
+
+// Fixture identifiers shared by all generated objects so related objects reference each other
+const (
+	// PaymentIntentID is the ID of the generated payment intent
+	PaymentIntentID = "pi_test_6Y4kQm2ZB8xR1sWd"
+	// ChargeID is the ID of the charge attached to the generated payment intent
+	ChargeID = "ch_test_9Lp3Vt7HcN5eKa2M"
+	// CustomerID is the ID of the generated customer
+	CustomerID = "cus_test_3Jf8Wq1RzD6mYb4P"
+	// RefundID is the ID of the generated refund
+	RefundID = "re_test_2Hs5Nc8XvK1pTg7E"
+	// PayoutID is the ID of the generated payout
+	PayoutID = "payout_test_7Qa4Zr9MbW2nLc6F"
+	// PayrollID is the ID of the generated payroll
+	PayrollID = "payroll_test_5Ud1Ke8TyG3vHx9A"
+	// PayrollItemID is the ID of the generated payroll item
+	PayrollItemID = "payroll_item_test_8Wm6Jp2SfC4rBz1N"
+	// SubscriptionID is the ID of the generated subscription
+	SubscriptionID = "sub_test_4Ne7Rb3LgY9kDq5V"
+	// InvoiceID is the ID of the generated invoice
+	InvoiceID = "in_test_1Cx9Ht6PwM3aZe8S"
+	// MerchantID is the merchant that owns every generated object
+	MerchantID = "merchant_test_6Gv2Ls5QkX8dWn3T"
+
+	// DepositAddress is the TRON deposit address used by generated crypto charges
+	DepositAddress = "TJRabPrwbZy45sbavfcjinPJC18kjpRTv8"
+	// CustomerAddress is the address the generated customer pays from
+	CustomerAddress = "TXLAQ63Xg1NAzckPwKHvzw7CSEmLMEqcdj"
+	// AssetID is the crypto asset used by generated charges
+	AssetID = "USDT-TRON"
+)
+
+// baseTime is the fixed creation time of every generated object, so fixtures are reproducible
+var baseTime = time.Date(2025, 1, 22, 12, 0, 0, 0, time.UTC)
+
+// at returns the Unix timestamp offset minutes after baseTime
+func at(minutes int) int64 {
+	return baseTime.Add(time.Duration(minutes) * time.Minute).Unix()
+}
+
+func strPtr(s string) *string { return &s }
+func intPtr(i int) *int       { return &i }
+func i64Ptr(i int64) *int64   { return &i }
+func boolPtr(b bool) *bool    { return &b }
+
+// usd returns an AssetAmount in US dollars
+func usd(amount string) *developer.AssetAmount {
+	return developer.NewAssetAmount(decimal.RequireFromString(amount), "USD", 2)
+}
+
+// usdt returns an AssetAmount in USDT on TRON
+func usdt(amount string) *developer.AssetAmount {
+	return developer.NewAssetAmount(decimal.RequireFromString(amount), AssetID, 6)
+}
+
+// NewCustomer returns a populated customer
+func NewCustomer() *developer.Customer {
+	return &developer.Customer{
+		ID:           CustomerID,
+		Object:       developer.CustomerObject,
+		TotalExpense: 10000,
+		TotalPayment: 10000,
+		Currency:     "USD",
+		Created:      at(-60 * 24 * 30),
+		Name:         strPtr("Ada Lovelace"),
+		Email:        strPtr("ada@example.com"),
+		Metadata:     map[string]string{"account_tier": "gold"},
+		Phone:        strPtr("+14155550100"),
+	}
+}
+
+// NewTransaction returns a blockchain deposit transaction for the generated charge
+//
+// Parameters:
+//   - amount: Decimal amount of USDT transferred
+//   - status: Transaction status ("submitted", "completed", "confirmed" or "failed")
+//   - amlStatus: AML check status ("", "approved" or "rejected")
+func NewTransaction(amount string, status string, amlStatus string) *developer.TransactionDetails {
+	tx := &developer.TransactionDetails{
+		TxId:               "tx_test_" + status,
+		SourceAddress:      CustomerAddress,
+		DestinationAddress: DepositAddress,
+		TxHash:             "5c1f0d7a9e3b24c6f8a0b1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f2",
+		Amount:             amount,
+		Decimals:           6,
+		AssetId:            AssetID,
+		Token:              "USDT",
+		Network:            "TRC20",
+		Type:               "0",
+		CreatedAt:          at(5),
+		Status:             status,
+		AmlStatus:          amlStatus,
+		ChargeId:           ChargeID,
+		FeeInfo:            strPtr(`{"network_fee":"0","service_fee":"1"}`),
+		FeeCurrency:        "TRX",
+	}
+	if amlStatus == "rejected" {
+		tx.AmlInfo = strPtr(`{"score":8.7,"rule_names":["sanctioned_counterparty"]}`)
+	} else if amlStatus != "" {
+		tx.AmlInfo = strPtr(`{"score":1.2}`)
+	}
+	return tx
+}
+
+// NewCharge returns a crypto charge for the generated payment intent with the given transactions
+func NewCharge(txs ...*developer.TransactionDetails) *developer.Charge {
+	paid := decimal.Zero
+	for _, tx := range txs {
+		if tx.Status != "failed" {
+			paid = paid.Add(decimal.RequireFromString(tx.Amount))
+		}
+	}
+	charge := &developer.Charge{
+		ID:                            ChargeID,
+		Object:                        developer.ChargeObject,
+		Amount:                        usdt("100"),
+		ExchangeRate:                  "1",
+		CalculatedStatementDescriptor: "MARTIANPAY* TEST STORE",
+		Captured:                      paid.GreaterThanOrEqual(decimal.NewFromInt(100)),
+		Created:                       at(1),
+		Customer:                      CustomerID,
+		Description:                   "Order #1001",
+		FraudDetails:                  &developer.ChargeFraudDetails{},
+		Metadata:                      map[string]string{"order_id": "1001"},
+		Paid:                          paid.GreaterThanOrEqual(decimal.NewFromInt(100)),
+		PaymentIntent:                 strPtr(PaymentIntentID),
+		PaymentMethodType:             developer.PaymentMethodTypeCrypto,
+		PaymentMethodOptions: &developer.PaymentMethodOptions{
+			Crypto: &developer.Crypto{
+				Amount:         strPtr("100"),
+				Token:          strPtr("USDT"),
+				AssetId:        strPtr(AssetID),
+				Network:        strPtr("TRC20"),
+				Decimals:       intPtr(6),
+				ExchangeRate:   strPtr("1"),
+				DepositAddress: strPtr(DepositAddress),
+			},
+		},
+		Transactions:    txs,
+		ReceiptEmail:    "ada@example.com",
+		ReceiptURL:      "https://pay.martianpay.com/receipts/" + ChargeID,
+		Refunds:         []*developer.Refund{},
+		PaymentProvider: "Crypto",
+	}
+	if len(txs) > 0 {
+		charge.PaymentDetails = &developer.PaymentDetails{
+			AmountCaptured: usdt(paid.String()),
+			AmountRefunded: usdt("0"),
+			TxFee:          usdt("1"),
+			TaxFee:         usdt("0"),
+			FrozenAmount:   usdt("0"),
+			NetAmount:      usdt(paid.Sub(decimal.NewFromInt(1)).String()),
+			GasFee:         map[string]*developer.AssetAmount{"TRX": developer.NewAssetAmount(decimal.RequireFromString("1.35"), "TRX", 6)},
+			NetworkFee:     usdt("0"),
+		}
+	}
+	return charge
+}
+
+// NewPaymentIntent returns a payment intent for 100 USD paid in USDT, with charges and
+// transactions consistent with the given status.
+func NewPaymentIntent(status developer.PaymentIntentStatus) *developer.PaymentIntent {
+	pi := &developer.PaymentIntent{
+		ID:                  PaymentIntentID,
+		Object:              developer.PaymentIntentObject,
+		Amount:              usd("100"),
+		ClientSecret:        PaymentIntentID + "_secret_Xk29fLq0",
+		Created:             at(0),
+		Updated:             at(10),
+		Currency:            "USD",
+		Customer:            NewCustomer(),
+		Description:         "Order #1001",
+		Metadata:            map[string]interface{}{"order_id": "1001"},
+		MerchantOrderId:     "sn-1001",
+		ReceiptEmail:        "ada@example.com",
+		ReturnURL:           "https://shop.example.com/orders/1001",
+		Status:              string(status),
+		PaymentIntentStatus: status,
+		ExpiredAt:           uint64(at(20)),
+		Charges:             []*developer.Charge{},
+		MerchantID:          strPtr(MerchantID),
+		MerchantName:        strPtr("Test Store"),
+	}
+
+	switch status {
+	case developer.PaymentIntentStatusWaiting:
+		pi.Charges = []*developer.Charge{NewCharge()}
+	case developer.PaymentIntentStatusPartiallyPaid:
+		pi.Charges = []*developer.Charge{NewCharge(NewTransaction("40", "submitted", ""))}
+	case developer.PaymentIntentStatusPaid:
+		pi.Charges = []*developer.Charge{NewCharge(NewTransaction("100", "submitted", ""))}
+	case developer.PaymentIntentStatusCompleted:
+		pi.Charges = []*developer.Charge{NewCharge(NewTransaction("100", "completed", "approved"))}
+	case developer.PaymentIntentStatusConfirmed:
+		pi.Charges = []*developer.Charge{NewCharge(NewTransaction("100", "confirmed", "approved"))}
+	case developer.PaymentIntentStatusFrozen, developer.PaymentIntentStatusUnfrozen:
+		charge := NewCharge(NewTransaction("100", "confirmed", "rejected"))
+		charge.PaymentDetails.FrozenAmount = usdt("100")
+		pi.Charges = []*developer.Charge{charge}
+		if status == developer.PaymentIntentStatusUnfrozen {
+			charge.PaymentDetails.FrozenAmount = usdt("0")
+			pi.UnfreezeWithdraws = []*developer.UnfreezeWithdraw{NewUnfreezeWithdraw()}
+		}
+	case developer.PaymentIntentStatusCancelled:
+		pi.CanceledAt = at(20)
+		pi.CancellationReason = string(developer.PaymentIntentCancellationReasonAutomatic)
+	}
+
+	if len(pi.Charges) > 0 && pi.Charges[0].PaymentDetails != nil {
+		details := *pi.Charges[0].PaymentDetails
+		details.AmountCaptured = usd(details.AmountCaptured.Amount.String())
+		details.AmountRefunded = usd("0")
+		details.TxFee = usd("1")
+		details.TaxFee = usd("0")
+		details.FrozenAmount = usd(details.FrozenAmount.Amount.String())
+		details.NetAmount = usd(details.NetAmount.Amount.String())
+		details.NetworkFee = usd("0")
+		pi.PaymentDetails = &details
+	}
+	return pi
+}
+
+// NewUnfreezeWithdraw returns an unfreeze release for the generated frozen payment
+func NewUnfreezeWithdraw() *developer.UnfreezeWithdraw {
+	return &developer.UnfreezeWithdraw{
+		ID:                 "wd_test_3Tb8Xn5KcQ1mRv7Y",
+		PayoutID:           "payout_test_unfreeze",
+		AssetID:            AssetID,
+		Amount:             usdt("100"),
+		NetworkFee:         usdt("0"),
+		Status:             "completed",
+		Address:            CustomerAddress,
+		Type:               developer.UnfreezeTypeRelease,
+		Description:        "AML review cleared",
+		OriginalFrozenTxID: "tx_test_confirmed",
+		CreatedAt:          at(60),
+		UpdatedAt:          at(75),
+	}
+}
+
+// NewRefund returns a partial refund of the generated payment intent in the given status
+// ("Pending", "Success", "Failed" or "Canceled").
+func NewRefund(status string) *developer.Refund {
+	refund := &developer.Refund{
+		ID:            RefundID,
+		Object:        developer.RefundObject,
+		Amount:        usdt("25"),
+		NetworkFee:    usdt("1"),
+		NetAmount:     usdt("24"),
+		Created:       at(120),
+		Description:   "Returned item",
+		Transactions:  []*developer.TransactionDetails{},
+		Metadata:      map[string]string{"rma": "RMA-77"},
+		Charge:        strPtr(ChargeID),
+		PaymentIntent: strPtr(PaymentIntentID),
+		RefundAddress: strPtr(CustomerAddress),
+		Reason:        "requested_by_customer",
+		Status:        status,
+	}
+	switch status {
+	case "Success":
+		tx := NewTransaction("24", "confirmed", "approved")
+		tx.Type = "1"
+		tx.RefundId = RefundID
+		tx.SourceAddress, tx.DestinationAddress = DepositAddress, CustomerAddress
+		refund.Transactions = append(refund.Transactions, tx)
+	case "Failed":
+		refund.FailureReason = "insufficient_balance"
+	}
+	return refund
+}
+
+// NewPayout returns a USDT payout converted from USD in the given status
+func NewPayout(status developer.PayoutStatus) *developer.Payout {
+	payout := &developer.Payout{
+		ID:                  PayoutID,
+		Object:              developer.PayoutObject,
+		ArrivalDate:         at(60 * 24),
+		Transactions:        []*developer.TransactionDetails{},
+		Created:             at(0),
+		Updated:             at(30),
+		MerchantId:          MerchantID,
+		SourceAmount:        decimal.RequireFromString("1500.00"),
+		SourceCoin:          "USD",
+		ExchangeRate:        decimal.RequireFromString("0.99985"),
+		ReceiveCoin:         "USDT",
+		ReceiveAssetId:      AssetID,
+		ReceiveAccountType:  "wallet",
+		ReceiveAmount:       decimal.RequireFromString("1499.775"),
+		ReceiveAmountMin:    decimal.RequireFromString("1492.276125"),
+		PaymentMaxAmount:    decimal.RequireFromString("1500.00"),
+		PaymentNetworkFee:   decimal.RequireFromString("1.00"),
+		PaymentServiceFee:   decimal.RequireFromString("3.75"),
+		PaymentTotalFee:     decimal.RequireFromString("4.75"),
+		PaymentNetAmount:    decimal.RequireFromString("1495.25"),
+		Status:              status,
+		ApprovalStatus:      strPtr("approved"),
+		StatementDescriptor: "MARTIANPAY PAYOUT",
+		ExternalId:          "ext-payout-42",
+		Metadata:            map[string]string{"batch": "2025-01"},
+		ReceiveWalletAddress: &developer.MerchantAddress{
+			ID:         "ma_test_1",
+			MerchantID: MerchantID,
+			Network:    "TRX",
+			Address:    CustomerAddress,
+			Status:     "success",
+			Alias:      "Treasury",
+			CreatedAt:  at(-60 * 24),
+			UpdatedAt:  at(-60 * 24),
+		},
+	}
+	switch status {
+	case developer.PayoutStatusPaid, developer.PayoutStatusInTransit:
+		tx := NewTransaction("1495.25", "confirmed", "approved")
+		tx.SourceAddress, tx.DestinationAddress = DepositAddress, CustomerAddress
+		payout.Transactions = append(payout.Transactions, tx)
+		payout.AmlStatus = "approved"
+	case developer.PayoutStatusFailed:
+		payout.FailureMessage = "destination address rejected by AML screening"
+		payout.AmlStatus = "rejected"
+		payout.AmlInfo = strPtr("sanctioned_counterparty;high_risk_exchange")
+	}
+	return payout
+}
+
+// NewPayroll returns a payroll batch with the given status
+func NewPayroll(status string) *developer.Payroll {
+	return &developer.Payroll{
+		ID:              PayrollID,
+		CreatedAt:       at(0),
+		UpdatedAt:       at(15),
+		MerchantID:      MerchantID,
+		ExternalID:      "payroll-2025-01",
+		ApprovalStatus:  "approved",
+		Status:          status,
+		TotalItemNum:    2,
+		TotalAmount:     "5000.00",
+		TotalServiceFee: "10.00",
+		Currency:        "USDT",
+	}
+}
+
+// NewPayrollItem returns a payroll item with the given status
+func NewPayrollItem(status string) *developer.PayrollItems {
+	item := &developer.PayrollItems{
+		ID:                PayrollItemID,
+		CreatedAt:         at(0),
+		UpdatedAt:         at(15),
+		PayrollID:         PayrollID,
+		Payroll:           NewPayroll("executing"),
+		ExternalID:        "employee-007",
+		Name:              "Grace Hopper",
+		Email:             "grace@example.com",
+		Phone:             "+14155550107",
+		Amount:            "2500.00",
+		ServiceFee:        "5.00",
+		ExchangeRateToUSD: "1.0001",
+		Coin:              "USDT",
+		Network:           "TRC20",
+		AssetID:           AssetID,
+		Address:           CustomerAddress,
+		AddressVerified:   true,
+		Status:            status,
+		Transactions:      []*developer.TransactionDetails{},
+		PaymentMethod:     "normal",
+	}
+	switch status {
+	case "succeeded":
+		tx := NewTransaction("2500.00", "confirmed", "approved")
+		tx.SourceAddress, tx.DestinationAddress = DepositAddress, CustomerAddress
+		item.Transactions = append(item.Transactions, tx)
+		item.TxId = tx.TxHash
+		item.AmlInfo = `{"score":0.4}`
+	case "failed":
+		item.FailReason = "address failed AML screening"
+		item.AmlInfo = "sanctioned_counterparty"
+	case "address_verification_sent":
+		item.AddressVerified = false
+	}
+	return item
+}
+
+// NewSubscription returns a monthly subscription in the given status
+func NewSubscription(status developer.SubscriptionStatus) *developer.SubscriptionDetails {
+	sub := &developer.SubscriptionDetails{
+		ID:                       SubscriptionID,
+		MerchantID:               MerchantID,
+		CustomerID:               CustomerID,
+		SellingPlanID:            "sp_test_monthly",
+		ProductID:                strPtr("prod_test_coffee"),
+		VariantID:                strPtr("var_test_coffee_1kg"),
+		Quantity:                 1,
+		Status:                   string(status),
+		CollectionMethod:         "charge_automatically",
+		BillingCycleAnchor:       at(0),
+		CurrentPeriodStart:       at(0),
+		CurrentPeriodEnd:         at(60 * 24 * 30),
+		LatestInvoiceID:          strPtr(InvoiceID),
+		DefaultPaymentMethodID:   strPtr("pm_test_visa"),
+		DefaultProviderType:      strPtr("stripe"),
+		DefaultPaymentMethodType: strPtr("card"),
+		Metadata:                 map[string]interface{}{"plan": "monthly"},
+		CreatedAt:                at(0),
+		UpdatedAt:                at(5),
+		CurrentCycleNumber:       intPtr(1),
+		NextChargeAmount:         strPtr("29.00"),
+		NextChargeAmountDisplay:  strPtr("$29.00"),
+		CustomerEmail:            strPtr("ada@example.com"),
+		CustomerName:             strPtr("Ada Lovelace"),
+		ProductName:              strPtr("Coffee Beans"),
+		VariantTitle:             strPtr("1kg"),
+		SellingPlanName:          strPtr("Monthly delivery"),
+		PaymentMethodBrand:       strPtr("visa"),
+		PaymentMethodLast4:       strPtr("4242"),
+	}
+	switch status {
+	case developer.SubscriptionStatusPaused:
+		sub.PausedAt = i64Ptr(at(60 * 24 * 10))
+		sub.PauseCollectionBehavior = strPtr(string(developer.PauseCollectionBehaviorVoid))
+	case developer.SubscriptionStatusCanceled:
+		sub.CanceledAt = i64Ptr(at(60 * 24 * 20))
+		sub.CancelReason = strPtr("too_expensive")
+	case developer.SubscriptionStatusIncomplete:
+		sub.PaymentRequired = boolPtr(true)
+		sub.PaymentURL = strPtr("https://pay.martianpay.com/subscriptions/" + SubscriptionID)
+		sub.PaymentExpiresAt = i64Ptr(at(60 * 23))
+	}
+	return sub
+}
+
+// NewInvoice returns a subscription invoice with the given status ("draft", "open", "paid" or "void")
+func NewInvoice(status string) *developer.InvoiceDetails {
+	invoice := &developer.InvoiceDetails{
+		ID:              InvoiceID,
+		MerchantID:      MerchantID,
+		SubscriptionID:  strPtr(SubscriptionID),
+		PaymentIntentID: strPtr(PaymentIntentID),
+		CustomerID:      CustomerID,
+		Amount:          "29.00",
+		Currency:        "USD",
+		Status:          status,
+		BillingReason:   strPtr("subscription_cycle"),
+		AttemptCount:    1,
+		DueDate:         i64Ptr(at(60 * 24 * 3)),
+		Lines: []developer.InvoiceLineItemDetails{{
+			Description:  "Coffee Beans × 1",
+			ProductID:    strPtr("prod_test_coffee"),
+			VariantID:    strPtr("var_test_coffee_1kg"),
+			Quantity:     1,
+			UnitAmount:   "29.00",
+			Amount:       "29.00",
+			Currency:     "USD",
+			PeriodStart:  i64Ptr(at(0)),
+			PeriodEnd:    i64Ptr(at(60 * 24 * 30)),
+			ProductName:  strPtr("Coffee Beans"),
+			VariantTitle: strPtr("1kg"),
+		}},
+		Metadata:                   map[string]interface{}{"cycle": 1},
+		CreatedAt:                  at(0),
+		UpdatedAt:                  at(5),
+		Version:                    1,
+		MerchantName:               strPtr("Test Store"),
+		CustomerEmail:              strPtr("ada@example.com"),
+		CustomerName:               strPtr("Ada Lovelace"),
+		SubscriptionProductName:    strPtr("Coffee Beans"),
+		SubscriptionProductVariant: strPtr("1kg"),
+	}
+	switch status {
+	case "paid":
+		invoice.PaidAt = i64Ptr(at(30))
+	case "void":
+		invoice.VoidedAt = i64Ptr(at(60))
+	}
+	return invoice
+}
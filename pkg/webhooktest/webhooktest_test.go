@@ -0,0 +1,41 @@
+// webhooktest_test.go contains unit tests for the webhook fixture builder.
+package webhooktest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEveryEventTypeIsSignedAndParsable(t *testing.T) {
+	for _, eventType := range developer.AllEventTypes() {
+		payload, sig, err := New(eventType).Signed(DefaultSecret)
+		require.NoError(t, err, eventType)
+
+		event, err := developer.ConstructEvent(payload, sig, DefaultSecret)
+		require.NoError(t, err, eventType)
+		assert.Equal(t, eventType, event.Type)
+
+		var object map[string]interface{}
+		require.NoError(t, json.Unmarshal(event.Data.Raw, &object), eventType)
+		assert.NotEmpty(t, object["id"], eventType)
+	}
+}
+
+func TestBuilderMutators(t *testing.T) {
+	event, err := New(developer.EventTypePaymentIntentSucceeded).
+		WithID("evt_custom").
+		PaymentIntent(func(pi *developer.PaymentIntent) { pi.MerchantOrderId = "order-42" }).
+		Event()
+	require.NoError(t, err)
+	assert.Equal(t, "evt_custom", event.ID)
+	assert.Equal(t, "order-42", event.Data.Object["merchant_order_id"])
+
+	_, err = New(developer.EventTypePayoutFailed).
+		Refund(func(r *developer.Refund) {}).
+		Event()
+	assert.Error(t, err)
+}
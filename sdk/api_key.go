@@ -0,0 +1,101 @@
+// Package martianpay provides SDK methods for managing API keys.
+// API keys authenticate requests; publishable keys are safe for client-side code, secret keys are not.
+package martianpay
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// CreateApiKey creates a new publishable or secret API key.
+// The full key value is only returned by this call, so it must be stored securely.
+//
+// Parameters:
+//   - req: Key name, description, and type ("public" or "secret")
+//
+// Returns:
+//   - *developer.ApiKey: The created key, including its full key value
+//   - error: nil on success, error on validation or API failure
+func (c *Client) CreateApiKey(req *developer.ApiKeyParams) (*developer.ApiKey, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var response developer.ApiKey
+	err := c.sendRequest("POST", "/v1/api_keys", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetApiKey retrieves an API key by ID. Secret key values are masked.
+//
+// Parameters:
+//   - id: The unique identifier of the API key
+//
+// Returns:
+//   - *developer.ApiKey: The API key details, including LastAccessedAt and ExpiredAt
+//   - error: nil on success, error on failure (e.g., key not found)
+func (c *Client) GetApiKey(id string) (*developer.ApiKey, error) {
+	var response developer.ApiKey
+	err := c.sendRequest("GET", fmt.Sprintf("/v1/api_keys/%s", id), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListApiKeys retrieves a paginated list of API keys.
+// Can be filtered by key type and status.
+//
+// Parameters:
+//   - req: Query parameters including pagination and filters
+//
+// Returns:
+//   - *developer.ApiKeyListResp: List of API keys with pagination metadata
+//   - error: nil on success, error on failure
+func (c *Client) ListApiKeys(req *developer.ApiKeyListRequest) (*developer.ApiKeyListResp, error) {
+	var response developer.ApiKeyListResp
+	err := c.sendRequestWithQuery("GET", "/v1/api_keys", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RollApiKey replaces an API key with a new one of the same type.
+// The old key keeps working for the overlap window so running services can switch over.
+//
+// Parameters:
+//   - id: The unique identifier of the API key to roll
+//   - overlap: How long the old key remains valid, rounded up to whole seconds (0 revokes it immediately)
+//
+// Returns:
+//   - *developer.ApiKeyRollResp: The new key (with its full value) and the expiring old key
+//   - error: nil on success, error on failure
+func (c *Client) RollApiKey(id string, overlap time.Duration) (*developer.ApiKeyRollResp, error) {
+	if overlap < 0 {
+		return nil, fmt.Errorf("overlap must not be negative")
+	}
+	// Round up so a short positive overlap never becomes an immediate revocation
+	req := &developer.ApiKeyRollRequest{ExpiresInSeconds: int64((overlap + time.Second - 1) / time.Second)}
+	var response developer.ApiKeyRollResp
+	err := c.sendRequest("POST", fmt.Sprintf("/v1/api_keys/%s/roll", id), req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RevokeApiKey immediately and permanently disables an API key.
+//
+// Parameters:
+//   - id: The unique identifier of the API key to revoke
+//
+// Returns:
+//   - error: nil on success, error on failure
+func (c *Client) RevokeApiKey(id string) error {
+	return c.sendRequest("DELETE", fmt.Sprintf("/v1/api_keys/%s", id), nil, nil)
+}
@@ -0,0 +1,40 @@
+// api_key_test.go contains unit tests for the API key management methods.
+package martianpay
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollApiKeyRoundsOverlapUp(t *testing.T) {
+	var req developer.ApiKeyRollRequest
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/api_keys/key_1/roll", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		writeData(t, w, developer.ApiKeyRollResp{})
+	})
+
+	tests := []struct {
+		overlap time.Duration
+		seconds int64
+	}{
+		{0, 0},
+		{500 * time.Millisecond, 1},
+		{time.Second, 1},
+		{90*time.Second + time.Nanosecond, 91},
+	}
+	for _, tt := range tests {
+		_, err := c.RollApiKey("key_1", tt.overlap)
+		require.NoError(t, err)
+		assert.Equal(t, tt.seconds, req.ExpiresInSeconds, tt.overlap.String())
+	}
+
+	_, err := c.RollApiKey("key_1", -time.Second)
+	assert.Error(t, err)
+}
@@ -48,3 +48,22 @@ func (c *Client) ListAssetFees() (*developer.NetworkFeesResponse, error) {
 	}
 	return &response, nil
 }
+
+// ListExchangeRates retrieves the current exchange rates of payable assets in a fiat currency.
+// Use the quote package to cache rates and convert fiat prices to crypto amounts.
+//
+// Parameters:
+//   - currency: The fiat currency to quote in (e.g., "USD")
+//
+// Returns:
+//   - *developer.ExchangeRateListResponse: The price of one unit of each asset in the currency
+//   - error: nil on success, error on failure (e.g., unsupported currency)
+func (c *Client) ListExchangeRates(currency string) (*developer.ExchangeRateListResponse, error) {
+	req := &developer.ExchangeRateListRequest{Currency: currency}
+	var response developer.ExchangeRateListResponse
+	err := c.sendRequestWithQuery("GET", "/v1/assets/exchange_rates", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
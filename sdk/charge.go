@@ -0,0 +1,167 @@
+// Package martianpay provides SDK methods for charges, reviews, and fraud reports.
+// A charge is one attempt to collect a payment intent. Charges flagged by risk rules get an
+// open review, which the merchant closes by approving the payment or refunding it as fraud.
+package martianpay
+
+import (
+	"fmt"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// GetCharge retrieves a charge with its transactions, refunds, review, and fraud details.
+//
+// Parameters:
+//   - chargeID: The unique identifier of the charge
+//
+// Returns:
+//   - *developer.ChargeGetResp: The charge
+//   - error: nil on success, error on failure (e.g., charge not found)
+func (c *Client) GetCharge(chargeID string) (*developer.ChargeGetResp, error) {
+	var response developer.ChargeGetResp
+	err := c.sendRequest("GET", fmt.Sprintf("/v1/charges/%s", chargeID), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListCharges retrieves a paginated list of charges.
+//
+// Parameters:
+//   - req: Pagination and filters for payment intent and customer
+//
+// Returns:
+//   - *developer.ChargeListResp: One page of charges
+//   - error: nil on success, error on failure
+func (c *Client) ListCharges(req *developer.ChargeListRequest) (*developer.ChargeListResp, error) {
+	var response developer.ChargeListResp
+	err := c.sendRequestWithQuery("GET", "/v1/charges", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ReportChargeFraud reports a charge as fraudulent or safe.
+// The report improves fraud detection but does not refund the charge; use RefundReviewAsFraud for that.
+//
+// Parameters:
+//   - req: The charge ID and the report (developer.ChargeFraudUserReportFraudulent or ChargeFraudUserReportSafe)
+//
+// Returns:
+//   - *developer.ChargeGetResp: The charge with its updated fraud details
+//   - error: nil on success, error on failure
+func (c *Client) ReportChargeFraud(req *developer.ChargeFraudReportRequest) (*developer.ChargeGetResp, error) {
+	if req.ChargeID == "" {
+		return nil, fmt.Errorf("charge ID is required")
+	}
+	if req.UserReport != developer.ChargeFraudUserReportFraudulent && req.UserReport != developer.ChargeFraudUserReportSafe {
+		return nil, fmt.Errorf("invalid fraud report %q", req.UserReport)
+	}
+	var response developer.ChargeGetResp
+	err := c.sendRequest("POST", fmt.Sprintf("/v1/charges/%s/fraud_report", req.ChargeID), req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetReview retrieves a review.
+//
+// Parameters:
+//   - reviewID: The unique identifier of the review
+//
+// Returns:
+//   - *developer.ReviewGetResp: The review with its IP address location and session
+//   - error: nil on success, error on failure (e.g., review not found)
+func (c *Client) GetReview(reviewID string) (*developer.ReviewGetResp, error) {
+	var response developer.ReviewGetResp
+	err := c.sendRequest("GET", fmt.Sprintf("/v1/reviews/%s", reviewID), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListReviews retrieves a paginated list of reviews.
+//
+// Parameters:
+//   - req: Pagination and filters for open state and reason
+//
+// Returns:
+//   - *developer.ReviewListResp: One page of reviews
+//   - error: nil on success, error on failure
+func (c *Client) ListReviews(req *developer.ReviewListRequest) (*developer.ReviewListResp, error) {
+	var response developer.ReviewListResp
+	err := c.sendRequestWithQuery("GET", "/v1/reviews", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListOpenReviews retrieves a page of reviews that still need a decision.
+//
+// Parameters:
+//   - pagination: The page to retrieve
+//
+// Returns:
+//   - *developer.ReviewListResp: One page of open reviews
+//   - error: nil on success, error on failure
+func (c *Client) ListOpenReviews(pagination developer.Pagination) (*developer.ReviewListResp, error) {
+	open := true
+	return c.ListReviews(&developer.ReviewListRequest{Pagination: pagination, Open: &open})
+}
+
+// CloseReview closes an open review with a reason.
+//
+// Parameters:
+//   - req: The review ID, the reason (approved, refunded, or refunded_as_fraud), and the refund address for crypto refunds
+//
+// Returns:
+//   - *developer.ReviewCloseResp: The closed review and the updated charge
+//   - error: nil on success, error on failure (e.g., review already closed)
+func (c *Client) CloseReview(req *developer.ReviewCloseRequest) (*developer.ReviewCloseResp, error) {
+	if req.ReviewID == "" {
+		return nil, fmt.Errorf("review ID is required")
+	}
+	if !req.Reason.IsValidCloseReason() {
+		return nil, fmt.Errorf("invalid review close reason %q", req.Reason)
+	}
+	var response developer.ReviewCloseResp
+	err := c.sendRequest("POST", fmt.Sprintf("/v1/reviews/%s/close", req.ReviewID), req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ApproveReview closes a review as approved, letting the payment proceed.
+//
+// Parameters:
+//   - reviewID: The unique identifier of the review
+//
+// Returns:
+//   - *developer.ReviewCloseResp: The closed review and the charge
+//   - error: nil on success, error on failure
+func (c *Client) ApproveReview(reviewID string) (*developer.ReviewCloseResp, error) {
+	return c.CloseReview(&developer.ReviewCloseRequest{ReviewID: reviewID, Reason: developer.ReviewClosedReasonApproved})
+}
+
+// RefundReviewAsFraud closes a review by refunding the charge and reporting it as fraudulent.
+//
+// Parameters:
+//   - reviewID: The unique identifier of the review
+//   - refundAddress: The address to refund crypto payments to; empty for card payments
+//
+// Returns:
+//   - *developer.ReviewCloseResp: The closed review and the refunded charge
+//   - error: nil on success, error on failure
+func (c *Client) RefundReviewAsFraud(reviewID, refundAddress string) (*developer.ReviewCloseResp, error) {
+	req := &developer.ReviewCloseRequest{ReviewID: reviewID, Reason: developer.ReviewClosedReasonRefundedAsFraud}
+	if refundAddress != "" {
+		req.RefundAddress = &refundAddress
+	}
+	return c.CloseReview(req)
+}
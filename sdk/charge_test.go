@@ -0,0 +1,73 @@
+// charge_test.go contains unit tests for the charge, review, and fraud report methods.
+package martianpay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOpenReviewsSendsPagination(t *testing.T) {
+	var query url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/reviews", r.URL.Path)
+		query = r.URL.Query()
+		writeData(t, w, developer.ReviewListResp{Total: 30, Page: 2, PageSize: 10})
+	})
+
+	resp, err := c.ListOpenReviews(developer.Pagination{Page: 2, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Page)
+	assert.Equal(t, "2", query.Get("page"))
+	assert.Equal(t, "10", query.Get("page_size"))
+	assert.Equal(t, "true", query.Get("open"))
+}
+
+func TestListChargesSendsFilters(t *testing.T) {
+	var query url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		writeData(t, w, developer.ChargeListResp{})
+	})
+
+	customer := "cus_1"
+	_, err := c.ListCharges(&developer.ChargeListRequest{Pagination: developer.Pagination{Page: 1, PageSize: 20}, Customer: &customer})
+	require.NoError(t, err)
+	assert.Equal(t, "1", query.Get("page"))
+	assert.Equal(t, "20", query.Get("page_size"))
+	assert.Equal(t, "cus_1", query.Get("customer"))
+	assert.False(t, query.Has("payment_intent"))
+}
+
+func TestRefundReviewAsFraud(t *testing.T) {
+	var path string
+	var body map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		writeData(t, w, developer.ReviewCloseResp{})
+	})
+
+	_, err := c.RefundReviewAsFraud("rev_1", "0xabc")
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/reviews/rev_1/close", path)
+	assert.Equal(t, map[string]interface{}{"reason": "refunded_as_fraud", "refund_address": "0xabc"}, body)
+}
+
+func TestChargeRequestsValidateBeforeSending(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	_, err := c.CloseReview(&developer.ReviewCloseRequest{ReviewID: "rev_1", Reason: "maybe"})
+	assert.Error(t, err)
+	_, err = c.CloseReview(&developer.ReviewCloseRequest{Reason: developer.ReviewClosedReasonApproved})
+	assert.Error(t, err)
+	_, err = c.ReportChargeFraud(&developer.ChargeFraudReportRequest{ChargeID: "ch_1", UserReport: "unsure"})
+	assert.Error(t, err)
+}
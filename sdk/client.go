@@ -5,6 +5,7 @@ package martianpay
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -13,6 +14,9 @@ import (
 	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
 )
 
 const (
@@ -23,14 +27,23 @@ const (
 // Client represents a MartianPay API client.
 // It contains the authentication credentials and configuration needed to access the API.
 type Client struct {
-	APIKey  string // API key for authentication
-	BaseURL string // Base URL for API requests, defaults to DefaultAPIURL
+	APIKey      string             // API key for authentication, used when Credentials is nil
+	BaseURL     string             // Base URL for API requests, defaults to DefaultAPIURL
+	Credentials CredentialProvider // Optional source of the API key, consulted for every request
+
+	// AllowedModes restricts the key modes the client may use (empty allows both test and live)
+	AllowedModes []developer.ApiKeyMode
+
+	publishable bool            // allows pk_ keys for publishable-key endpoints
+	ctx         context.Context // bounds every request when set by withContext
 }
 
 // NewClient creates a new MartianPay client instance.
+// A publishable (pk_) key is rejected with ErrPublishableKey when the first request is made;
+// use NewClientForModes to have the key checked when the client is created.
 //
 // Parameters:
-//   - apiKey: The MartianPay API key for authentication
+//   - apiKey: The MartianPay secret API key for authentication
 //
 // Returns:
 //   - *Client: An initialized client instance
@@ -41,6 +54,47 @@ func NewClient(apiKey string) *Client {
 	}
 }
 
+// NewClientWithCredentials creates a client that asks provider for the API key on every request,
+// so keys can be rotated without restarting the process.
+//
+// Parameters:
+//   - provider: Source of the API key (see StaticCredentials, EnvCredentials, FileCredentials and DualKeyCredentials)
+//
+// Returns:
+//   - *Client: An initialized client instance
+func NewClientWithCredentials(provider CredentialProvider) *Client {
+	return &Client{
+		BaseURL:     DefaultAPIURL,
+		Credentials: provider,
+	}
+}
+
+// withContext returns a shallow copy of the client whose requests are bound to ctx,
+// so a cancelled or expired context aborts a request that is still in flight
+func (c *Client) withContext(ctx context.Context) *Client {
+	cc := *c
+	cc.ctx = ctx
+	return &cc
+}
+
+// credentials returns the provider used for requests, falling back to the static APIKey
+func (c *Client) credentials() CredentialProvider {
+	if c.Credentials != nil {
+		return c.Credentials
+	}
+	return StaticCredentials(c.APIKey)
+}
+
+// String describes the client without revealing its API key, so clients are safe to log
+func (c *Client) String() string {
+	return fmt.Sprintf("martianpay.Client{BaseURL: %q, Credentials: %v}", c.BaseURL, c.credentials())
+}
+
+// GoString is like String, so %#v does not reveal the API key either
+func (c *Client) GoString() string {
+	return c.String()
+}
+
 // CommonResponse represents the standard API response structure.
 // All API responses follow this format for consistency.
 type CommonResponse struct {
@@ -108,66 +162,16 @@ func getHTTPStatusText(statusCode int) string {
 // Returns:
 //   - error: nil on success, error describing the failure otherwise
 func (c *Client) sendRequest(method, path string, body interface{}, response interface{}) error {
-	var bodyReader io.Reader
+	var bodyBytes []byte
 	if body != nil {
-		bodyBytes, err := json.Marshal(body)
+		var err error
+		bodyBytes, err = json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("error marshaling request: %v", err)
 		}
-		bodyReader = bytes.NewReader(bodyBytes)
 	}
 
-	url := fmt.Sprintf("%s%s", c.BaseURL, path)
-	req, err := http.NewRequest(method, url, bodyReader)
-	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	authStr := base64.StdEncoding.EncodeToString([]byte(c.APIKey + ":"))
-	req.Header.Set("Authorization", "Basic "+authStr)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("error sending request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// Check HTTP status code first
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		statusText := getHTTPStatusText(resp.StatusCode)
-		if len(bodyBytes) > 0 {
-			return fmt.Errorf("HTTP %d %s: %s", resp.StatusCode, statusText, string(bodyBytes))
-		}
-		return fmt.Errorf("HTTP %d %s", resp.StatusCode, statusText)
-	}
-
-	var commonResp CommonResponse
-	if err := json.NewDecoder(resp.Body).Decode(&commonResp); err != nil {
-		return fmt.Errorf("error decoding response: %v", err)
-	}
-
-	// Check for business-level errors (error_code field)
-	// Skip error check if error_code is empty, "ok", or "success"
-	if commonResp.ErrorCode != "" && commonResp.ErrorCode != "ok" && commonResp.ErrorCode != "success" {
-		return fmt.Errorf("API error [%s]: %s", commonResp.ErrorCode, commonResp.Msg)
-	}
-
-	// Legacy: check deprecated Code field
-	if commonResp.Code != 0 {
-		return fmt.Errorf("API error: %s", commonResp.Msg)
-	}
-
-	// Skip unmarshaling if response is nil (e.g., for DELETE operations)
-	if response != nil {
-		if err := json.Unmarshal(commonResp.Data, response); err != nil {
-			return fmt.Errorf("error unmarshaling data: %v", err)
-		}
-	}
-
-	return nil
+	return c.do(method, fmt.Sprintf("%s%s", c.BaseURL, path), bodyBytes, response)
 }
 
 // sendRequestWithQuery sends an HTTP request with query parameters.
@@ -189,12 +193,10 @@ func (c *Client) sendRequestWithQuery(method, path string, params interface{}, r
 	if params != nil {
 		queryParams := url.Values{}
 		v := reflect.ValueOf(params)
-		t := reflect.TypeOf(params)
 
 		// Handle pointer types
 		if v.Kind() == reflect.Ptr {
 			v = v.Elem()
-			t = t.Elem()
 		}
 
 		// Handle map types
@@ -209,78 +211,147 @@ func (c *Client) sendRequestWithQuery(method, path string, params interface{}, r
 			}
 		} else if v.Kind() == reflect.Struct {
 			// Handle struct types
-			for i := 0; i < v.NumField(); i++ {
-				field := v.Field(i)
-				fieldType := t.Field(i)
-
-				// Try form tag first, then fall back to json tag
-				tag := fieldType.Tag.Get("form")
-				if tag == "" {
-					tag = fieldType.Tag.Get("json")
-				}
+			addStructQuery(queryParams, v)
+		}
+
+		if len(queryParams) > 0 {
+			urlStr = urlStr + "?" + queryParams.Encode()
+		}
+	}
+
+	return c.do(method, urlStr, nil, response)
+}
 
-				// Skip if field is empty or nil
-				if tag == "" || tag == "-" {
+// addStructQuery adds the tagged, non-empty fields of a struct to the query parameters.
+// Embedded structs without a tag, such as developer.Pagination, are flattened into the
+// same query, matching how their fields are marshaled to JSON.
+func addStructQuery(queryParams url.Values, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		fieldType := t.Field(i)
+
+		// Try form tag first, then fall back to json tag
+		tag := fieldType.Tag.Get("form")
+		if tag == "" {
+			tag = fieldType.Tag.Get("json")
+		}
+
+		// Flatten untagged embedded structs
+		if tag == "" && fieldType.Anonymous {
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
 					continue
 				}
+				field = field.Elem()
+			}
+			if field.Kind() == reflect.Struct {
+				addStructQuery(queryParams, field)
+			}
+			continue
+		}
 
-				// Extract field name from tag (handle comma-separated options)
-				fieldName := tag
-				if idx := len(tag); idx > 0 {
-					if commaIdx := 0; commaIdx < idx {
-						for j, c := range tag {
-							if c == ',' {
-								fieldName = tag[:j]
-								break
-							}
-						}
-					}
-				}
+		// Skip if field is empty or nil
+		if tag == "" || tag == "-" {
+			continue
+		}
 
-				// Add non-zero values to query params
-				switch field.Kind() {
+		// Extract field name from tag (handle comma-separated options)
+		fieldName := tag
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			fieldName = tag[:idx]
+		}
+
+		// Add non-zero values to query params
+		switch field.Kind() {
+		case reflect.String:
+			if field.String() != "" {
+				queryParams.Add(fieldName, field.String())
+			}
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			queryParams.Add(fieldName, strconv.FormatInt(field.Int(), 10))
+		case reflect.Bool:
+			queryParams.Add(fieldName, strconv.FormatBool(field.Bool()))
+		case reflect.Ptr:
+			if !field.IsNil() {
+				switch field.Elem().Kind() {
 				case reflect.String:
-					if field.String() != "" {
-						queryParams.Add(fieldName, field.String())
-					}
-				case reflect.Int, reflect.Int32, reflect.Int64:
-					queryParams.Add(fieldName, strconv.FormatInt(field.Int(), 10))
+					queryParams.Add(fieldName, field.Elem().String())
 				case reflect.Bool:
-					queryParams.Add(fieldName, strconv.FormatBool(field.Bool()))
-				case reflect.Ptr:
-					if !field.IsNil() {
-						switch field.Elem().Kind() {
-						case reflect.String:
-							queryParams.Add(fieldName, field.Elem().String())
-						case reflect.Bool:
-							queryParams.Add(fieldName, strconv.FormatBool(field.Elem().Bool()))
-						}
-					}
+					queryParams.Add(fieldName, strconv.FormatBool(field.Elem().Bool()))
+				case reflect.Int, reflect.Int32, reflect.Int64:
+					queryParams.Add(fieldName, strconv.FormatInt(field.Elem().Int(), 10))
 				}
 			}
 		}
+	}
+}
+
+// do sends a request with credentials from the client's CredentialProvider and decodes the response.
+// If the API rejects the key with 401 and the provider implements CredentialRejecter, the request
+// is retried once with the provider's next key.
+//
+// Parameters:
+//   - method: HTTP method
+//   - urlStr: Full request URL including any query string
+//   - body: JSON request body (nil for no body)
+//   - response: Pointer to struct to unmarshal response data into (can be nil)
+//
+// Returns:
+//   - error: nil on success, error describing the failure otherwise
+func (c *Client) do(method, urlStr string, body []byte, response interface{}) error {
+	creds := c.credentials()
+	for attempt := 0; ; attempt++ {
+		apiKey, err := creds.APIKey()
+		if err != nil {
+			return fmt.Errorf("error loading API key: %v", err)
+		}
+		keyInfo, err := c.checkKey(apiKey)
+		if err != nil {
+			return err
+		}
 
-		if len(queryParams) > 0 {
-			urlStr = urlStr + "?" + queryParams.Encode()
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+		ctx := c.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		req, err := http.NewRequestWithContext(ctx, method, urlStr, bodyReader)
+		if err != nil {
+			return fmt.Errorf("error creating request: %v", err)
 		}
-	}
 
-	req, err := http.NewRequest(method, urlStr, nil)
-	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
-	}
+		req.Header.Set("Content-Type", "application/json")
+		authStr := base64.StdEncoding.EncodeToString([]byte(apiKey + ":"))
+		req.Header.Set("Authorization", "Basic "+authStr)
 
-	req.Header.Set("Content-Type", "application/json")
-	authStr := base64.StdEncoding.EncodeToString([]byte(c.APIKey + ":"))
-	req.Header.Set("Authorization", "Basic "+authStr)
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error sending request: %v", err)
+		}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("error sending request: %v", err)
+		// Let a rotating provider switch to its next key and try again
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			if rejecter, ok := creds.(CredentialRejecter); ok && rejecter.Rejected(apiKey) {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				continue
+			}
+		}
+
+		err = decodeResponse(resp, response, keyInfo)
+		resp.Body.Close()
+		return err
 	}
-	defer resp.Body.Close()
+}
 
+// decodeResponse checks the HTTP status and business error code of a response, verifies its
+// livemode against the key (when keyInfo is known), and unmarshals its data into response.
+func decodeResponse(resp *http.Response, response interface{}, keyInfo *developer.ApiKeyInfo) error {
 	// Check HTTP status code first
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
@@ -307,6 +378,10 @@ func (c *Client) sendRequestWithQuery(method, path string, params interface{}, r
 		return fmt.Errorf("API error: %s", commonResp.Msg)
 	}
 
+	if err := checkLivemode(keyInfo, commonResp.Data); err != nil {
+		return err
+	}
+
 	// Skip unmarshaling if response is nil (e.g., for DELETE operations)
 	if response != nil {
 		if err := json.Unmarshal(commonResp.Data, response); err != nil {
@@ -0,0 +1,74 @@
+// client_test.go contains unit tests for request encoding and the shared test server helpers.
+package martianpay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient starts a server with the handler and returns a client pointed at it
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c := NewClient("sk_test_123")
+	c.BaseURL = server.URL
+	return c
+}
+
+// writeData writes data in the API's response envelope
+func writeData(t *testing.T, w http.ResponseWriter, data interface{}) {
+	raw, err := json.Marshal(data)
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(w).Encode(CommonResponse{Msg: "success", Data: raw}))
+}
+
+func TestSendRequestWithQueryFlattensEmbeddedStructs(t *testing.T) {
+	var query map[string][]string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		writeData(t, w, developer.DepositAccountListResponse{})
+	})
+
+	userID := "u1"
+	permanent := true
+	_, err := c.ListDepositAccounts(&developer.DepositAccountListRequest{
+		Pagination:  developer.Pagination{Page: 2, PageSize: 50},
+		UserId:      &userID,
+		IsPermanent: &permanent,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"page":         {"2"},
+		"page_size":    {"50"},
+		"user_id":      {"u1"},
+		"is_permanent": {"true"},
+	}, query)
+}
+
+func TestListUserDepositAccountsFollowsPages(t *testing.T) {
+	var pages []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		resp := developer.DepositAccountListResponse{Total: 3}
+		switch page {
+		case "0":
+			resp.DepositAccounts = []*developer.DepositAccount{{DepositAddress: "addr_1"}, {DepositAddress: "addr_2"}}
+		case "1":
+			resp.DepositAccounts = []*developer.DepositAccount{{DepositAddress: "addr_3"}}
+		}
+		writeData(t, w, resp)
+	})
+
+	accounts, err := c.ListUserDepositAccounts("u1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, pages)
+	require.Len(t, accounts, 3)
+	assert.Equal(t, "addr_3", accounts[2].DepositAddress)
+}
@@ -0,0 +1,224 @@
+// Package martianpay provides credential providers that supply the API key for each request.
+// Providers let services rotate keys without a restart: the key can come from an environment
+// variable, a file that is re-read when it changes, or a pair of keys used during a rollover.
+// Every provider formats itself with the key redacted, so it is safe to log.
+package martianpay
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CredentialProvider supplies the API key for a request. It is called once per request
+// and must be safe for concurrent use.
+type CredentialProvider interface {
+	// APIKey returns the key to authenticate the next request with
+	APIKey() (string, error)
+}
+
+// CredentialRejecter is implemented by providers that hold more than one key.
+// When the API answers 401, the client calls Rejected with the key that was refused;
+// if it returns true, the request is retried once with the provider's next key.
+type CredentialRejecter interface {
+	Rejected(apiKey string) bool
+}
+
+// RedactAPIKey returns a form of the key that is safe to log: its prefix and last four characters
+//
+// Parameters:
+//   - apiKey: The key to redact
+//
+// Returns:
+//   - string: For example "sk_live_…3f9a", or "" for an empty key
+func RedactAPIKey(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	prefix := ""
+	for _, p := range []string{"sk_test_", "sk_live_", "pk_test_", "pk_live_"} {
+		if strings.HasPrefix(apiKey, p) {
+			prefix = p
+			break
+		}
+	}
+	rest := apiKey[len(prefix):]
+	if len(rest) <= 8 {
+		return prefix + "…"
+	}
+	return prefix + "…" + rest[len(rest)-4:]
+}
+
+// StaticCredentials is a fixed API key
+type StaticCredentials string
+
+// APIKey returns the key
+func (s StaticCredentials) APIKey() (string, error) {
+	if s == "" {
+		return "", errors.New("no API key configured")
+	}
+	return string(s), nil
+}
+
+// String returns the redacted key
+func (s StaticCredentials) String() string {
+	return "StaticCredentials(" + RedactAPIKey(string(s)) + ")"
+}
+
+// GoString returns the redacted key
+func (s StaticCredentials) GoString() string {
+	return s.String()
+}
+
+// EnvCredentials reads the API key from an environment variable on every request
+type EnvCredentials string
+
+// APIKey returns the current value of the environment variable
+func (e EnvCredentials) APIKey() (string, error) {
+	key := strings.TrimSpace(os.Getenv(string(e)))
+	if key == "" {
+		return "", fmt.Errorf("environment variable %s is not set", string(e))
+	}
+	return key, nil
+}
+
+// String returns the variable name, never its value
+func (e EnvCredentials) String() string {
+	return "EnvCredentials($" + string(e) + ")"
+}
+
+// FileCredentials reads the API key from a file, such as a mounted Kubernetes secret.
+// The file is re-read whenever its modification time or size changes, so a new key takes
+// effect on the next request after the file is replaced.
+type FileCredentials struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileCredentials returns a provider for the key stored in path. Surrounding whitespace is ignored.
+//
+// Returns:
+//   - *FileCredentials: The provider
+//   - error: Error if the file cannot be read or is empty
+func NewFileCredentials(path string) (*FileCredentials, error) {
+	f := &FileCredentials{path: path}
+	if _, err := f.APIKey(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// APIKey returns the key from the file, reloading it if the file changed.
+// If a reload fails, the previously loaded key is kept.
+func (f *FileCredentials) APIKey() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if f.key != "" {
+			return f.key, nil
+		}
+		return "", fmt.Errorf("error reading API key file: %v", err)
+	}
+	if f.key != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.key, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if f.key != "" {
+			return f.key, nil
+		}
+		return "", fmt.Errorf("error reading API key file: %v", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		if f.key != "" {
+			return f.key, nil
+		}
+		return "", fmt.Errorf("API key file %s is empty", f.path)
+	}
+	f.key, f.modTime, f.size = key, info.ModTime(), info.Size()
+	return f.key, nil
+}
+
+// String returns the file path and the redacted key
+func (f *FileCredentials) String() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return fmt.Sprintf("FileCredentials(%s, %s)", f.path, RedactAPIKey(f.key))
+}
+
+// GoString returns the file path and the redacted key
+func (f *FileCredentials) GoString() string {
+	return f.String()
+}
+
+// DualKeyCredentials holds the current key and its replacement during a rotation.
+// Requests use the active key; when the API rejects it with 401, the provider switches to
+// the next key and the request is retried. Once switched, it does not switch back.
+type DualKeyCredentials struct {
+	mu     sync.Mutex
+	keys   []string
+	active int
+}
+
+// NewDualKeyCredentials returns a provider that starts with primary and falls back to secondary
+//
+// Parameters:
+//   - primary: The key to use first, usually the current key
+//   - secondary: The key to fall back to on 401, usually the newly rolled key
+//
+// Returns:
+//   - *DualKeyCredentials: The provider
+func NewDualKeyCredentials(primary, secondary string) *DualKeyCredentials {
+	return &DualKeyCredentials{keys: []string{primary, secondary}}
+}
+
+// APIKey returns the active key
+func (d *DualKeyCredentials) APIKey() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := d.keys[d.active]
+	if key == "" {
+		return "", errors.New("no API key configured")
+	}
+	return key, nil
+}
+
+// Rejected switches to the next key if apiKey is the active key and another key remains.
+// Concurrent requests rejected with the same key switch only once.
+func (d *DualKeyCredentials) Rejected(apiKey string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.keys[d.active] != apiKey {
+		// Another request already switched; retry with the new active key
+		return true
+	}
+	if d.active+1 >= len(d.keys) || d.keys[d.active+1] == "" {
+		return false
+	}
+	d.active++
+	return true
+}
+
+// String returns the redacted keys and which one is active
+func (d *DualKeyCredentials) String() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return fmt.Sprintf("DualKeyCredentials(primary=%s, secondary=%s, active=%d)",
+		RedactAPIKey(d.keys[0]), RedactAPIKey(d.keys[1]), d.active)
+}
+
+// GoString returns the redacted keys and which one is active
+func (d *DualKeyCredentials) GoString() string {
+	return d.String()
+}
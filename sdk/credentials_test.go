@@ -0,0 +1,88 @@
+// credentials_test.go contains unit tests for credential providers and the 401 key fallback.
+package martianpay
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCredentialsReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_key")
+	_, err := NewFileCredentials(path)
+	assert.Error(t, err, "missing file")
+	require.NoError(t, os.WriteFile(path, []byte("  \n"), 0o600))
+	_, err = NewFileCredentials(path)
+	assert.Error(t, err, "empty file")
+
+	require.NoError(t, os.WriteFile(path, []byte("sk_test_first\n"), 0o600))
+	creds, err := NewFileCredentials(path)
+	require.NoError(t, err)
+	key, err := creds.APIKey()
+	require.NoError(t, err)
+	assert.Equal(t, "sk_test_first", key)
+
+	require.NoError(t, os.WriteFile(path, []byte("sk_test_second_key"), 0o600))
+	key, err = creds.APIKey()
+	require.NoError(t, err)
+	assert.Equal(t, "sk_test_second_key", key)
+
+	// A failed reload keeps the last good key
+	require.NoError(t, os.Remove(path))
+	key, err = creds.APIKey()
+	require.NoError(t, err)
+	assert.Equal(t, "sk_test_second_key", key)
+	assert.NotContains(t, creds.String(), "second_key")
+}
+
+func TestDualKeyCredentialsRetryOn401(t *testing.T) {
+	var seen []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		key, _, _ := r.BasicAuth()
+		seen = append(seen, key)
+		if key != "sk_test_new_key_2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeData(t, w, developer.WebhookEndpoint{ID: "wh_1"})
+	})
+	creds := NewDualKeyCredentials("sk_test_old_key_1", "sk_test_new_key_2")
+	c.Credentials = creds
+
+	endpoint, err := c.GetWebhookEndpoint("wh_1")
+	require.NoError(t, err)
+	assert.Equal(t, "wh_1", endpoint.ID)
+	assert.Equal(t, []string{"sk_test_old_key_1", "sk_test_new_key_2"}, seen)
+
+	// The provider stays on the new key
+	seen = nil
+	_, err = c.GetWebhookEndpoint("wh_1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sk_test_new_key_2"}, seen)
+	assert.NotContains(t, c.String(), "new_key")
+}
+
+func TestRetryOn401HappensOnce(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	c.Credentials = NewDualKeyCredentials("sk_test_old_key_1", "sk_test_new_key_2")
+	_, err := c.GetWebhookEndpoint("wh_1")
+	assert.ErrorContains(t, err, "401")
+	assert.Equal(t, 2, requests)
+
+	// Providers without a second key are not retried
+	requests = 0
+	c.Credentials = StaticCredentials("sk_test_only_key_1")
+	_, err = c.GetWebhookEndpoint("wh_1")
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+}
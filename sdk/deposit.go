@@ -0,0 +1,111 @@
+// Package martianpay provides SDK methods for permanent deposit addresses.
+// A permanent deposit address belongs to one end user and one asset, and keeps receiving
+// deposits for as long as the user exists, which suits exchange and wallet top-ups.
+package martianpay
+
+import (
+	"fmt"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// GetOrCreateDepositAddress returns the permanent deposit address of a user for an asset,
+// creating it on first use. Calling it again for the same user and asset returns the same address.
+//
+// Parameters:
+//   - userID: The merchant's identifier for the end user
+//   - assetID: The asset the user will deposit (e.g., "USDT-TRON")
+//
+// Returns:
+//   - *developer.DepositAccount: The permanent deposit account with its address
+//   - error: nil on success, error on failure (e.g., unsupported asset)
+func (c *Client) GetOrCreateDepositAddress(userID, assetID string) (*developer.DepositAccount, error) {
+	if userID == "" || assetID == "" {
+		return nil, fmt.Errorf("user ID and asset ID are required")
+	}
+	req := &developer.DepositAccountCreateRequest{UserId: userID, AssetId: assetID}
+	var response developer.DepositAccount
+	err := c.sendRequest("POST", "/v1/deposit_accounts", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListDepositAccounts retrieves a paginated list of deposit accounts with their transactions.
+//
+// Parameters:
+//   - req: Pagination and filters for user, asset, and permanent accounts
+//
+// Returns:
+//   - *developer.DepositAccountListResponse: One page of deposit accounts
+//   - error: nil on success, error on failure
+func (c *Client) ListDepositAccounts(req *developer.DepositAccountListRequest) (*developer.DepositAccountListResponse, error) {
+	var response developer.DepositAccountListResponse
+	err := c.sendRequestWithQuery("GET", "/v1/deposit_accounts", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListUserDepositAccounts retrieves every permanent deposit account of a user, following all pages.
+//
+// Parameters:
+//   - userID: The merchant's identifier for the end user
+//
+// Returns:
+//   - []*developer.DepositAccount: All of the user's permanent deposit accounts
+//   - error: nil on success, error on failure
+func (c *Client) ListUserDepositAccounts(userID string) ([]*developer.DepositAccount, error) {
+	permanent := true
+	req := &developer.DepositAccountListRequest{
+		Pagination:  developer.Pagination{Page: 0, PageSize: 50},
+		UserId:      &userID,
+		IsPermanent: &permanent,
+	}
+	var accounts []*developer.DepositAccount
+	for {
+		page, err := c.ListDepositAccounts(req)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, page.DepositAccounts...)
+		if len(page.DepositAccounts) == 0 || int64(len(accounts)) >= page.Total {
+			return accounts, nil
+		}
+		req.Page++
+	}
+}
+
+// ListUserDepositTransactions retrieves the deposit transactions to all of a user's permanent addresses, oldest first.
+//
+// Parameters:
+//   - userID: The merchant's identifier for the end user
+//
+// Returns:
+//   - []*developer.Transaction: The user's deposit transactions, each hash once
+//   - error: nil on success, error on failure
+func (c *Client) ListUserDepositTransactions(userID string) ([]*developer.Transaction, error) {
+	accounts, err := c.ListUserDepositAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	return developer.DepositTransactions(accounts), nil
+}
+
+// GetUserDepositTotals computes a user's credited, pending, and rejected deposits per asset.
+//
+// Parameters:
+//   - userID: The merchant's identifier for the end user
+//
+// Returns:
+//   - []*developer.DepositTotal: Totals per asset
+//   - error: nil on success, error on failure
+func (c *Client) GetUserDepositTotals(userID string) ([]*developer.DepositTotal, error) {
+	accounts, err := c.ListUserDepositAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	return developer.DepositTotals(accounts)
+}
@@ -0,0 +1,44 @@
+// Package martianpay provides SDK methods for reading the event log.
+// The event log holds every webhook event, so it can be used to recover events missed during outages.
+package martianpay
+
+import (
+	"fmt"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// ListEvents retrieves a paginated list of events, newest first.
+// Can be filtered by event type, creation time range, and the resource the event is about.
+//
+// Parameters:
+//   - req: Query parameters including pagination and filters (type, created range, object ID)
+//
+// Returns:
+//   - *developer.EventListResp: List of events with pagination metadata
+//   - error: nil on success, error on failure
+func (c *Client) ListEvents(req *developer.EventListRequest) (*developer.EventListResp, error) {
+	var response developer.EventListResp
+	err := c.sendRequestWithQuery("GET", "/v1/events", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetEvent retrieves a single event by ID.
+//
+// Parameters:
+//   - eventID: The unique identifier of the event
+//
+// Returns:
+//   - *developer.Event: The event, with the object as it was when the event occurred
+//   - error: nil on success, error on failure (e.g., event not found)
+func (c *Client) GetEvent(eventID string) (*developer.Event, error) {
+	var response developer.Event
+	err := c.sendRequest("GET", fmt.Sprintf("/v1/events/%s", eventID), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,109 @@
+// Package martianpay provides live/test mode guardrails based on API key prefixes.
+// The client reads the key type and mode from the prefix of every key it sends, refuses
+// publishable keys for secret-key endpoints, optionally restricts the modes it may use, and
+// checks that the livemode flag of each response matches the key's mode.
+package martianpay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+var (
+	// ErrPublishableKey is returned when a publishable (pk_) key is used for a secret-key endpoint
+	ErrPublishableKey = errors.New("publishable API key cannot be used for secret-key endpoints")
+	// ErrModeNotAllowed is returned when the key's mode is not in the client's AllowedModes
+	ErrModeNotAllowed = errors.New("API key mode is not allowed for this client")
+	// ErrLivemodeMismatch is returned when a response's livemode flag does not match the key's mode
+	ErrLivemodeMismatch = errors.New("response livemode does not match API key mode")
+)
+
+// NewClientForModes creates a client that only accepts secret keys of the allowed modes.
+// For example, NewClientForModes(key, developer.ApiKeyModeTest) in CI makes it impossible
+// to move real funds even if a live key is configured by mistake. Unlike NewClient, it
+// reports a publishable or disallowed key as soon as the client is created.
+//
+// Parameters:
+//   - apiKey: The MartianPay secret API key
+//   - allowed: Modes the client may use; at least one is required
+//
+// Returns:
+//   - *Client: An initialized client instance
+//   - error: Error if the key is not a secret key or its mode is not allowed
+func NewClientForModes(apiKey string, allowed ...developer.ApiKeyMode) (*Client, error) {
+	if len(allowed) == 0 {
+		return nil, errors.New("at least one allowed mode is required")
+	}
+	c := NewClient(apiKey)
+	c.AllowedModes = allowed
+	if _, err := c.checkKey(apiKey); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// KeyInfo returns the type and mode of the key the client currently uses
+//
+// Returns:
+//   - *developer.ApiKeyInfo: The key type and mode
+//   - error: Error if the key cannot be loaded or has no known prefix
+func (c *Client) KeyInfo() (*developer.ApiKeyInfo, error) {
+	apiKey, err := c.credentials().APIKey()
+	if err != nil {
+		return nil, err
+	}
+	return developer.ParseApiKey(apiKey)
+}
+
+// checkKey applies the client's key guards before a request is sent.
+// Keys without a known prefix are allowed unless AllowedModes is set; the returned
+// info is nil for them and the livemode check is skipped. Clients created by PublicClient
+// only accept publishable keys.
+func (c *Client) checkKey(apiKey string) (*developer.ApiKeyInfo, error) {
+	info, err := developer.ParseApiKey(apiKey)
+	if err != nil {
+		if len(c.AllowedModes) > 0 {
+			return nil, fmt.Errorf("%w: %v", ErrModeNotAllowed, err)
+		}
+		return nil, nil
+	}
+	if !info.IsSecret() && !c.publishable {
+		return nil, ErrPublishableKey
+	}
+	if info.IsSecret() && c.publishable {
+		return nil, ErrSecretKeyInPublicClient
+	}
+	if len(c.AllowedModes) > 0 {
+		allowed := false
+		for _, m := range c.AllowedModes {
+			if m == info.Mode {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("%w: key is %s mode, allowed %v", ErrModeNotAllowed, info.Mode, c.AllowedModes)
+		}
+	}
+	return info, nil
+}
+
+// checkLivemode compares the top-level livemode field of the response data, if any, with the key's mode
+func checkLivemode(info *developer.ApiKeyInfo, data json.RawMessage) error {
+	if info == nil || len(data) == 0 || data[0] != '{' {
+		return nil
+	}
+	var flags struct {
+		Livemode *bool `json:"livemode"`
+	}
+	if err := json.Unmarshal(data, &flags); err != nil || flags.Livemode == nil {
+		return nil
+	}
+	if *flags.Livemode != info.IsLive() {
+		return fmt.Errorf("%w: key is %s mode, response livemode=%t", ErrLivemodeMismatch, info.Mode, *flags.Livemode)
+	}
+	return nil
+}
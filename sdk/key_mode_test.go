@@ -0,0 +1,62 @@
+// key_mode_test.go contains unit tests for the live/test mode guardrails.
+package martianpay
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRefusesPublishableKey(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeData(t, w, developer.WebhookEndpoint{ID: "wh_1"})
+	})
+	c.APIKey = "pk_test_123"
+	_, err := c.GetWebhookEndpoint("wh_1")
+	assert.ErrorIs(t, err, ErrPublishableKey)
+	assert.Equal(t, 0, requests, "the key is refused before any request is sent")
+
+	_, err = NewClientForModes("pk_test_123", developer.ApiKeyModeTest)
+	assert.ErrorIs(t, err, ErrPublishableKey)
+}
+
+func TestNewClientForModes(t *testing.T) {
+	c, err := NewClientForModes("sk_test_123", developer.ApiKeyModeTest)
+	require.NoError(t, err)
+	info, err := c.KeyInfo()
+	require.NoError(t, err)
+	assert.Equal(t, developer.ApiKeyModeTest, info.Mode)
+
+	_, err = NewClientForModes("sk_live_123", developer.ApiKeyModeTest)
+	assert.ErrorIs(t, err, ErrModeNotAllowed)
+	_, err = NewClientForModes("unprefixed", developer.ApiKeyModeTest)
+	assert.ErrorIs(t, err, ErrModeNotAllowed)
+	_, err = NewClientForModes("sk_test_123")
+	assert.Error(t, err)
+
+	// A rotated key is checked on every request
+	c.APIKey = "sk_live_456"
+	_, err = c.GetWebhookEndpoint("wh_1")
+	assert.ErrorIs(t, err, ErrModeNotAllowed)
+}
+
+func TestLivemodeMismatch(t *testing.T) {
+	livemode := true
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeData(t, w, developer.WebhookEndpoint{ID: "wh_1", Livemode: livemode})
+	})
+
+	// c uses a test key
+	_, err := c.GetWebhookEndpoint("wh_1")
+	assert.ErrorIs(t, err, ErrLivemodeMismatch)
+
+	livemode = false
+	endpoint, err := c.GetWebhookEndpoint("wh_1")
+	require.NoError(t, err)
+	assert.Equal(t, "wh_1", endpoint.ID)
+}
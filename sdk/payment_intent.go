@@ -4,6 +4,7 @@ package martianpay
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
 )
@@ -63,6 +64,27 @@ func (c *Client) GetPaymentIntent(id string) (*developer.PaymentIntentGetResp, e
 	return &response, nil
 }
 
+// GetPaymentIntentTimeline retrieves a payment intent and flattens it into a chronological
+// timeline of charges, transactions, refunds, and unfreeze withdrawals with block explorer links.
+//
+// Parameters:
+//   - id: The unique identifier of the payment intent
+//
+// Returns:
+//   - *developer.PaymentTimeline: The timeline, oldest entry first; render it with String or JSON
+//   - error: nil on success, error on failure (e.g., payment intent not found)
+func (c *Client) GetPaymentIntentTimeline(id string) (*developer.PaymentTimeline, error) {
+	pi, err := c.GetPaymentIntent(id)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := c.GetAllAssets()
+	if err != nil {
+		return nil, fmt.Errorf("error loading assets for explorer links: %v", err)
+	}
+	return developer.BuildPaymentTimeline(&pi.PaymentIntent, developer.NewAssetRegistry(assets)), nil
+}
+
 // ListPaymentIntents retrieves a paginated list of payment intents.
 // Can be filtered by status, customer, date range, and other criteria.
 //
@@ -154,3 +176,61 @@ func (c *Client) CreatePaymentIntentInvoice(req *developer.PaymentIntentInvoiceC
 	}
 	return &response, nil
 }
+
+// ConfirmCardPayment confirms a payment intent with a card and returns the provider payload
+// the frontend needs to collect or charge the card.
+// With Stripe, pass CardPayment.Stripe.ClientSecret to Stripe.js; with PayerMax, render the
+// drop-in component and finalize from the frontend with PublicClient.CreatePayerMaxDropinOrder.
+//
+// Parameters:
+//   - id: The unique identifier of the payment intent
+//   - currency: The fiat currency to charge
+//   - paymentMethodID: A saved payment method ID, or "" for a new card
+//   - savePaymentMethod: Whether to save a new card for future payments
+//
+// Returns:
+//   - *developer.PaymentIntentUpdateResp: The confirmed payment intent
+//   - *developer.CardPayment: The card charge and its Stripe or PayerMax payload
+//   - error: nil on success, developer.ErrNoCardPayload if the response has no card payload, or the request error
+func (c *Client) ConfirmCardPayment(id, currency, paymentMethodID string, savePaymentMethod bool) (*developer.PaymentIntentUpdateResp, *developer.CardPayment, error) {
+	if currency == "" {
+		return nil, nil, fmt.Errorf("fiat currency is required")
+	}
+	response, err := c.UpdatePaymentIntent(id, developer.NewCardConfirmRequest(id, currency, paymentMethodID, savePaymentMethod))
+	if err != nil {
+		return nil, nil, err
+	}
+	payment, err := response.CardPayment()
+	if err != nil {
+		return response, nil, err
+	}
+	return response, payment, nil
+}
+
+// ChargeSavedCard confirms a payment intent with one of the customer's saved cards.
+// The card is looked up first, so an unknown or expired card fails before the payment intent is confirmed.
+//
+// Parameters:
+//   - id: The unique identifier of the payment intent
+//   - customerID: The customer who owns the saved card
+//   - paymentMethodID: The saved payment method ID
+//   - currency: The fiat currency to charge
+//
+// Returns:
+//   - *developer.PaymentIntentUpdateResp: The confirmed payment intent
+//   - *developer.CardPayment: The card charge and its provider payload
+//   - error: nil on success, error if the card is not usable or the request fails
+func (c *Client) ChargeSavedCard(id, customerID, paymentMethodID, currency string) (*developer.PaymentIntentUpdateResp, *developer.CardPayment, error) {
+	methods, err := c.ListCustomerPaymentMethods(customerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing payment methods: %v", err)
+	}
+	method := methods.Find(paymentMethodID)
+	if method == nil {
+		return nil, nil, fmt.Errorf("payment method %s is not saved for customer %s", paymentMethodID, customerID)
+	}
+	if method.IsExpired(time.Now()) {
+		return nil, nil, fmt.Errorf("payment method %s expired %02d/%d", paymentMethodID, method.ExpMonth, method.ExpYear)
+	}
+	return c.ConfirmCardPayment(id, currency, paymentMethodID, false)
+}
@@ -0,0 +1,99 @@
+// payment_intent_test.go contains unit tests for confirming payment intents with cards.
+package martianpay
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cardServer serves saved payment methods and payment intent confirmations, and records the confirm bodies
+type cardServer struct {
+	methods  []*developer.PaymentMethodCard
+	charge   *developer.Charge
+	confirms []map[string]interface{}
+}
+
+func (s *cardServer) handle(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/customers/payment_methods":
+			assert.Equal(t, "cus_1", r.URL.Query().Get("customer_id"))
+			writeData(t, w, developer.PaymentMethodListResponse{PaymentMethods: s.methods})
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/payment_intents/pi_1":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			s.confirms = append(s.confirms, body)
+			pi := developer.PaymentIntent{ID: "pi_1"}
+			if s.charge != nil {
+				pi.Charges = []*developer.Charge{s.charge}
+			}
+			writeData(t, w, pi)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func TestChargeSavedCard(t *testing.T) {
+	nextYear := int64(time.Now().Year() + 1)
+	srv := &cardServer{
+		methods: []*developer.PaymentMethodCard{{ID: "pm_1", Last4: "4242", ExpMonth: 12, ExpYear: nextYear}},
+		charge: &developer.Charge{ID: "ch_1", PaymentMethodType: developer.PaymentMethodTypeCards,
+			StripePayload: &developer.StripePayload{ClientSecret: "pi_stripe_secret"}},
+	}
+	c := newTestClient(t, srv.handle(t))
+
+	response, payment, err := c.ChargeSavedCard("pi_1", "cus_1", "pm_1", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "pi_1", response.ID)
+	assert.Equal(t, developer.CardProviderStripe, payment.Provider)
+	assert.Equal(t, "pi_stripe_secret", payment.Stripe.ClientSecret)
+
+	require.Len(t, srv.confirms, 1)
+	body := srv.confirms[0]
+	assert.Equal(t, "cards", body["payment_method_type"])
+	fiat := body["payment_method_options"].(map[string]interface{})["fiat"].(map[string]interface{})
+	assert.Equal(t, "USD", fiat["currency"])
+	assert.Equal(t, "pm_1", fiat["payment_method_id"])
+	assert.NotContains(t, fiat, "save_payment_method", "a saved card is charged as is")
+}
+
+func TestChargeSavedCardRejectsUnusableCards(t *testing.T) {
+	srv := &cardServer{methods: []*developer.PaymentMethodCard{{ID: "pm_old", ExpMonth: 1, ExpYear: 2020}}}
+	c := newTestClient(t, srv.handle(t))
+
+	_, _, err := c.ChargeSavedCard("pi_1", "cus_1", "pm_missing", "USD")
+	assert.ErrorContains(t, err, "not saved for customer cus_1")
+	_, _, err = c.ChargeSavedCard("pi_1", "cus_1", "pm_old", "USD")
+	assert.ErrorContains(t, err, "expired 01/2020")
+	assert.Empty(t, srv.confirms, "the payment intent is not confirmed")
+}
+
+func TestConfirmCardPaymentWithoutPayload(t *testing.T) {
+	srv := &cardServer{}
+	c := newTestClient(t, srv.handle(t))
+
+	response, payment, err := c.ConfirmCardPayment("pi_1", "USD", "", true)
+	assert.True(t, errors.Is(err, developer.ErrNoCardPayload))
+	assert.Nil(t, payment)
+	require.NotNil(t, response, "the confirmed payment intent is still returned")
+	assert.Equal(t, "pi_1", response.ID)
+
+	// A new card can be saved for later payments
+	require.Len(t, srv.confirms, 1)
+	fiat := srv.confirms[0]["payment_method_options"].(map[string]interface{})["fiat"].(map[string]interface{})
+	assert.Equal(t, true, fiat["save_payment_method"])
+	assert.NotContains(t, fiat, "payment_method_id")
+
+	_, _, err = c.ConfirmCardPayment("pi_1", "", "", false)
+	assert.Error(t, err)
+	assert.Len(t, srv.confirms, 1, "a missing currency fails before any request")
+}
@@ -0,0 +1,157 @@
+// Package martianpay provides a restricted client for customer-facing checkout flows.
+// A PublicClient is built from a publishable (pk_) key and only exposes the endpoints that
+// accept a publishable key together with a payment intent's client secret, so a browser,
+// mobile app, or backend-for-frontend can drive checkout without holding the secret key.
+package martianpay
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// ErrSecretKeyInPublicClient is returned when a PublicClient is given a secret (sk_) key
+var ErrSecretKeyInPublicClient = errors.New("PublicClient requires a publishable (pk_) key; never ship a secret key to clients")
+
+// PublicClient calls the publishable-key checkout endpoints of the MartianPay API
+type PublicClient struct {
+	BaseURL string // Base URL for API requests, defaults to DefaultAPIURL
+
+	key  string
+	info *developer.ApiKeyInfo
+}
+
+// NewPublicClient creates a client for checkout flows.
+//
+// Parameters:
+//   - publishableKey: A pk_test_ or pk_live_ key
+//
+// Returns:
+//   - *PublicClient: An initialized public client
+//   - error: Error if the key is not a publishable key
+func NewPublicClient(publishableKey string) (*PublicClient, error) {
+	info, err := developer.ParseApiKey(publishableKey)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsSecret() {
+		return nil, ErrSecretKeyInPublicClient
+	}
+	return &PublicClient{BaseURL: DefaultAPIURL, key: publishableKey, info: info}, nil
+}
+
+// KeyInfo returns the mode of the client's publishable key
+func (p *PublicClient) KeyInfo() *developer.ApiKeyInfo {
+	info := *p.info
+	return &info
+}
+
+// String describes the client without revealing its key
+func (p *PublicClient) String() string {
+	return fmt.Sprintf("martianpay.PublicClient{BaseURL: %q, Key: %s}", p.BaseURL, RedactAPIKey(p.key))
+}
+
+// GoString is like String
+func (p *PublicClient) GoString() string {
+	return p.String()
+}
+
+// client returns the underlying client, which accepts only this publishable key
+func (p *PublicClient) client() *Client {
+	return &Client{
+		BaseURL:      p.BaseURL,
+		Credentials:  StaticCredentials(p.key),
+		AllowedModes: []developer.ApiKeyMode{p.info.Mode},
+		publishable:  true,
+	}
+}
+
+// GetPaymentIntent retrieves a payment intent using its client secret.
+// The publishable key is filled in automatically.
+//
+// Parameters:
+//   - req: Payment intent ID, client secret, and the payment link ID when paying through a link
+//
+// Returns:
+//   - *developer.PaymentIntentGetResp: The payment intent as visible to the customer
+//   - error: nil on success, error on failure (e.g., missing or wrong client secret)
+func (p *PublicClient) GetPaymentIntent(req *developer.PaymentIntentGetRequest) (*developer.PaymentIntentGetResp, error) {
+	if req.ID == "" || req.ClientSecret == "" {
+		return nil, errors.New("payment intent ID and client secret are required")
+	}
+	query := *req
+	query.Key = &p.key
+	var response developer.PaymentIntentGetResp
+	err := p.client().sendRequestWithQuery("GET", fmt.Sprintf("/v1/payment_intents/%s", req.ID), &query, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdatePaymentIntentLink selects the payment method for a payment intent created from a payment link.
+// The publishable key is filled in automatically.
+//
+// Parameters:
+//   - req: Payment intent ID, client secret, payment link ID, and payment method details
+//
+// Returns:
+//   - *developer.PaymentIntentUpdateResp: The updated payment intent with its charge
+//   - error: nil on success, error on failure
+func (p *PublicClient) UpdatePaymentIntentLink(req *developer.PaymentIntentLinkUpdateRequest) (*developer.PaymentIntentUpdateResp, error) {
+	if req.ID == "" || req.ClientSecret == "" {
+		return nil, errors.New("payment intent ID and client secret are required")
+	}
+	body := *req
+	body.Key = p.key
+	var response developer.PaymentIntentUpdateResp
+	err := p.client().sendRequest("POST", fmt.Sprintf("/v1/payment_intents/%s/link", req.ID), &body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CreatePayerMaxDropinOrder submits the PayerMax drop-in payment token for a card charge.
+// The publishable key is filled in automatically.
+//
+// Parameters:
+//   - req: Payment intent ID, charge ID, client secret, and the PayerMax payment token
+//
+// Returns:
+//   - *developer.PayerMaxDropinOrderResponse: Whether the order succeeded, with the updated payment intent
+//   - error: nil on success, error on failure
+func (p *PublicClient) CreatePayerMaxDropinOrder(req *developer.PayerMaxDropinOrderRequest) (*developer.PayerMaxDropinOrderResponse, error) {
+	if req.ClientSecret == "" || req.ChargeID == "" || req.PaymentToken == "" {
+		return nil, errors.New("client secret, charge ID and payment token are required")
+	}
+	body := *req
+	body.Key = p.key
+	var response developer.PayerMaxDropinOrderResponse
+	err := p.client().sendRequest("POST", "/v1/payment_intents/payermax/dropin_order", &body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetPaymentLink retrieves the public details of a payment link for rendering a checkout page.
+//
+// Parameters:
+//   - req: The payment link ID
+//
+// Returns:
+//   - *developer.PaymentLinkPublicGetResponse: The payment link with its product and pricing details
+//   - error: nil on success, error on failure (e.g., link not found or inactive)
+func (p *PublicClient) GetPaymentLink(req *developer.PaymentLinkPublicGetRequest) (*developer.PaymentLinkPublicGetResponse, error) {
+	if req.ID == "" {
+		return nil, errors.New("payment link ID is required")
+	}
+	var response developer.PaymentLinkPublicGetResponse
+	err := p.client().sendRequestWithQuery("GET", fmt.Sprintf("/v1/payment_links/%s/public", req.ID), map[string]string{"key": p.key}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,142 @@
+// public_client_test.go contains unit tests for the publishable-key checkout client.
+package martianpay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPublishableKey = "pk_test_public_key_1234"
+
+// publicRequest is a request received by the test server
+type publicRequest struct {
+	Auth  string
+	Path  string
+	Query map[string]string
+	Body  map[string]interface{}
+}
+
+// newTestPublicClient returns a public client whose requests are recorded in requests
+func newTestPublicClient(t *testing.T, requests *[]publicRequest) *PublicClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, _, _ := r.BasicAuth()
+		req := publicRequest{Auth: key, Path: r.URL.Path, Query: map[string]string{}}
+		for k := range r.URL.Query() {
+			req.Query[k] = r.URL.Query().Get(k)
+		}
+		raw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if len(raw) > 0 {
+			require.NoError(t, json.Unmarshal(raw, &req.Body))
+		}
+		*requests = append(*requests, req)
+		writeData(t, w, map[string]interface{}{"id": "pi_1", "success": true})
+	}))
+	t.Cleanup(server.Close)
+
+	p, err := NewPublicClient(testPublishableKey)
+	require.NoError(t, err)
+	p.BaseURL = server.URL
+	return p
+}
+
+func TestNewPublicClientRejectsSecretKeys(t *testing.T) {
+	_, err := NewPublicClient("sk_test_123")
+	assert.ErrorIs(t, err, ErrSecretKeyInPublicClient)
+	_, err = NewPublicClient("sk_live_123")
+	assert.ErrorIs(t, err, ErrSecretKeyInPublicClient)
+	_, err = NewPublicClient("not_a_key")
+	assert.Error(t, err)
+
+	p, err := NewPublicClient("pk_live_123")
+	require.NoError(t, err)
+	assert.Equal(t, developer.ApiKeyModeLive, p.KeyInfo().Mode)
+}
+
+func TestPublicClientSendsKeyAndClientSecret(t *testing.T) {
+	var requests []publicRequest
+	p := newTestPublicClient(t, &requests)
+
+	_, err := p.GetPaymentIntent(&developer.PaymentIntentGetRequest{ID: "pi_1", ClientSecret: "pi_1_secret"})
+	require.NoError(t, err)
+	methodType := developer.PaymentMethodTypeCrypto
+	_, err = p.UpdatePaymentIntentLink(&developer.PaymentIntentLinkUpdateRequest{
+		ID: "pi_1", ClientSecret: "pi_1_secret", PaymentLinkId: "plink_1",
+		PaymentMethodType: &methodType, PaymentMethodData: &developer.PaymentMethodConfirmOptions{},
+	})
+	require.NoError(t, err)
+	_, err = p.CreatePayerMaxDropinOrder(&developer.PayerMaxDropinOrderRequest{PaymentIntentID: "pi_1", ChargeID: "ch_1", ClientSecret: "pi_1_secret", PaymentToken: "tok_1"})
+	require.NoError(t, err)
+	_, err = p.GetPaymentLink(&developer.PaymentLinkPublicGetRequest{ID: "plink_1"})
+	require.NoError(t, err)
+
+	require.Len(t, requests, 4)
+	for _, req := range requests {
+		assert.Equal(t, testPublishableKey, req.Auth, req.Path)
+	}
+
+	assert.Equal(t, "/v1/payment_intents/pi_1", requests[0].Path)
+	assert.Equal(t, map[string]string{"key": testPublishableKey, "client_secret": "pi_1_secret"}, requests[0].Query)
+
+	assert.Equal(t, "/v1/payment_intents/pi_1/link", requests[1].Path)
+	assert.Equal(t, testPublishableKey, requests[1].Body["key"])
+	assert.Equal(t, "pi_1_secret", requests[1].Body["client_secret"])
+	assert.Equal(t, "plink_1", requests[1].Body["payment_link_id"])
+
+	assert.Equal(t, "/v1/payment_intents/payermax/dropin_order", requests[2].Path)
+	assert.Equal(t, testPublishableKey, requests[2].Body["key"])
+	assert.Equal(t, "pi_1_secret", requests[2].Body["client_secret"])
+	assert.Equal(t, "tok_1", requests[2].Body["payment_token"])
+
+	assert.Equal(t, "/v1/payment_links/plink_1/public", requests[3].Path)
+	assert.Equal(t, map[string]string{"key": testPublishableKey}, requests[3].Query)
+}
+
+func TestPublicClientValidatesBeforeSending(t *testing.T) {
+	var requests []publicRequest
+	p := newTestPublicClient(t, &requests)
+
+	_, err := p.GetPaymentIntent(&developer.PaymentIntentGetRequest{ID: "pi_1"})
+	assert.Error(t, err)
+	_, err = p.UpdatePaymentIntentLink(&developer.PaymentIntentLinkUpdateRequest{ID: "pi_1"})
+	assert.Error(t, err)
+	_, err = p.CreatePayerMaxDropinOrder(&developer.PayerMaxDropinOrderRequest{ChargeID: "ch_1", ClientSecret: "pi_1_secret"})
+	assert.Error(t, err)
+	_, err = p.GetPaymentLink(&developer.PaymentLinkPublicGetRequest{})
+	assert.Error(t, err)
+	assert.Empty(t, requests)
+}
+
+func TestPublicClientNeverSendsSecretKey(t *testing.T) {
+	var requests []publicRequest
+	p := newTestPublicClient(t, &requests)
+
+	// The internal client only accepts publishable keys, even if a secret key reaches it
+	c := p.client()
+	c.Credentials = StaticCredentials("sk_test_secret_key_1")
+	err := c.sendRequest("GET", "/v1/payment_intents/pi_1", nil, nil)
+	assert.ErrorIs(t, err, ErrSecretKeyInPublicClient)
+
+	// and only the mode of the key it was created with
+	c.Credentials = StaticCredentials("pk_live_public_key_1")
+	err = c.sendRequest("GET", "/v1/payment_intents/pi_1", nil, nil)
+	assert.ErrorIs(t, err, ErrModeNotAllowed)
+	assert.Empty(t, requests)
+}
+
+func TestPublicClientRedactsKey(t *testing.T) {
+	p, err := NewPublicClient(testPublishableKey)
+	require.NoError(t, err)
+	for _, s := range []string{p.String(), p.GoString(), fmt.Sprintf("%v", p), fmt.Sprintf("%#v", p)} {
+		assert.NotContains(t, s, "public_key_1234")
+		assert.Contains(t, s, "pk_test_…1234")
+	}
+}
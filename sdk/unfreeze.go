@@ -0,0 +1,126 @@
+// Package martianpay provides SDK methods for managing funds frozen by AML screening.
+// Frozen funds are either released to the merchant balance or reversed to the sender,
+// and each decision creates an unfreeze withdrawal that can be tracked until it completes.
+package martianpay
+
+import (
+	"fmt"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// CreateUnfreeze requests an unfreeze of the frozen funds of a payment intent.
+//
+// Parameters:
+//   - req: Payment intent ID, unfreeze type, and the return address and external ID for reverses
+//
+// Returns:
+//   - *developer.UnfreezeCreateResponse: The created unfreeze withdrawal
+//   - error: nil on success, error on failure (e.g., no frozen funds or missing address)
+func (c *Client) CreateUnfreeze(req *developer.UnfreezeCreateRequest) (*developer.UnfreezeCreateResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var response developer.UnfreezeCreateResponse
+	err := c.sendRequest("POST", "/v1/unfreezes", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ReleaseFrozenFunds releases the frozen funds of a payment intent to the merchant balance.
+//
+// Parameters:
+//   - paymentIntentID: The payment intent with frozen funds
+//   - description: Why the funds are released
+//
+// Returns:
+//   - *developer.UnfreezeCreateResponse: The created unfreeze withdrawal
+//   - error: nil on success, error on failure
+func (c *Client) ReleaseFrozenFunds(paymentIntentID, description string) (*developer.UnfreezeCreateResponse, error) {
+	return c.CreateUnfreeze(developer.NewUnfreezeRelease(paymentIntentID, description))
+}
+
+// ReverseFrozenFunds sends the frozen funds of a payment intent back to a source address.
+//
+// Parameters:
+//   - paymentIntentID: The payment intent with frozen funds
+//   - address: The address to return the funds to
+//   - externalID: Merchant reference that makes the request idempotent
+//   - description: Why the funds are reversed
+//
+// Returns:
+//   - *developer.UnfreezeCreateResponse: The created unfreeze withdrawal
+//   - error: nil on success, error on failure
+func (c *Client) ReverseFrozenFunds(paymentIntentID, address, externalID, description string) (*developer.UnfreezeCreateResponse, error) {
+	return c.CreateUnfreeze(developer.NewUnfreezeReverse(paymentIntentID, address, externalID, description))
+}
+
+// GetUnfreeze retrieves an unfreeze withdrawal to track its status.
+//
+// Parameters:
+//   - id: The unique identifier of the unfreeze withdrawal
+//
+// Returns:
+//   - *developer.UnfreezeGetResponse: The unfreeze withdrawal
+//   - error: nil on success, error on failure (e.g., not found)
+func (c *Client) GetUnfreeze(id string) (*developer.UnfreezeGetResponse, error) {
+	var response developer.UnfreezeGetResponse
+	err := c.sendRequest("GET", fmt.Sprintf("/v1/unfreezes/%s", id), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListUnfreezes retrieves a paginated list of unfreeze withdrawals.
+//
+// Parameters:
+//   - req: Pagination and filters for payment intent, type, and status
+//
+// Returns:
+//   - *developer.UnfreezeListResponse: One page of unfreeze withdrawals
+//   - error: nil on success, error on failure
+func (c *Client) ListUnfreezes(req *developer.UnfreezeListRequest) (*developer.UnfreezeListResponse, error) {
+	var response developer.UnfreezeListResponse
+	err := c.sendRequestWithQuery("GET", "/v1/unfreezes", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetFrozenFunds retrieves a payment intent and returns its frozen funds per asset.
+//
+// Parameters:
+//   - paymentIntentID: The payment intent to check
+//
+// Returns:
+//   - []*developer.FrozenFunds: Frozen, unfreezing, and remaining amounts per asset
+//   - error: nil on success, error on failure
+func (c *Client) GetFrozenFunds(paymentIntentID string) ([]*developer.FrozenFunds, error) {
+	pi, err := c.GetPaymentIntent(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+	return developer.FrozenFundsOf(&pi.PaymentIntent)
+}
+
+// GetFrozenQueue lists a page of payment intents and returns the frozen funds that still need a decision, oldest first.
+// Intents are checked by their AML-rejected transactions rather than their status, since a payment can
+// contain a frozen transaction and still be paid in full by others.
+//
+// Parameters:
+//   - req: Pagination and filters for the payment intents to check
+//
+// Returns:
+//   - []*developer.FrozenFunds: Frozen funds with a remaining amount
+//   - error: nil on success, error on failure
+func (c *Client) GetFrozenQueue(req *developer.PaymentIntentListRequest) ([]*developer.FrozenFunds, error) {
+	page, err := c.ListPaymentIntents(req)
+	if err != nil {
+		return nil, err
+	}
+	return developer.BuildFrozenQueue(page.PaymentIntents)
+}
@@ -0,0 +1,26 @@
+// unfreeze_test.go contains unit tests for the unfreeze methods.
+package martianpay
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListUnfreezesSendsPaginationAndFilters(t *testing.T) {
+	var query url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/unfreezes", r.URL.Path)
+		query = r.URL.Query()
+		writeData(t, w, developer.UnfreezeListResponse{})
+	})
+
+	pi := "pi_1"
+	_, err := c.ListUnfreezes(&developer.UnfreezeListRequest{Pagination: developer.Pagination{Page: 3, PageSize: 25}, PaymentIntentID: &pi})
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"page": {"3"}, "page_size": {"25"}, "payment_intent_id": {"pi_1"}}, query)
+}
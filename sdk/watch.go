@@ -0,0 +1,385 @@
+// Package martianpay provides a watcher that polls a resource and streams its status changes.
+// It complements webhooks inside workflows that need to block until a payment intent, payout,
+// refund, payroll, or subscription reaches a given status. Polling starts fast and backs off
+// while nothing changes, and speeds up again after every change.
+package martianpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// WatchResource is a type of resource that can be watched
+type WatchResource string
+
+const (
+	// WatchPaymentIntent watches a payment intent (object: *developer.PaymentIntentGetResp)
+	WatchPaymentIntent WatchResource = "payment_intent"
+	// WatchPayout watches a payout (object: *developer.PayoutGetResp)
+	WatchPayout WatchResource = "payout"
+	// WatchRefund watches a refund (object: *developer.RefundGetResp)
+	WatchRefund WatchResource = "refund"
+	// WatchPayroll watches a payroll (object: *developer.PayrollGetResponse)
+	WatchPayroll WatchResource = "payroll"
+	// WatchSubscription watches a subscription (object: *developer.SubscriptionDetails)
+	WatchSubscription WatchResource = "subscription"
+)
+
+const (
+	// DefaultWatchInitialInterval is the first polling interval and the interval after each change
+	DefaultWatchInitialInterval = time.Second
+	// DefaultWatchMaxInterval caps the polling interval while the status is unchanged
+	DefaultWatchMaxInterval = 30 * time.Second
+	// DefaultWatchMaxErrors is the number of consecutive failed polls after which the watch stops
+	DefaultWatchMaxErrors = 5
+)
+
+// terminalStatuses are the statuses after which a resource no longer changes
+var terminalStatuses = map[WatchResource]map[string]bool{
+	WatchPayout: {
+		string(developer.PayoutStatusPaid): true, string(developer.PayoutStatusFailed): true,
+		string(developer.PayoutStatusCanceled): true, string(developer.PayoutStatusRejected): true,
+	},
+	WatchRefund:       {"Success": true, "Failed": true, "Canceled": true},
+	WatchPayroll:      {"completed": true, "failed": true, "canceled": true, "rejected": true},
+	WatchSubscription: {string(developer.SubscriptionStatusCanceled): true},
+}
+
+// IsTerminalStatus reports whether a resource in the given status can no longer change
+func IsTerminalStatus(resource WatchResource, status string) bool {
+	if resource == WatchPaymentIntent {
+		return developer.PaymentIntentStatus(status).IsTerminal()
+	}
+	return terminalStatuses[resource][status]
+}
+
+// StatusChange is an observed change of a resource's status
+type StatusChange struct {
+	// Resource is the type of the watched resource
+	Resource WatchResource
+	// ID is the ID of the watched resource
+	ID string
+	// From is the previous status ("" for the first observation)
+	From string
+	// To is the new status
+	To string
+	// Object is the resource as returned by its Get method
+	Object interface{}
+	// ObservedAt is when the change was observed
+	ObservedAt time.Time
+}
+
+// WatchOptions configures a watch
+type WatchOptions struct {
+	// Until stops the watch when it returns true for a new status (default: a terminal status)
+	Until func(status string) bool
+	// InitialInterval is the first polling interval (default DefaultWatchInitialInterval)
+	InitialInterval time.Duration
+	// MaxInterval caps the polling interval (default DefaultWatchMaxInterval)
+	MaxInterval time.Duration
+	// MaxErrors is the number of consecutive failed polls that end the watch (default DefaultWatchMaxErrors)
+	MaxErrors int
+}
+
+// Watcher streams the status changes of one resource
+type Watcher struct {
+	// Changes receives every status change, starting with the initial status.
+	// It is closed when the watch ends; read it until closed, or call Wait.
+	Changes <-chan StatusChange
+
+	done chan struct{}
+	last *StatusChange
+	err  error
+}
+
+// Wait discards any unread changes, blocks until the watch ends, and returns the last change
+//
+// Returns:
+//   - *StatusChange: The change that satisfied Until, or the last change seen
+//   - error: nil if Until matched, the context error, or the error that ended polling
+func (w *Watcher) Wait() (*StatusChange, error) {
+	for range w.Changes {
+	}
+	<-w.done
+	return w.last, w.err
+}
+
+// Watch polls a resource and streams its status changes until opts.Until matches,
+// the resource reaches a terminal status (when Until is nil), or ctx ends.
+//
+// Parameters:
+//   - ctx: Context that bounds the watch, including a poll request that is in flight
+//   - resource: The type of resource to watch
+//   - id: The ID of the resource
+//   - opts: Optional settings, nil for defaults
+//
+// Returns:
+//   - *Watcher: The running watcher
+//   - error: Error if the resource type is not supported
+func (c *Client) Watch(ctx context.Context, resource WatchResource, id string, opts *WatchOptions) (*Watcher, error) {
+	fetch, err := c.statusFetcher(resource)
+	if err != nil {
+		return nil, err
+	}
+	o := WatchOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.InitialInterval <= 0 {
+		o.InitialInterval = DefaultWatchInitialInterval
+	}
+	if o.MaxInterval < o.InitialInterval {
+		o.MaxInterval = DefaultWatchMaxInterval
+		if o.MaxInterval < o.InitialInterval {
+			o.MaxInterval = o.InitialInterval
+		}
+	}
+	if o.MaxErrors <= 0 {
+		o.MaxErrors = DefaultWatchMaxErrors
+	}
+	if o.Until == nil {
+		o.Until = func(status string) bool { return IsTerminalStatus(resource, status) }
+	}
+
+	changes := make(chan StatusChange, 16)
+	w := &Watcher{Changes: changes, done: make(chan struct{})}
+	go w.run(ctx, resource, id, fetch, o, changes)
+	return w, nil
+}
+
+// run is the polling loop of a watcher
+func (w *Watcher) run(ctx context.Context, resource WatchResource, id string, fetch statusFetcher, o WatchOptions, changes chan<- StatusChange) {
+	defer close(w.done)
+	defer close(changes)
+
+	interval := o.InitialInterval
+	failures := 0
+	status := ""
+	for {
+		next, object, err := fetch(ctx, id)
+		if ctx.Err() != nil {
+			w.err = ctx.Err()
+			return
+		}
+		if err != nil {
+			failures++
+			if failures >= o.MaxErrors {
+				w.err = fmt.Errorf("watching %s %s: %w", resource, id, err)
+				return
+			}
+		} else {
+			failures = 0
+			if w.last == nil || next != status {
+				change := StatusChange{Resource: resource, ID: id, From: status, To: next, Object: object, ObservedAt: time.Now()}
+				w.last = &change
+				status = next
+				interval = o.InitialInterval
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					w.err = ctx.Err()
+					return
+				}
+				if o.Until(next) {
+					return
+				}
+			} else {
+				// Unchanged: back off, up to MaxInterval
+				interval += interval / 2
+				if interval > o.MaxInterval {
+					interval = o.MaxInterval
+				}
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			w.err = ctx.Err()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// statusFetcher loads a resource and returns its status; the request is bound to ctx
+type statusFetcher func(ctx context.Context, id string) (string, interface{}, error)
+
+// statusFetcher returns the fetch function for a resource type
+func (c *Client) statusFetcher(resource WatchResource) (statusFetcher, error) {
+	switch resource {
+	case WatchPaymentIntent:
+		return func(ctx context.Context, id string) (string, interface{}, error) {
+			pi, err := c.withContext(ctx).GetPaymentIntent(id)
+			if err != nil {
+				return "", nil, err
+			}
+			status := pi.PaymentIntentStatus
+			if status == "" {
+				status = developer.PaymentIntentStatus(pi.Status)
+			}
+			return string(status), pi, nil
+		}, nil
+	case WatchPayout:
+		return func(ctx context.Context, id string) (string, interface{}, error) {
+			payout, err := c.withContext(ctx).GetPayout(id)
+			if err != nil {
+				return "", nil, err
+			}
+			return string(payout.Status), payout, nil
+		}, nil
+	case WatchRefund:
+		return func(ctx context.Context, id string) (string, interface{}, error) {
+			refund, err := c.withContext(ctx).GetRefund(id)
+			if err != nil {
+				return "", nil, err
+			}
+			return refund.Status, refund, nil
+		}, nil
+	case WatchPayroll:
+		return func(ctx context.Context, id string) (string, interface{}, error) {
+			payroll, err := c.withContext(ctx).GetPayroll(id)
+			if err != nil {
+				return "", nil, err
+			}
+			if payroll.Payroll == nil {
+				return "", nil, errors.New("payroll response has no payroll")
+			}
+			return payroll.Payroll.Status, payroll, nil
+		}, nil
+	case WatchSubscription:
+		return func(ctx context.Context, id string) (string, interface{}, error) {
+			sub, err := c.withContext(ctx).GetSubscription(id)
+			if err != nil {
+				return "", nil, err
+			}
+			return sub.Status, sub, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported watch resource %q", resource)
+}
+
+// UnexpectedStatusError is returned by the WaitFor helpers when the resource reaches a
+// terminal status other than the ones waited for
+type UnexpectedStatusError struct {
+	// Resource is the type of the watched resource
+	Resource WatchResource
+	// ID is the ID of the watched resource
+	ID string
+	// Status is the terminal status that was reached
+	Status string
+	// Want are the statuses that were waited for
+	Want []string
+}
+
+func (e *UnexpectedStatusError) Error() string {
+	return fmt.Sprintf("%s %s reached terminal status %q while waiting for %v", e.Resource, e.ID, e.Status, e.Want)
+}
+
+// waitFor watches a resource until it reaches one of want, or a terminal status
+func (c *Client) waitFor(ctx context.Context, resource WatchResource, id string, want []string) (interface{}, error) {
+	if len(want) == 0 {
+		return nil, errors.New("at least one status to wait for is required")
+	}
+	wanted := make(map[string]bool, len(want))
+	for _, s := range want {
+		wanted[s] = true
+	}
+	w, err := c.Watch(ctx, resource, id, &WatchOptions{
+		Until: func(status string) bool { return wanted[status] || IsTerminalStatus(resource, status) },
+	})
+	if err != nil {
+		return nil, err
+	}
+	last, err := w.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if !wanted[last.To] {
+		return last.Object, &UnexpectedStatusError{Resource: resource, ID: id, Status: last.To, Want: want}
+	}
+	return last.Object, nil
+}
+
+// WaitForPaymentIntent blocks until the payment intent reaches one of the given statuses.
+//
+// Parameters:
+//   - ctx: Context that bounds the wait (use a deadline)
+//   - id: The payment intent ID
+//   - statuses: Statuses to wait for, e.g. developer.PaymentIntentStatusConfirmed
+//
+// Returns:
+//   - *developer.PaymentIntentGetResp: The payment intent in the reached status
+//   - error: *UnexpectedStatusError if it reached another terminal status (the payment intent is
+//     still returned), the context error, or a polling error
+func (c *Client) WaitForPaymentIntent(ctx context.Context, id string, statuses ...developer.PaymentIntentStatus) (*developer.PaymentIntentGetResp, error) {
+	want := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		want = append(want, string(s))
+	}
+	obj, err := c.waitFor(ctx, WatchPaymentIntent, id, want)
+	pi, _ := obj.(*developer.PaymentIntentGetResp)
+	return pi, err
+}
+
+// WaitForPayout blocks until the payout reaches one of the given statuses.
+//
+// Parameters:
+//   - ctx: Context that bounds the wait
+//   - id: The payout ID
+//   - statuses: Statuses to wait for, e.g. developer.PayoutStatusPaid
+//
+// Returns:
+//   - *developer.PayoutGetResp: The payout in the reached status
+//   - error: *UnexpectedStatusError if it reached another terminal status, the context error, or a polling error
+func (c *Client) WaitForPayout(ctx context.Context, id string, statuses ...developer.PayoutStatus) (*developer.PayoutGetResp, error) {
+	want := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		want = append(want, string(s))
+	}
+	obj, err := c.waitFor(ctx, WatchPayout, id, want)
+	payout, _ := obj.(*developer.PayoutGetResp)
+	return payout, err
+}
+
+// WaitForRefund blocks until the refund reaches one of the given statuses (e.g. "Success").
+//
+// Returns:
+//   - *developer.RefundGetResp: The refund in the reached status
+//   - error: *UnexpectedStatusError if it reached another terminal status, the context error, or a polling error
+func (c *Client) WaitForRefund(ctx context.Context, id string, statuses ...string) (*developer.RefundGetResp, error) {
+	obj, err := c.waitFor(ctx, WatchRefund, id, statuses)
+	refund, _ := obj.(*developer.RefundGetResp)
+	return refund, err
+}
+
+// WaitForPayroll blocks until the payroll reaches one of the given statuses (e.g. "completed").
+//
+// Returns:
+//   - *developer.PayrollGetResponse: The payroll in the reached status
+//   - error: *UnexpectedStatusError if it reached another terminal status, the context error, or a polling error
+func (c *Client) WaitForPayroll(ctx context.Context, id string, statuses ...string) (*developer.PayrollGetResponse, error) {
+	obj, err := c.waitFor(ctx, WatchPayroll, id, statuses)
+	payroll, _ := obj.(*developer.PayrollGetResponse)
+	return payroll, err
+}
+
+// WaitForSubscription blocks until the subscription reaches one of the given statuses.
+//
+// Returns:
+//   - *developer.SubscriptionDetails: The subscription in the reached status
+//   - error: *UnexpectedStatusError if it reached another terminal status, the context error, or a polling error
+func (c *Client) WaitForSubscription(ctx context.Context, id string, statuses ...developer.SubscriptionStatus) (*developer.SubscriptionDetails, error) {
+	want := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		want = append(want, string(s))
+	}
+	obj, err := c.waitFor(ctx, WatchSubscription, id, want)
+	sub, _ := obj.(*developer.SubscriptionDetails)
+	return sub, err
+}
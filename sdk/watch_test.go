@@ -0,0 +1,158 @@
+// watch_test.go contains unit tests for the status watcher and the WaitFor helpers.
+package martianpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// payoutServer serves a payout whose status is taken from statuses, one per poll,
+// repeating the last one, and records when each poll arrived
+type payoutServer struct {
+	mu       sync.Mutex
+	statuses []developer.PayoutStatus
+	polls    []time.Time
+}
+
+func (s *payoutServer) handle(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		n := len(s.polls)
+		s.polls = append(s.polls, time.Now())
+		status := s.statuses[len(s.statuses)-1]
+		if n < len(s.statuses) {
+			status = s.statuses[n]
+		}
+		s.mu.Unlock()
+		writeData(t, w, developer.PayoutGetResp{Payout: developer.Payout{ID: "po_1", Status: status}})
+	}
+}
+
+func (s *payoutServer) gaps() []time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var gaps []time.Duration
+	for i := 1; i < len(s.polls); i++ {
+		gaps = append(gaps, s.polls[i].Sub(s.polls[i-1]))
+	}
+	return gaps
+}
+
+func TestWatchBacksOffUpToMaxInterval(t *testing.T) {
+	srv := &payoutServer{statuses: []developer.PayoutStatus{developer.PayoutStatusPending, developer.PayoutStatusPending, developer.PayoutStatusPending,
+		developer.PayoutStatusPending, developer.PayoutStatusPending, developer.PayoutStatusPending, developer.PayoutStatusPending, developer.PayoutStatusPaid}}
+	c := newTestClient(t, srv.handle(t))
+
+	w, err := c.Watch(context.Background(), WatchPayout, "po_1", &WatchOptions{InitialInterval: 10 * time.Millisecond, MaxInterval: 40 * time.Millisecond})
+	require.NoError(t, err)
+	var changes []StatusChange
+	for change := range w.Changes {
+		changes = append(changes, change)
+	}
+	last, err := w.Wait()
+	require.NoError(t, err)
+	assert.Equal(t, "paid", last.To)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "", changes[0].From)
+	assert.Equal(t, "pending", changes[0].To)
+	assert.Equal(t, "pending", changes[1].From)
+
+	// The first status is a change, then unchanged polls back off by half each time:
+	// 15ms, 22ms, 33ms, then the 40ms cap. Without the cap the last gaps would be 50ms, 75ms and 113ms.
+	gaps := srv.gaps()
+	require.Len(t, gaps, 7)
+	want := []time.Duration{10, 15, 22, 33, 40, 40, 40}
+	for i, gap := range gaps {
+		assert.GreaterOrEqual(t, gap, want[i]*time.Millisecond-time.Millisecond, "gap %d", i)
+	}
+	assert.Less(t, gaps[6], 100*time.Millisecond, "the interval is capped at MaxInterval")
+}
+
+func TestWatchStopsOnUntil(t *testing.T) {
+	srv := &payoutServer{statuses: []developer.PayoutStatus{developer.PayoutStatusPending, developer.PayoutStatusInTransit, developer.PayoutStatusPaid}}
+	c := newTestClient(t, srv.handle(t))
+
+	w, err := c.Watch(context.Background(), WatchPayout, "po_1", &WatchOptions{
+		InitialInterval: time.Millisecond,
+		Until:           func(status string) bool { return status == string(developer.PayoutStatusInTransit) },
+	})
+	require.NoError(t, err)
+	last, err := w.Wait()
+	require.NoError(t, err)
+	assert.Equal(t, "in_transit", last.To)
+	assert.Equal(t, "pending", last.From)
+	assert.Len(t, srv.gaps(), 1, "no poll after Until matched")
+}
+
+func TestWatchMaxErrors(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	w, err := c.Watch(context.Background(), WatchPayout, "po_1", &WatchOptions{InitialInterval: time.Millisecond, MaxErrors: 3})
+	require.NoError(t, err)
+	last, err := w.Wait()
+	assert.Nil(t, last)
+	assert.ErrorContains(t, err, "watching payout po_1")
+	assert.ErrorContains(t, err, "503")
+	mu.Lock()
+	assert.Equal(t, 3, requests)
+	mu.Unlock()
+
+	_, err = c.Watch(context.Background(), WatchResource("invoice"), "in_1", nil)
+	assert.Error(t, err)
+}
+
+func TestWatchContextCancelsInFlightRequest(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// Hang until the test ends, like an unresponsive server
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.WaitForPayout(ctx, "po_1", developer.PayoutStatusPaid)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second, "the hung request is aborted at the deadline")
+}
+
+func TestWaitForUnexpectedStatus(t *testing.T) {
+	srv := &payoutServer{statuses: []developer.PayoutStatus{developer.PayoutStatusPending, developer.PayoutStatusFailed}}
+	c := newTestClient(t, srv.handle(t))
+
+	payout, err := c.WaitForPayout(context.Background(), "po_1", developer.PayoutStatusPaid)
+	var unexpected *UnexpectedStatusError
+	require.True(t, errors.As(err, &unexpected))
+	assert.Equal(t, "failed", unexpected.Status)
+	assert.Equal(t, []string{"paid"}, unexpected.Want)
+	require.NotNil(t, payout, "the payout is returned with the error")
+	assert.Equal(t, developer.PayoutStatusFailed, payout.Status)
+
+	// The first observed status already matches
+	srv.polls = nil
+	payout, err = c.WaitForPayout(context.Background(), "po_1", developer.PayoutStatusPending)
+	require.NoError(t, err)
+	assert.Equal(t, developer.PayoutStatusPending, payout.Status)
+
+	_, err = c.WaitForPayout(context.Background(), "po_1")
+	assert.Error(t, err)
+}
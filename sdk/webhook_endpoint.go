@@ -0,0 +1,167 @@
+// Package martianpay provides SDK methods for managing webhook endpoints.
+// Webhook endpoints receive signed event notifications for the event types they are subscribed to.
+package martianpay
+
+import (
+	"fmt"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+)
+
+// CreateWebhookEndpoint registers a new webhook endpoint.
+// The URL must be an https endpoint and EnabledEvents must contain known event types, family wildcards, or "*".
+//
+// Parameters:
+//   - req: Endpoint URL, description, and the event types to subscribe to
+//
+// Returns:
+//   - *developer.WebhookEndpoint: The created endpoint, including its signing secret
+//   - error: nil on success, error on validation or API failure
+func (c *Client) CreateWebhookEndpoint(req *developer.WebhookEndpointParams) (*developer.WebhookEndpoint, error) {
+	if err := req.ValidateForCreate(); err != nil {
+		return nil, err
+	}
+	var response developer.WebhookEndpoint
+	err := c.sendRequest("POST", "/v1/webhook_endpoints", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetWebhookEndpoint retrieves a webhook endpoint by ID.
+//
+// Parameters:
+//   - id: The unique identifier of the webhook endpoint
+//
+// Returns:
+//   - *developer.WebhookEndpoint: The webhook endpoint details
+//   - error: nil on success, error on failure (e.g., endpoint not found)
+func (c *Client) GetWebhookEndpoint(id string) (*developer.WebhookEndpoint, error) {
+	var response developer.WebhookEndpoint
+	err := c.sendRequest("GET", fmt.Sprintf("/v1/webhook_endpoints/%s", id), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListWebhookEndpoints retrieves a paginated list of webhook endpoints.
+//
+// Parameters:
+//   - req: Pagination parameters
+//
+// Returns:
+//   - *developer.WebhookEndpointListResp: List of webhook endpoints with pagination metadata
+//   - error: nil on success, error on failure
+func (c *Client) ListWebhookEndpoints(req *developer.WebhookEndpointListRequest) (*developer.WebhookEndpointListResp, error) {
+	var response developer.WebhookEndpointListResp
+	err := c.sendRequestWithQuery("GET", "/v1/webhook_endpoints", req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateWebhookEndpoint updates the URL, description, or enabled events of a webhook endpoint.
+// Only the fields that are set are sent, so the others are left unchanged.
+//
+// Parameters:
+//   - id: The unique identifier of the webhook endpoint
+//   - req: Fields to update
+//
+// Returns:
+//   - *developer.WebhookEndpoint: The updated webhook endpoint
+//   - error: nil on success, error on validation or API failure
+func (c *Client) UpdateWebhookEndpoint(id string, req *developer.WebhookEndpointUpdateRequest) (*developer.WebhookEndpoint, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var response developer.WebhookEndpoint
+	err := c.sendRequest("POST", fmt.Sprintf("/v1/webhook_endpoints/%s", id), req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SetWebhookEndpointEvents replaces the event types a webhook endpoint is subscribed to.
+//
+// Parameters:
+//   - id: The unique identifier of the webhook endpoint
+//   - events: Event types or family wildcards such as "payout.*" to subscribe to, or ["*"] for all events
+//
+// Returns:
+//   - *developer.WebhookEndpoint: The updated webhook endpoint
+//   - error: nil on success, error on validation or API failure
+func (c *Client) SetWebhookEndpointEvents(id string, events []developer.EventType) (*developer.WebhookEndpoint, error) {
+	enabled := make([]string, 0, len(events))
+	for _, e := range events {
+		enabled = append(enabled, string(e))
+	}
+	return c.UpdateWebhookEndpoint(id, &developer.WebhookEndpointUpdateRequest{EnabledEvents: enabled})
+}
+
+// DisableWebhookEndpoint stops event delivery to a webhook endpoint without deleting it.
+//
+// Parameters:
+//   - id: The unique identifier of the webhook endpoint
+//
+// Returns:
+//   - *developer.WebhookEndpoint: The webhook endpoint with status "inactive"
+//   - error: nil on success, error on failure
+func (c *Client) DisableWebhookEndpoint(id string) (*developer.WebhookEndpoint, error) {
+	return c.setWebhookEndpointStatus(id, developer.DeveloperStatusInactive)
+}
+
+// EnableWebhookEndpoint resumes event delivery to a disabled webhook endpoint.
+//
+// Parameters:
+//   - id: The unique identifier of the webhook endpoint
+//
+// Returns:
+//   - *developer.WebhookEndpoint: The webhook endpoint with status "active"
+//   - error: nil on success, error on failure
+func (c *Client) EnableWebhookEndpoint(id string) (*developer.WebhookEndpoint, error) {
+	return c.setWebhookEndpointStatus(id, developer.DeveloperStatusActive)
+}
+
+// setWebhookEndpointStatus updates the status of a webhook endpoint
+func (c *Client) setWebhookEndpointStatus(id, status string) (*developer.WebhookEndpoint, error) {
+	var response developer.WebhookEndpoint
+	req := &developer.WebhookEndpointStatusUpdateRequest{Status: status}
+	err := c.sendRequest("POST", fmt.Sprintf("/v1/webhook_endpoints/%s", id), req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RollWebhookEndpointSecret replaces the signing secret of a webhook endpoint.
+// Events signed after the roll use the new secret, so handlers must be updated with it.
+//
+// Parameters:
+//   - id: The unique identifier of the webhook endpoint
+//
+// Returns:
+//   - *developer.WebhookEndpoint: The webhook endpoint with its new secret
+//   - error: nil on success, error on failure
+func (c *Client) RollWebhookEndpointSecret(id string) (*developer.WebhookEndpoint, error) {
+	var response developer.WebhookEndpoint
+	err := c.sendRequest("POST", fmt.Sprintf("/v1/webhook_endpoints/%s/roll_secret", id), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteWebhookEndpoint permanently deletes a webhook endpoint.
+//
+// Parameters:
+//   - id: The unique identifier of the webhook endpoint
+//
+// Returns:
+//   - error: nil on success, error on failure
+func (c *Client) DeleteWebhookEndpoint(id string) error {
+	return c.sendRequest("DELETE", fmt.Sprintf("/v1/webhook_endpoints/%s", id), nil, nil)
+}
@@ -0,0 +1,31 @@
+// webhook_endpoint_test.go contains unit tests for the webhook endpoint methods.
+package martianpay
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MartianPay/martianpay-go-sample/pkg/developer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateWebhookEndpointSendsOnlySetFields(t *testing.T) {
+	var body map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/webhook_endpoints/wh_1", r.URL.Path)
+		body = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		writeData(t, w, developer.WebhookEndpoint{ID: "wh_1"})
+	})
+
+	description := "orders"
+	_, err := c.UpdateWebhookEndpoint("wh_1", &developer.WebhookEndpointUpdateRequest{Description: &description})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"description": "orders"}, body)
+
+	_, err = c.SetWebhookEndpointEvents("wh_1", []developer.EventType{"payout.*", developer.EventTypeRefundCreated})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"enabled_events": []interface{}{"payout.*", "refund.created"}}, body)
+}